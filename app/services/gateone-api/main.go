@@ -9,13 +9,27 @@ import (
 	"github.com/fadhilijuma/gateone-service/app/services/gateone-api/v1/build/all"
 	"github.com/fadhilijuma/gateone-service/app/services/gateone-api/v1/build/crud"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate/stores/outboxdb"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/user/stores/usercache"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/webhook"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/webhook/stores/webhookdb"
 	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/debug"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/health"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/idempotency/stores/idempotencydb"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/metrics"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/mux"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/ratelimit"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/ratelimit/stores/ratelimitdb"
+	"github.com/fadhilijuma/gateone-service/foundation/alert"
+	"github.com/fadhilijuma/gateone-service/foundation/jwks"
 	"github.com/fadhilijuma/gateone-service/foundation/keystore"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/tracing"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"github.com/fadhilijuma/gateone-service/foundation/worker"
 	"net/http"
 	"os"
 	"os/signal"
@@ -34,29 +48,27 @@ var routes = "all" // go build -ldflags "-X main.routes=crud"
 func main() {
 	var log *logger.Logger
 
-	events := logger.Events{
-		Error: func(ctx context.Context, r logger.Record) {
-			log.Info(ctx, "******* SEND ALERT ******")
-		},
-	}
+	// The alerter is configured once the configuration is loaded, it drops
+	// the records logged before then.
+	alerts := alert.New()
 
 	traceIDFn := func(ctx context.Context) string {
 		return web.GetTraceID(ctx)
 	}
 
-	log = logger.NewWithEvents(os.Stdout, logger.LevelInfo, "GATEONE-API", traceIDFn, events)
+	log = logger.NewWithEvents(os.Stdout, logger.LevelInfo, "GATEONE-API", traceIDFn, alerts.Events())
 
 	// -------------------------------------------------------------------------
 
 	ctx := context.Background()
 
-	if err := run(ctx, log); err != nil {
+	if err := run(ctx, log, alerts); err != nil {
 		log.Error(ctx, "startup", "msg", err)
 		os.Exit(1)
 	}
 }
 
-func run(ctx context.Context, log *logger.Logger) error {
+func run(ctx context.Context, log *logger.Logger, alerts *alert.Alerter) error {
 
 	// -------------------------------------------------------------------------
 	// GOMAXPROCS
@@ -76,11 +88,23 @@ func run(ctx context.Context, log *logger.Logger) error {
 			APIHost            string        `conf:"default:0.0.0.0:3000"`
 			DebugHost          string        `conf:"default:0.0.0.0:4000"`
 			CORSAllowedOrigins []string      `conf:"default:*"`
+			RequireIfMatch     bool          `conf:"default:false"`
+			ReadinessTimeout   time.Duration `conf:"default:1s"`
+			ShutdownDrain      time.Duration `conf:"default:5s"`
+			PublicURL          string        `conf:"help:external base URL of the service advertised in the discovery document"`
+			TrustedProxies     []string      `conf:"help:addresses or CIDR ranges of the proxies X-Forwarded-For is trusted from"`
 		}
 		Auth struct {
-			KeysFolder string `conf:"default:configs/keys/"`
-			ActiveKID  string `conf:"default:54bb2165-71e1-41a6-af3e-7da4a0e1e2c1"`
-			Issuer     string `conf:"default:service project"`
+			KeysFolder           string        `conf:"default:configs/keys/"`
+			ReloadInterval       time.Duration `conf:"default:1m"`
+			Issuer               string        `conf:"default:service project"`
+			Audience             string        `conf:"default:gateone-api"`
+			TokenTTL             time.Duration `conf:"default:1h"`
+			ClockSkew            time.Duration `conf:"default:30s"`
+			JWKSURL              string
+			PolicyPath           string
+			PolicyReloadInterval time.Duration `conf:"default:30s"`
+			DecisionLogFile      string
 		}
 		DB struct {
 			User         string `conf:"default:postgres"`
@@ -91,6 +115,68 @@ func run(ctx context.Context, log *logger.Logger) error {
 			MaxOpenConns int    `conf:"default:0"`
 			DisableTLS   bool   `conf:"default:true"`
 		}
+		UserCache struct {
+			Capacity int           `conf:"default:10000"`
+			TTL      time.Duration `conf:"default:5m"`
+		}
+		RateLimit struct {
+			Store         string        `conf:"default:memory,help:memory or postgres"`
+			Rate          float64       `conf:"default:10"`
+			Burst         int           `conf:"default:20"`
+			Groups        []string      `conf:"default:tokens:0.2:5,help:per group overrides as group:rate:burst"`
+			PruneInterval time.Duration `conf:"default:10m"`
+		}
+		Idempotency struct {
+			Window        time.Duration `conf:"default:24h"`
+			MaxBodySize   int64         `conf:"default:1048576,help:largest request body in bytes accepted with an idempotency key"`
+			PruneInterval time.Duration `conf:"default:1h"`
+		}
+		Log struct {
+			Level            string        `conf:"default:INFO"`
+			RedactKeys       []string      `conf:"help:attribute keys to redact in addition to the defaults"`
+			SampleTick       time.Duration `conf:"default:1s,help:period repetitive info logs are sampled over, 0 disables sampling"`
+			SampleInitial    int           `conf:"default:100"`
+			SampleThereafter int           `conf:"default:100"`
+		}
+		Delegate struct {
+			Durable     bool          `conf:"default:true,help:deliver delegate calls through the outbox"`
+			Interval    time.Duration `conf:"default:1s"`
+			BatchSize   int           `conf:"default:50"`
+			Lease       time.Duration `conf:"default:1m"`
+			Timeout     time.Duration `conf:"default:30s"`
+			MaxAttempts int           `conf:"default:8"`
+			MinBackoff  time.Duration `conf:"default:1s"`
+			MaxBackoff  time.Duration `conf:"default:10m"`
+			MaxRunning  int           `conf:"default:10"`
+		}
+		Webhook struct {
+			Interval     time.Duration `conf:"default:1s"`
+			BatchSize    int           `conf:"default:50"`
+			Lease        time.Duration `conf:"default:1m"`
+			Timeout      time.Duration `conf:"default:10s"`
+			MaxAttempts  int           `conf:"default:10"`
+			MinBackoff   time.Duration `conf:"default:10s"`
+			MaxBackoff   time.Duration `conf:"default:1h"`
+			DisableAfter int           `conf:"default:20,help:failed deliveries in a row that disable a subscription"`
+			MaxRunning   int           `conf:"default:10"`
+		}
+		Alert struct {
+			QueueSize   int           `conf:"default:1000"`
+			SendTimeout time.Duration `conf:"default:10s"`
+			WebhookURL  string
+			File        string
+			EmailSpool  string `conf:"help:directory of the outbox alert emails are spooled to"`
+			EmailFrom   string `conf:"default:alerts@gateone.local"`
+			EmailTo     []string
+			Warn        alertLevel
+			Error       alertLevel
+		}
+		Tracing struct {
+			ServiceName string  `conf:"default:gateone-api"`
+			Exporter    string  `conf:"default:none,help:none, stdout or otlp"`
+			Endpoint    string  `conf:"default:http://localhost:4318/v1/traces"`
+			Probability float64 `conf:"default:0.05"`
+		}
 	}{
 		Version: conf.Version{
 			Build: build,
@@ -108,6 +194,19 @@ func run(ctx context.Context, log *logger.Logger) error {
 		return fmt.Errorf("parsing config: %w", err)
 	}
 
+	level, err := logger.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return fmt.Errorf("parsing log level: %w", err)
+	}
+
+	log.SetLevel(level)
+	log.SetRedactKeys(cfg.Log.RedactKeys...)
+	log.SetSampling(logger.Sampling{
+		Tick:       cfg.Log.SampleTick,
+		Initial:    cfg.Log.SampleInitial,
+		Thereafter: cfg.Log.SampleThereafter,
+	})
+
 	// -------------------------------------------------------------------------
 	// App Starting
 
@@ -122,6 +221,34 @@ func run(ctx context.Context, log *logger.Logger) error {
 
 	expvar.NewString("build").Set(build)
 
+	// -------------------------------------------------------------------------
+	// Start Alerting Support
+
+	log.Info(ctx, "startup", "status", "initializing alerting support")
+
+	if err := startAlerts(alerts, log, cfg.Alert.QueueSize, cfg.Alert.SendTimeout, alertSinks{
+		webhookURL: cfg.Alert.WebhookURL,
+		file:       cfg.Alert.File,
+		emailSpool: cfg.Alert.EmailSpool,
+		emailFrom:  cfg.Alert.EmailFrom,
+		emailTo:    cfg.Alert.EmailTo,
+	}, map[logger.Level]alertLevel{
+		logger.LevelWarn:  cfg.Alert.Warn,
+		logger.LevelError: cfg.Alert.Error,
+	}); err != nil {
+		return fmt.Errorf("starting alerts: %w", err)
+	}
+	defer func() {
+		log.Info(ctx, "shutdown", "status", "stopping alerting support")
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		if err := alerts.Shutdown(ctx); err != nil {
+			log.Warn(ctx, "shutdown", "status", "flushing alerts", "msg", err)
+		}
+	}()
+
 	// -------------------------------------------------------------------------
 	// Database Support
 
@@ -144,23 +271,95 @@ func run(ctx context.Context, log *logger.Logger) error {
 		db.Close()
 	}()
 
+	if err := metrics.RegisterDB(db.DB, cfg.DB.Name); err != nil {
+		return fmt.Errorf("registering db metrics: %w", err)
+	}
+
+	// -------------------------------------------------------------------------
+	// User Cache Support
+
+	log.Info(ctx, "startup", "status", "initializing user cache support", "capacity", cfg.UserCache.Capacity, "ttl", cfg.UserCache.TTL)
+
+	userCache := usercache.NewCache(usercache.Config{
+		Capacity: cfg.UserCache.Capacity,
+		TTL:      cfg.UserCache.TTL,
+	})
+
+	// Other instances of the service may change users we have cached. The
+	// database notifies every instance so the stale values can be evicted.
+	listenCtx, stopListen := context.WithCancel(ctx)
+	defer stopListen()
+
+	go func() {
+		if err := usercache.Listen(listenCtx, log, db, userCache); err != nil {
+			log.Error(ctx, "shutdown", "status", "user cache listener closed", "msg", err)
+		}
+	}()
+
 	// -------------------------------------------------------------------------
 	// Initialize authentication support
 
 	log.Info(ctx, "startup", "status", "initializing authentication support")
 
-	// Load the private keys files from disk. We can assume some system like
-	// Vault has created these files already. How that happens is not our
-	// concern.
-	ks := keystore.New()
-	if err := ks.LoadRSAKeys(os.DirFS(cfg.Auth.KeysFolder)); err != nil {
-		return fmt.Errorf("reading keys: %w", err)
+	var keyLookup auth.KeyLookup
+
+	switch cfg.Auth.JWKSURL {
+	case "":
+
+		// Load the private keys files from disk. We can assume some system like
+		// Vault has created these files already. How that happens is not our
+		// concern.
+		ks := keystore.New()
+		if err := ks.LoadKeys(os.DirFS(cfg.Auth.KeysFolder)); err != nil {
+			return fmt.Errorf("reading keys: %w", err)
+		}
+		keyLookup = ks
+
+		// Keys are rotated by dropping new files into the folder. Reload the
+		// folder on SIGHUP and on an interval so new keys are picked up and
+		// retired keys are removed without a restart.
+		reloadCtx, stopReload := context.WithCancel(ctx)
+		defer stopReload()
+
+		go reloadKeys(reloadCtx, log, ks, cfg.Auth.KeysFolder, cfg.Auth.ReloadInterval)
+
+	default:
+
+		// Verify tokens against the key set published by another instance
+		// of the service. This instance can't issue tokens in this mode.
+		log.Info(ctx, "startup", "status", "using remote key set", "url", cfg.Auth.JWKSURL)
+
+		rs, err := jwks.NewRemoteStore(jwks.RemoteConfig{URL: cfg.Auth.JWKSURL})
+		if err != nil {
+			return fmt.Errorf("constructing remote key set: %w", err)
+		}
+		keyLookup = rs
+	}
+
+	// Authorization decisions are kept for compliance review. They go to
+	// their own file when one is configured.
+	decisionLog := log
+	if cfg.Auth.DecisionLogFile != "" {
+		f, err := os.OpenFile(cfg.Auth.DecisionLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+		if err != nil {
+			return fmt.Errorf("opening decision log: %w", err)
+		}
+		defer f.Close()
+
+		decisionLog = logger.New(f, logger.LevelInfo, "GATEONE-API", web.GetTraceID)
+		decisionLog.SetRedactKeys(cfg.Log.RedactKeys...)
 	}
 
 	authCfg := auth.Config{
-		Log:       log,
-		DB:        db,
-		KeyLookup: ks,
+		Log:         log,
+		DB:          db,
+		KeyLookup:   keyLookup,
+		Issuer:      cfg.Auth.Issuer,
+		Audience:    cfg.Auth.Audience,
+		TokenTTL:    cfg.Auth.TokenTTL,
+		ClockSkew:   cfg.Auth.ClockSkew,
+		PolicyPath:  cfg.Auth.PolicyPath,
+		DecisionLog: decisionLog,
 	}
 
 	auth, err := auth.New(authCfg)
@@ -168,13 +367,95 @@ func run(ctx context.Context, log *logger.Logger) error {
 		return fmt.Errorf("constructing auth: %w", err)
 	}
 
+	log.Info(ctx, "startup", "status", "authorization policy loaded", "path", cfg.Auth.PolicyPath, "revision", auth.PolicyRevision())
+
+	// Policies loaded from disk are reloaded when they change so access rules
+	// can be updated without a redeploy.
+	policyCtx, stopPolicy := context.WithCancel(ctx)
+	defer stopPolicy()
+
+	go auth.WatchPolicies(policyCtx, cfg.Auth.PolicyReloadInterval)
+
+	// -------------------------------------------------------------------------
+	// Rate Limit Support
+
+	log.Info(ctx, "startup", "status", "initializing rate limit support", "store", cfg.RateLimit.Store)
+
+	rateLimits, err := ratelimit.ParseLimits(
+		ratelimit.Limit{Rate: cfg.RateLimit.Rate, Burst: cfg.RateLimit.Burst},
+		[]string{"conditions", "patients", "regions", "roles", "tokens", "users", "webhooks"},
+		cfg.RateLimit.Groups,
+	)
+	if err != nil {
+		return fmt.Errorf("parsing rate limits: %w", err)
+	}
+
+	var rateLimiter ratelimit.Storer
+
+	switch cfg.RateLimit.Store {
+	case "memory":
+		rateLimiter = ratelimit.NewMemoryStore()
+
+	case "postgres":
+
+		// Replicas share their buckets through the database so a client is
+		// held to the same limit whichever replica serves it.
+		store := ratelimitdb.NewStore(log, db)
+		rateLimiter = store
+
+		pruneCtx, stopPrune := context.WithCancel(ctx)
+		defer stopPrune()
+
+		go pruneRateLimits(pruneCtx, log, store, cfg.RateLimit.PruneInterval)
+
+	default:
+		return fmt.Errorf("unknown rate limit store %q", cfg.RateLimit.Store)
+	}
+
+	// -------------------------------------------------------------------------
+	// Idempotency Support
+
+	log.Info(ctx, "startup", "status", "initializing idempotency support", "window", cfg.Idempotency.Window)
+
+	pruneKeysCtx, stopPruneKeys := context.WithCancel(ctx)
+	defer stopPruneKeys()
+
+	go pruneIdempotencyKeys(pruneKeysCtx, log, idempotencydb.NewStore(log, db), cfg.Idempotency.PruneInterval)
+
+	// -------------------------------------------------------------------------
+	// Start Tracing Support
+
+	log.Info(ctx, "startup", "status", "initializing tracing support", "exporter", cfg.Tracing.Exporter)
+
+	traceProvider, err := tracing.NewProvider(ctx, tracing.Config{
+		ServiceName: cfg.Tracing.ServiceName,
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Probability: cfg.Tracing.Probability,
+	})
+	if err != nil {
+		return fmt.Errorf("starting tracing: %w", err)
+	}
+	defer func() {
+		log.Info(ctx, "shutdown", "status", "stopping tracing support")
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		if err := traceProvider.Shutdown(ctx); err != nil {
+			log.Error(ctx, "shutdown", "status", "flushing spans", "msg", err)
+		}
+	}()
+
+	tracer := traceProvider.Tracer(cfg.Tracing.ServiceName)
+
 	// -------------------------------------------------------------------------
 	// Start Debug Service
 
 	go func() {
 		log.Info(ctx, "startup", "status", "debug v1 router started", "host", cfg.Web.DebugHost)
 
-		if err := http.ListenAndServe(cfg.Web.DebugHost, debug.Mux()); err != nil {
+		if err := http.ListenAndServe(cfg.Web.DebugHost, debug.Mux(debug.Config{Log: log, Auth: auth})); err != nil {
 			log.Error(ctx, "shutdown", "status", "debug v1 router closed", "host", cfg.Web.DebugHost, "msg", err)
 		}
 	}()
@@ -187,18 +468,138 @@ func run(ctx context.Context, log *logger.Logger) error {
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
 
+	// The readiness checks tell load balancers whether to send traffic to
+	// this instance. Add a check for every dependency requests can't be
+	// served without.
+	readiness := health.NewReadiness(cfg.Web.ReadinessTimeout, health.Check{
+		Name: "database",
+		Fn: func(ctx context.Context) error {
+			return sqldb.StatusCheck(ctx, db)
+		},
+	})
+
+	dlg := delegate.New(log)
+	if cfg.Delegate.Durable {
+		dlg = delegate.NewDurable(log, outboxdb.NewStore(log, db))
+	}
+
+	// The webhook core queues a delivery of every event to the
+	// subscriptions for its type.
+	whCore := webhook.NewCore(log, dlg, webhookdb.NewStore(log, db))
+
+	trustedProxies, err := mid.ParseTrustedProxies(cfg.Web.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("parsing trusted proxies: %w", err)
+	}
+
 	cfgMux := mux.Config{
-		Build:    build,
-		Shutdown: shutdown,
-		Log:      log,
-		Delegate: delegate.New(log),
-		Auth:     auth,
-		DB:       db,
+		Build:              build,
+		Shutdown:           shutdown,
+		Log:                log,
+		Tracer:             tracer,
+		Delegate:           dlg,
+		Auth:               auth,
+		DB:                 db,
+		UserCache:          userCache,
+		RateLimiter:        rateLimiter,
+		RateLimits:         rateLimits,
+		IdempotencyWindow:  cfg.Idempotency.Window,
+		IdempotencyMaxBody: cfg.Idempotency.MaxBodySize,
+		RequireIfMatch:     cfg.Web.RequireIfMatch,
+		Readiness:          readiness,
+		PublicURL:          cfg.Web.PublicURL,
+		TrustedProxies:     trustedProxies,
 	}
 
+	webAPI, err := mux.WebAPI(cfgMux, buildRoutes(), mux.WithCORS(cfg.Web.CORSAllowedOrigins))
+	if err != nil {
+		return fmt.Errorf("constructing api: %w", err)
+	}
+
+	// -------------------------------------------------------------------------
+	// Start Delegate Dispatcher
+
+	// The dispatcher starts once the routes are bound since the cores
+	// register their delegate functions when they are constructed.
+	if cfg.Delegate.Durable {
+		log.Info(ctx, "startup", "status", "initializing delegate dispatcher")
+
+		wrk, err := worker.New(cfg.Delegate.MaxRunning)
+		if err != nil {
+			return fmt.Errorf("constructing delegate worker: %w", err)
+		}
+
+		dispatcher, err := delegate.NewDispatcher(log, dlg, wrk, delegate.DispatcherConfig{
+			Interval:    cfg.Delegate.Interval,
+			BatchSize:   cfg.Delegate.BatchSize,
+			Lease:       cfg.Delegate.Lease,
+			Timeout:     cfg.Delegate.Timeout,
+			MaxAttempts: cfg.Delegate.MaxAttempts,
+			MinBackoff:  cfg.Delegate.MinBackoff,
+			MaxBackoff:  cfg.Delegate.MaxBackoff,
+		})
+		if err != nil {
+			return fmt.Errorf("constructing delegate dispatcher: %w", err)
+		}
+
+		dispatchCtx, stopDispatch := context.WithCancel(ctx)
+		go dispatcher.Run(dispatchCtx)
+
+		defer func() {
+			log.Info(ctx, "shutdown", "status", "stopping delegate dispatcher")
+			stopDispatch()
+
+			ctx, cancel := context.WithTimeout(ctx, cfg.Delegate.Timeout)
+			defer cancel()
+
+			if err := wrk.Shutdown(ctx); err != nil {
+				log.Error(ctx, "shutdown", "status", "waiting for delegate calls", "msg", err)
+			}
+		}()
+	}
+
+	// -------------------------------------------------------------------------
+	// Start Webhook Deliverer
+
+	log.Info(ctx, "startup", "status", "initializing webhook deliverer")
+
+	whWorker, err := worker.New(cfg.Webhook.MaxRunning)
+	if err != nil {
+		return fmt.Errorf("constructing webhook worker: %w", err)
+	}
+
+	deliverer, err := webhook.NewDeliverer(log, whCore, whWorker, webhook.NewClient(), webhook.DelivererConfig{
+		Interval:     cfg.Webhook.Interval,
+		BatchSize:    cfg.Webhook.BatchSize,
+		Lease:        cfg.Webhook.Lease,
+		Timeout:      cfg.Webhook.Timeout,
+		MaxAttempts:  cfg.Webhook.MaxAttempts,
+		MinBackoff:   cfg.Webhook.MinBackoff,
+		MaxBackoff:   cfg.Webhook.MaxBackoff,
+		DisableAfter: cfg.Webhook.DisableAfter,
+	})
+	if err != nil {
+		return fmt.Errorf("constructing webhook deliverer: %w", err)
+	}
+
+	deliverCtx, stopDeliver := context.WithCancel(ctx)
+	go deliverer.Run(deliverCtx)
+
+	defer func() {
+		log.Info(ctx, "shutdown", "status", "stopping webhook deliverer")
+		stopDeliver()
+
+		ctx, cancel := context.WithTimeout(ctx, cfg.Webhook.Timeout)
+		defer cancel()
+
+		if err := whWorker.Shutdown(ctx); err != nil {
+			log.Error(ctx, "shutdown", "status", "waiting for webhook deliveries", "msg", err)
+		}
+	}()
+
 	api := http.Server{
 		Addr:         cfg.Web.APIHost,
-		Handler:      mux.WebAPI(cfgMux, buildRoutes(), mux.WithCORS(cfg.Web.CORSAllowedOrigins)),
+		Handler:      webAPI,
 		ReadTimeout:  cfg.Web.ReadTimeout,
 		WriteTimeout: cfg.Web.WriteTimeout,
 		IdleTimeout:  cfg.Web.IdleTimeout,
@@ -224,6 +625,13 @@ func run(ctx context.Context, log *logger.Logger) error {
 		log.Info(ctx, "shutdown", "status", "shutdown started", "signal", sig)
 		defer log.Info(ctx, "shutdown", "status", "shutdown complete", "signal", sig)
 
+		// Fail the readiness checks first and give load balancers time to
+		// notice, so no new requests are sent while the server stops.
+		readiness.Shutdown()
+
+		log.Info(ctx, "shutdown", "status", "draining", "delay", cfg.Web.ShutdownDrain)
+		time.Sleep(cfg.Web.ShutdownDrain)
+
 		ctx, cancel := context.WithTimeout(ctx, cfg.Web.ShutdownTimeout)
 		defer cancel()
 
@@ -236,6 +644,184 @@ func run(ctx context.Context, log *logger.Logger) error {
 	return nil
 }
 
+// reloadKeys reloads the keys folder into the keystore every interval and
+// whenever the process receives a SIGHUP, until the context is cancelled. A
+// failed reload leaves the current keys in place.
+func reloadKeys(ctx context.Context, log *logger.Logger, ks *keystore.KeyStore, folder string, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		trigger := "interval"
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			trigger = "sighup"
+		case <-tick:
+		}
+
+		if err := ks.LoadKeys(os.DirFS(folder)); err != nil {
+			log.Error(ctx, "keystore", "status", "reload failed", "trigger", trigger, "folder", folder, "msg", err)
+			continue
+		}
+
+		kid, err := ks.ActiveKID()
+		if err != nil {
+			log.Error(ctx, "keystore", "status", "reloaded without an active key", "trigger", trigger, "folder", folder, "msg", err)
+			continue
+		}
+
+		if trigger == "sighup" {
+			log.Info(ctx, "keystore", "status", "reloaded", "trigger", trigger, "active_kid", kid)
+		}
+	}
+}
+
+// pruneRateLimits removes idle buckets from the database every interval. A
+// bucket idle for the interval has refilled long ago unless the limits are
+// very low, so removing it doesn't change any limits.
+func pruneRateLimits(ctx context.Context, log *logger.Logger, store *ratelimitdb.Store, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := store.Prune(ctx, interval); err != nil {
+			log.Error(ctx, "ratelimit", "status", "prune failed", "msg", err)
+		}
+	}
+}
+
+// pruneIdempotencyKeys removes expired idempotency keys every interval.
+func pruneIdempotencyKeys(ctx context.Context, log *logger.Logger, store *idempotencydb.Store, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := store.Prune(ctx, time.Now()); err != nil {
+			log.Error(ctx, "idempotency", "status", "prune failed", "msg", err)
+		}
+	}
+}
+
+// alertLevel represents the configuration of the alerts of a log level. The
+// sinks are named webhook, email and file, those that aren't configured are
+// skipped. No sinks disables the alerts of the level, except for the error
+// level which defaults to all of them.
+type alertLevel struct {
+	Sinks       []string
+	DedupWindow time.Duration `conf:"default:5m"`
+	Rate        int           `conf:"default:20"`
+	RatePeriod  time.Duration `conf:"default:1m"`
+	BatchSize   int           `conf:"default:10"`
+	BatchWait   time.Duration `conf:"default:30s"`
+}
+
+// alertSinks represents the configuration of the sinks alerts are sent to.
+type alertSinks struct {
+	webhookURL string
+	file       string
+	emailSpool string
+	emailFrom  string
+	emailTo    []string
+}
+
+// startAlerts constructs the configured sinks and starts the alerter.
+func startAlerts(alerts *alert.Alerter, log *logger.Logger, queueSize int, sendTimeout time.Duration, as alertSinks, levels map[logger.Level]alertLevel) error {
+	sinks := make(map[string]alert.Sink)
+
+	if as.webhookURL != "" {
+		sinks["webhook"] = alert.NewWebhook(as.webhookURL, &http.Client{Timeout: sendTimeout})
+	}
+
+	if as.file != "" {
+		file, err := alert.NewFile(as.file)
+		if err != nil {
+			return fmt.Errorf("file sink: %w", err)
+		}
+		sinks["file"] = file
+	}
+
+	if as.emailSpool != "" && len(as.emailTo) > 0 {
+		outbox, err := alert.NewSpoolOutbox(as.emailSpool)
+		if err != nil {
+			return fmt.Errorf("email outbox: %w", err)
+		}
+
+		email, err := alert.NewEmail(outbox, as.emailFrom, as.emailTo)
+		if err != nil {
+			return fmt.Errorf("email sink: %w", err)
+		}
+		sinks["email"] = email
+	}
+
+	cfg := alert.Config{
+		Log:         log,
+		Levels:      make(map[logger.Level]alert.Level),
+		QueueSize:   queueSize,
+		SendTimeout: sendTimeout,
+	}
+
+	for level, al := range levels {
+		names := al.Sinks
+		if level == logger.LevelError && names == nil {
+			names = []string{"webhook", "email", "file"}
+		}
+
+		lc := alert.Level{
+			DedupWindow: al.DedupWindow,
+			Rate:        al.Rate,
+			RatePeriod:  al.RatePeriod,
+			BatchSize:   al.BatchSize,
+			BatchWait:   al.BatchWait,
+		}
+
+		for _, name := range names {
+			switch name {
+			case "webhook", "email", "file":
+				if sink, exists := sinks[name]; exists {
+					lc.Sinks = append(lc.Sinks, sink)
+				}
+			default:
+				return fmt.Errorf("level %s: unknown sink %q", level, name)
+			}
+		}
+
+		cfg.Levels[level] = lc
+	}
+
+	return alerts.Start(cfg)
+}
+
 func buildRoutes() mux.RouteAdder {
 
 	// The idea here is that we can build different versions of the binary
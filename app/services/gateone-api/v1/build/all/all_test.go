@@ -0,0 +1,63 @@
+package all_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"github.com/fadhilijuma/gateone-service/app/services/gateone-api/v1/build/all"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/mux"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the OpenAPI document in testdata")
+
+// Test_OpenAPI fails when the OpenAPI document generated from the routes no
+// longer matches the document in testdata. After changing routes or models
+// regenerate it with: go test ./app/services/gateone-api/v1/build/all -update
+func Test_OpenAPI(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelError, "TEST", func(context.Context) string { return "" })
+
+	cfg := mux.Config{
+		Build:    "develop",
+		Shutdown: make(chan os.Signal, 1),
+		Log:      log,
+		Delegate: delegate.New(log),
+	}
+
+	api, err := mux.WebAPI(cfg, all.Routes())
+	if err != nil {
+		t.Fatalf("Should be able to construct the api: %s", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil)
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Should be able to retrieve the document: got status %d: %s", w.Code, w.Body)
+	}
+
+	golden := filepath.Join("testdata", "openapi.json")
+
+	if *update {
+		if err := os.WriteFile(golden, w.Body.Bytes(), 0644); err != nil {
+			t.Fatalf("Should be able to update the document: %s", err)
+		}
+	}
+
+	exp, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Should be able to read the document: %s", err)
+	}
+
+	if !bytes.Equal(w.Body.Bytes(), exp) {
+		t.Fatalf("Should match %s, the routes or models changed: regenerate it with -update and review the diff", golden)
+	}
+}
@@ -2,11 +2,13 @@
 package crud
 
 import (
+	"github.com/fadhilijuma/gateone-service/app/services/gateone-api/v1/handlers/checkgrp"
 	"github.com/fadhilijuma/gateone-service/app/services/gateone-api/v1/handlers/conditiongrp"
 	"github.com/fadhilijuma/gateone-service/app/services/gateone-api/v1/handlers/patientgrp"
 	"github.com/fadhilijuma/gateone-service/app/services/gateone-api/v1/handlers/regiongrp"
 	"github.com/fadhilijuma/gateone-service/app/services/gateone-api/v1/handlers/rolegrp"
 	"github.com/fadhilijuma/gateone-service/app/services/gateone-api/v1/handlers/usergrp"
+	"github.com/fadhilijuma/gateone-service/app/services/gateone-api/v1/handlers/wellknowngrp"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/mux"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
 )
@@ -21,37 +23,79 @@ type add struct{}
 
 // Add implements the RouterAdder interface.
 func (add) Add(app *web.App, cfg mux.Config) {
+	checkgrp.Routes(app, checkgrp.Config{
+		Build:     cfg.Build,
+		Log:       cfg.Log,
+		Readiness: cfg.Readiness,
+	})
 
 	conditiongrp.Routes(app, conditiongrp.Config{
-		Log:      cfg.Log,
-		Delegate: cfg.Delegate,
-		Auth:     cfg.Auth,
-		DB:       cfg.DB,
+		Log:                cfg.Log,
+		Delegate:           cfg.Delegate,
+		Auth:               cfg.Auth,
+		DB:                 cfg.DB,
+		UserCache:          cfg.UserCache,
+		RateLimiter:        cfg.RateLimiter,
+		RateLimit:          cfg.RateLimits["conditions"],
+		IdempotencyWindow:  cfg.IdempotencyWindow,
+		IdempotencyMaxBody: cfg.IdempotencyMaxBody,
+		RequireIfMatch:     cfg.RequireIfMatch,
 	})
 	patientgrp.Routes(app, patientgrp.Config{
-		Log:      cfg.Log,
-		Delegate: cfg.Delegate,
-		Auth:     cfg.Auth,
-		DB:       cfg.DB,
+		Log:                cfg.Log,
+		Delegate:           cfg.Delegate,
+		Auth:               cfg.Auth,
+		DB:                 cfg.DB,
+		UserCache:          cfg.UserCache,
+		RateLimiter:        cfg.RateLimiter,
+		RateLimit:          cfg.RateLimits["patients"],
+		IdempotencyWindow:  cfg.IdempotencyWindow,
+		IdempotencyMaxBody: cfg.IdempotencyMaxBody,
+		RequireIfMatch:     cfg.RequireIfMatch,
 	})
 
 	regiongrp.Routes(app, regiongrp.Config{
-		Log:      cfg.Log,
-		Delegate: cfg.Delegate,
-		Auth:     cfg.Auth,
-		DB:       cfg.DB,
+		Log:                cfg.Log,
+		Delegate:           cfg.Delegate,
+		Auth:               cfg.Auth,
+		DB:                 cfg.DB,
+		UserCache:          cfg.UserCache,
+		RateLimiter:        cfg.RateLimiter,
+		RateLimit:          cfg.RateLimits["regions"],
+		IdempotencyWindow:  cfg.IdempotencyWindow,
+		IdempotencyMaxBody: cfg.IdempotencyMaxBody,
+		RequireIfMatch:     cfg.RequireIfMatch,
 	})
 	rolegrp.Routes(app, rolegrp.Config{
-		Log:      cfg.Log,
-		Delegate: cfg.Delegate,
-		Auth:     cfg.Auth,
-		DB:       cfg.DB,
+		Log:                cfg.Log,
+		Delegate:           cfg.Delegate,
+		Auth:               cfg.Auth,
+		DB:                 cfg.DB,
+		UserCache:          cfg.UserCache,
+		RateLimiter:        cfg.RateLimiter,
+		RateLimit:          cfg.RateLimits["roles"],
+		IdempotencyWindow:  cfg.IdempotencyWindow,
+		IdempotencyMaxBody: cfg.IdempotencyMaxBody,
+		RequireIfMatch:     cfg.RequireIfMatch,
 	})
 
 	usergrp.Routes(app, usergrp.Config{
-		Log:      cfg.Log,
-		Delegate: cfg.Delegate,
-		Auth:     cfg.Auth,
-		DB:       cfg.DB,
+		Log:                cfg.Log,
+		Delegate:           cfg.Delegate,
+		Auth:               cfg.Auth,
+		DB:                 cfg.DB,
+		UserCache:          cfg.UserCache,
+		RateLimiter:        cfg.RateLimiter,
+		RateLimit:          cfg.RateLimits["users"],
+		IdempotencyWindow:  cfg.IdempotencyWindow,
+		IdempotencyMaxBody: cfg.IdempotencyMaxBody,
+		RequireIfMatch:     cfg.RequireIfMatch,
+		TokenRateLimit:     cfg.RateLimits["tokens"],
+	})
+
+	wellknowngrp.Routes(app, wellknowngrp.Config{
+		Log:       cfg.Log,
+		Auth:      cfg.Auth,
+		PublicURL: cfg.PublicURL,
 	})
 }
@@ -0,0 +1,85 @@
+// Package auditgrp maintains the group of handlers for reading and verifying
+// the audit log.
+package auditgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
+	"github.com/fadhilijuma/gateone-service/foundation/validate"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type handlers struct {
+	audit *audit.Core
+}
+
+func new(audit *audit.Core) *handlers {
+	return &handlers{
+		audit: audit,
+	}
+}
+
+// query returns a list of audit log entries with paging.
+func (h *handlers) query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	pg, err := page.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	if pg.Cursor != nil {
+		return validate.NewFieldsError("cursor", errors.New("not supported"))
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.audit.Query(ctx, filter, pg.Number, pg.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.audit.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, v1.NewPageDocument(toAppEntries(entries), total, pg.Number, pg.RowsPerPage), http.StatusOK)
+}
+
+// queryByID returns an audit log entry by its ID.
+func (h *handlers) queryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	entryID, err := uuid.Parse(web.Param(r, "entry_id"))
+	if err != nil {
+		return validate.NewFieldsError("entry_id", err)
+	}
+
+	e, err := h.audit.QueryByID(ctx, entryID)
+	if err != nil {
+		if errors.Is(err, audit.ErrNotFound) {
+			return v1.NewTrustedError(err, http.StatusNotFound)
+		}
+		return fmt.Errorf("querybyid: entryID[%s]: %w", entryID, err)
+	}
+
+	return web.Respond(ctx, w, toAppEntry(e), http.StatusOK)
+}
+
+// verify checks the chain of audit log entries for changed or missing
+// entries.
+func (h *handlers) verify(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := h.audit.Verify(ctx)
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+
+	return web.Respond(ctx, w, toAppVerification(v), http.StatusOK)
+}
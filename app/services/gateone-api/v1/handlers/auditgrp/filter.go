@@ -0,0 +1,71 @@
+package auditgrp
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
+	"github.com/fadhilijuma/gateone-service/foundation/validate"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of query string parameters read by parseFilter.
+const (
+	filterByActorID          = "actor_id"
+	filterByAction           = "action"
+	filterByEntityType       = "entity_type"
+	filterByEntityID         = "entity_id"
+	filterByStartCreatedDate = "start_created_date"
+	filterByEndCreatedDate   = "end_created_date"
+)
+
+// filterParams lists the query string parameters read by parseFilter.
+var filterParams = []string{filterByActorID, filterByAction, filterByEntityType, filterByEntityID, filterByStartCreatedDate, filterByEndCreatedDate}
+
+func parseFilter(r *http.Request) (audit.QueryFilter, error) {
+	values := r.URL.Query()
+
+	var filter audit.QueryFilter
+
+	if actorID := values.Get(filterByActorID); actorID != "" {
+		id, err := uuid.Parse(actorID)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError(filterByActorID, err)
+		}
+		filter.WithActorID(id)
+	}
+
+	if action := values.Get(filterByAction); action != "" {
+		filter.WithAction(action)
+	}
+
+	if entityType := values.Get(filterByEntityType); entityType != "" {
+		filter.WithEntityType(entityType)
+	}
+
+	if entityID := values.Get(filterByEntityID); entityID != "" {
+		id, err := uuid.Parse(entityID)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError(filterByEntityID, err)
+		}
+		filter.WithEntityID(id)
+	}
+
+	if createdDate := values.Get(filterByStartCreatedDate); createdDate != "" {
+		t, err := time.Parse(time.RFC3339, createdDate)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError(filterByStartCreatedDate, err)
+		}
+		filter.WithStartDateCreated(t)
+	}
+
+	if createdDate := values.Get(filterByEndCreatedDate); createdDate != "" {
+		t, err := time.Parse(time.RFC3339, createdDate)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError(filterByEndCreatedDate, err)
+		}
+		filter.WithEndCreatedDate(t)
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,81 @@
+package auditgrp
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
+	"time"
+)
+
+// AppChange represents the value of a field before and after a change.
+type AppChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// AppEntry represents an entry of the audit log.
+type AppEntry struct {
+	ID          string      `json:"id"`
+	Seq         int64       `json:"seq"`
+	ActorID     string      `json:"actorID"`
+	TraceID     string      `json:"traceID"`
+	ClientIP    string      `json:"clientIP"`
+	Action      string      `json:"action"`
+	EntityType  string      `json:"entityType"`
+	EntityID    string      `json:"entityID"`
+	Changes     []AppChange `json:"changes"`
+	DateCreated string      `json:"dateCreated"`
+	PrevHash    string      `json:"prevHash"`
+	Hash        string      `json:"hash"`
+}
+
+func toAppEntry(e audit.Entry) AppEntry {
+	changes := make([]AppChange, len(e.Changes))
+	for i, ch := range e.Changes {
+		changes[i] = AppChange{
+			Field:  ch.Field,
+			Before: ch.Before,
+			After:  ch.After,
+		}
+	}
+
+	return AppEntry{
+		ID:          e.ID.String(),
+		Seq:         e.Seq,
+		ActorID:     e.Actor.UserID.String(),
+		TraceID:     e.Actor.TraceID,
+		ClientIP:    e.Actor.ClientIP,
+		Action:      e.Action,
+		EntityType:  e.EntityType,
+		EntityID:    e.EntityID.String(),
+		Changes:     changes,
+		DateCreated: e.DateCreated.Format(time.RFC3339),
+		PrevHash:    e.PrevHash,
+		Hash:        e.Hash,
+	}
+}
+
+func toAppEntries(entries []audit.Entry) []AppEntry {
+	items := make([]AppEntry, len(entries))
+	for i, e := range entries {
+		items[i] = toAppEntry(e)
+	}
+
+	return items
+}
+
+// AppVerification represents the outcome of verifying the audit log.
+type AppVerification struct {
+	Entries   int    `json:"entries"`
+	Valid     bool   `json:"valid"`
+	BrokenSeq int64  `json:"brokenSeq,omitzero"`
+	Reason    string `json:"reason,omitzero"`
+}
+
+func toAppVerification(v audit.Verification) AppVerification {
+	return AppVerification{
+		Entries:   v.Entries,
+		Valid:     v.Valid,
+		BrokenSeq: v.BrokenSeq,
+		Reason:    v.Reason,
+	}
+}
@@ -0,0 +1,51 @@
+package auditgrp
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit/stores/auditdb"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+	"slices"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log  *logger.Logger
+	Auth *auth.Auth
+	DB   *sqlx.DB
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	audCore := audit.NewCore(cfg.Log, auditdb.NewStore(cfg.Log, cfg.DB))
+
+	authen := mid.Authenticate(cfg.Auth)
+	ruleAdmin := mid.Authorize(cfg.Auth, auth.RuleAdminOnly)
+
+	hdl := new(audCore)
+	app.Handle(http.MethodGet, version, "/audit", hdl.query, authen, ruleAdmin).Describe(web.RouteDoc{
+		Summary:  "List the audit log entries",
+		Response: v1.PageDocument[AppEntry]{},
+		Security: web.SecurityBearer,
+		Query:    slices.Concat(page.Params(false), filterParams),
+	})
+	app.Handle(http.MethodGet, version, "/audit/verify", hdl.verify, authen, ruleAdmin).Describe(web.RouteDoc{
+		Summary:  "Verify the chain of audit log entries",
+		Response: AppVerification{},
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodGet, version, "/audit/{entry_id}", hdl.queryByID, authen, ruleAdmin).Describe(web.RouteDoc{
+		Summary:  "Get an audit log entry",
+		Response: AppEntry{},
+		Security: web.SecurityBearer,
+	})
+}
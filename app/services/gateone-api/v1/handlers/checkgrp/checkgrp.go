@@ -0,0 +1,65 @@
+// Package checkgrp maintains the group of handlers for health checking.
+package checkgrp
+
+import (
+	"context"
+	"errors"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/health"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+	"os"
+	"runtime"
+)
+
+type handlers struct {
+	build string
+	log   *logger.Logger
+	ready *health.Readiness
+}
+
+func new(build string, log *logger.Logger, readiness *health.Readiness) *handlers {
+	return &handlers{
+		build: build,
+		log:   log,
+		ready: readiness,
+	}
+}
+
+// liveness returns simple status info if the service is alive. If the
+// process can't respond the orchestrator will restart it.
+func (h *handlers) liveness(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unavailable"
+	}
+
+	data := Liveness{
+		Status:     "up",
+		Build:      h.build,
+		Host:       host,
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+	}
+
+	return web.Respond(ctx, w, data, http.StatusOK)
+}
+
+// readiness checks if the database and the other dependencies are ready and
+// if not will return a 503 status. It also returns a 503 status as soon as
+// the service starts to shut down, so load balancers stop sending traffic.
+func (h *handlers) readiness(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	results, err := h.ready.Check(ctx)
+
+	data := toReadiness(results, err)
+
+	switch {
+	case errors.Is(err, health.ErrShuttingDown):
+		return web.Respond(ctx, w, data, http.StatusServiceUnavailable)
+
+	case err != nil:
+		h.log.Info(ctx, "readiness failure", "msg", err)
+		return web.Respond(ctx, w, data, http.StatusServiceUnavailable)
+	}
+
+	return web.Respond(ctx, w, data, http.StatusOK)
+}
@@ -0,0 +1,44 @@
+package checkgrp
+
+import (
+	"errors"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/health"
+)
+
+// Liveness represents information about the running process.
+type Liveness struct {
+	Status     string `json:"status"`
+	Build      string `json:"build"`
+	Host       string `json:"host"`
+	GOMAXPROCS int    `json:"GOMAXPROCS"`
+}
+
+// Readiness represents the state of the service and of each dependency it
+// checked. The details of failures are logged rather than returned.
+type Readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func toReadiness(results []health.Result, err error) Readiness {
+	status := "ok"
+	switch {
+	case errors.Is(err, health.ErrShuttingDown):
+		status = "shutting down"
+	case err != nil:
+		status = "not ready"
+	}
+
+	checks := make(map[string]string, len(results))
+	for _, res := range results {
+		checks[res.Name] = "ok"
+		if res.Err != nil {
+			checks[res.Name] = "failed"
+		}
+	}
+
+	return Readiness{
+		Status: status,
+		Checks: checks,
+	}
+}
@@ -0,0 +1,31 @@
+package checkgrp
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/web/v1/health"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Build     string
+	Log       *logger.Logger
+	Readiness *health.Readiness
+}
+
+// Routes adds specific routes for this group. The checks are bound without
+// the application middleware so probes don't flood the logs and metrics.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	hdl := new(cfg.Build, cfg.Log, cfg.Readiness)
+	app.HandleNoMiddleware(http.MethodGet, version, "/liveness", hdl.liveness).Describe(web.RouteDoc{
+		Summary:  "Check the service is alive",
+		Response: Liveness{},
+	})
+	app.HandleNoMiddleware(http.MethodGet, version, "/readiness", hdl.readiness).Describe(web.RouteDoc{
+		Summary:  "Check the service is ready to receive traffic",
+		Response: Readiness{},
+	})
+}
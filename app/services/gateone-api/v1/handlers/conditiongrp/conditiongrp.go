@@ -5,10 +5,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/condition"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/etag"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
 	"net/http"
@@ -22,17 +26,50 @@ var (
 type handlers struct {
 	condition *condition.Core
 	user      *user.Core
+	audit     *audit.Core
 }
 
-func new(condition *condition.Core, user *user.Core) *handlers {
+func new(condition *condition.Core, user *user.Core, audit *audit.Core) *handlers {
 	return &handlers{
 		condition: condition,
 		user:      user,
+		audit:     audit,
 	}
 }
 
+// executeUnderTransaction constructs a new handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *handlers) executeUnderTransaction(ctx context.Context) (*handlers, error) {
+	tx, ok := transaction.Get(ctx)
+	if !ok {
+		return h, nil
+	}
+
+	condition, err := h.condition.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.user.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	audit, err := h.audit.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(condition, user, audit), nil
+}
+
 // create adds a new condition to the system.
 func (h *handlers) create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	var app AppNewCondition
 	if err := web.Decode(r, &app); err != nil {
 		return v1.NewTrustedError(err, http.StatusBadRequest)
@@ -40,14 +77,28 @@ func (h *handlers) create(ctx context.Context, w http.ResponseWriter, r *http.Re
 
 	cn, err := h.condition.Create(ctx, toCoreNewCondition(ctx, app))
 	if err != nil {
+		if errors.Is(err, condition.ErrUserDisabled) {
+			return v1.NewTrustedError(err, http.StatusBadRequest)
+		}
 		return fmt.Errorf("create: app[%+v]: %w", app, err)
 	}
 
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionCreated, condition.Domain, cn.ID, nil, cn); err != nil {
+		return err
+	}
+
+	etag.Set(w, cn.Version)
+
 	return web.Respond(ctx, w, toAppCondition(cn), http.StatusCreated)
 }
 
 // update updates a condition in the system.
 func (h *handlers) update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	var app AppUpdateCondition
 	if err := web.Decode(r, &app); err != nil {
 		return v1.NewTrustedError(err, http.StatusBadRequest)
@@ -55,22 +106,51 @@ func (h *handlers) update(ctx context.Context, w http.ResponseWriter, r *http.Re
 
 	cn := mid.GetCondition(ctx)
 
+	if err := etag.Check(r, cn.Version); err != nil {
+		return err
+	}
+
 	updCn, err := h.condition.Update(ctx, cn, toCoreUpdateCondition(app))
 	if err != nil {
-		return fmt.Errorf("update: conditionID[%s] app[%+v]: %w", updCn.ID, app, err)
+		if errors.Is(err, condition.ErrVersionConflict) {
+			return v1.NewTrustedError(err, http.StatusPreconditionFailed)
+		}
+		return fmt.Errorf("update: conditionID[%s] app[%+v]: %w", cn.ID, app, err)
 	}
 
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionUpdated, condition.Domain, updCn.ID, cn, updCn); err != nil {
+		return err
+	}
+
+	etag.Set(w, updCn.Version)
+
 	return web.Respond(ctx, w, toAppCondition(updCn), http.StatusOK)
 }
 
 // delete removes a condition from the system.
 func (h *handlers) delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	cn := mid.GetCondition(ctx)
 
+	if err := etag.Check(r, cn.Version); err != nil {
+		return err
+	}
+
 	if err := h.condition.Delete(ctx, cn); err != nil {
+		if errors.Is(err, condition.ErrVersionConflict) {
+			return v1.NewTrustedError(err, http.StatusPreconditionFailed)
+		}
 		return fmt.Errorf("delete: conditionID[%s]: %w", cn.ID, err)
 	}
 
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionDeleted, condition.Domain, cn.ID, cn, nil); err != nil {
+		return err
+	}
+
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
 }
 
@@ -91,6 +171,10 @@ func (h *handlers) query(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return err
 	}
 
+	if pg.Cursor != nil {
+		return h.queryByCursor(ctx, w, filter, orderBy, pg)
+	}
+
 	cns, err := h.condition.Query(ctx, filter, orderBy, pg.Number, pg.RowsPerPage)
 	if err != nil {
 		return fmt.Errorf("query: %w", err)
@@ -104,7 +188,29 @@ func (h *handlers) query(ctx context.Context, w http.ResponseWriter, r *http.Req
 	return web.Respond(ctx, w, v1.NewPageDocument(toAppConditions(cns), total, pg.Number, pg.RowsPerPage), http.StatusOK)
 }
 
+// queryByCursor returns a list of conditions with cursor paging.
+func (h *handlers) queryByCursor(ctx context.Context, w http.ResponseWriter, filter condition.QueryFilter, orderBy order.By, pg page.Page) error {
+	if err := pg.Cursor.Check(orderBy.String()); err != nil {
+		return v1.NewTrustedError(err, http.StatusBadRequest)
+	}
+
+	// One more row than the page holds is queried to tell if there are more.
+	cns, err := h.condition.QueryByCursor(ctx, filter, orderBy, *pg.Cursor, pg.RowsPerPage+1)
+	if err != nil {
+		return fmt.Errorf("querybycursor: %w", err)
+	}
+
+	cns, next, prev := page.Window(pg, orderBy.String(), cns, func(cn condition.Condition) []string {
+		return condition.CursorValues(cn, orderBy)
+	})
+
+	return web.Respond(ctx, w, v1.NewCursorDocument(toAppConditions(cns), next, prev, pg.RowsPerPage), http.StatusOK)
+}
+
 // queryByID returns a condition by its ID.
 func (h *handlers) queryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-	return web.Respond(ctx, w, toAppCondition(mid.GetCondition(ctx)), http.StatusOK)
+	cn := mid.GetCondition(ctx)
+	etag.Set(w, cn.Version)
+
+	return web.Respond(ctx, w, toAppCondition(cn), http.StatusOK)
 }
@@ -7,12 +7,16 @@ import (
 	"net/http"
 )
 
-func parseFilter(r *http.Request) (condition.QueryFilter, error) {
-	const (
-		filterByConditionID = "condition_id"
-		filterByName        = "name"
-	)
+// Set of query string parameters read by parseFilter.
+const (
+	filterByConditionID = "condition_id"
+	filterByName        = "name"
+)
 
+// filterParams lists the query string parameters read by parseFilter.
+var filterParams = []string{filterByConditionID, filterByName}
+
+func parseFilter(r *http.Request) (condition.QueryFilter, error) {
 	values := r.URL.Query()
 
 	var filter condition.QueryFilter
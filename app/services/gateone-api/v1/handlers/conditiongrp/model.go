@@ -15,6 +15,7 @@ type AppCondition struct {
 	Name        string `json:"name"`
 	DateCreated string `json:"dateCreated"`
 	DateUpdated string `json:"dateUpdated"`
+	Version     int    `json:"version"`
 }
 
 func toAppCondition(pn condition.Condition) AppCondition {
@@ -24,6 +25,7 @@ func toAppCondition(pn condition.Condition) AppCondition {
 		Name:        pn.Name,
 		DateCreated: pn.DateCreated.Format(time.RFC3339),
 		DateUpdated: pn.DateUpdated.Format(time.RFC3339),
+		Version:     pn.Version,
 	}
 }
 
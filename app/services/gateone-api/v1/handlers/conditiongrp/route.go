@@ -1,45 +1,90 @@
 package conditiongrp
 
 import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit/stores/auditdb"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/condition"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/condition/stores/conditiondb"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user/stores/usercache"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user/stores/userdb"
+	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/idempotency/stores/idempotencydb"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/ratelimit"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Log      *logger.Logger
-	Delegate *delegate.Delegate
-	Auth     *auth.Auth
-	DB       *sqlx.DB
+	Log                *logger.Logger
+	Delegate           *delegate.Delegate
+	Auth               *auth.Auth
+	DB                 *sqlx.DB
+	UserCache          *usercache.Cache
+	RateLimiter        ratelimit.Storer
+	RateLimit          ratelimit.Limit
+	IdempotencyWindow  time.Duration
+	IdempotencyMaxBody int64
+	RequireIfMatch     bool
 }
 
 // Routes adds specific routes for this group.
 func Routes(app *web.App, cfg Config) {
 	const version = "v1"
 
-	usrCore := user.NewCore(cfg.Log, cfg.Delegate, usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB)))
+	usrCore := user.NewCore(cfg.Log, cfg.Delegate, usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB), cfg.UserCache))
 	condCore := condition.NewCore(cfg.Log, usrCore, cfg.Delegate, conditiondb.NewStore(cfg.Log, cfg.DB))
+	audCore := audit.NewCore(cfg.Log, auditdb.NewStore(cfg.Log, cfg.DB))
 
 	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.Log, cfg.RateLimiter, "conditions", cfg.RateLimit)
+	tran := mid.ExecuteInTransaction(cfg.Log, sqldb.NewBeginner(cfg.DB))
+	idem := mid.Idempotency(cfg.Log, idempotencydb.NewStore(cfg.Log, cfg.DB), cfg.IdempotencyWindow, cfg.IdempotencyMaxBody)
+	ifMatch := mid.RequireIfMatch(cfg.RequireIfMatch)
 	ruleAny := mid.Authorize(cfg.Auth, auth.RuleAny)
 	ruleUserOnly := mid.Authorize(cfg.Auth, auth.RuleUserOnly)
 	ruleAdminOrSubject := mid.AuthorizeCondition(cfg.Auth, auth.RuleAdminOrSubject, condCore)
 
-	hdl := new(condCore, usrCore)
-	app.Handle(http.MethodGet, version, "/conditions", hdl.query, authen, ruleAny)
-	app.Handle(http.MethodGet, version, "/conditions/{condition_id}", hdl.queryByID, authen, ruleAdminOrSubject)
-	app.Handle(http.MethodPost, version, "/conditions", hdl.create, authen, ruleUserOnly)
-	app.Handle(http.MethodPut, version, "/conditions/{condition_id}", hdl.update, authen, ruleAdminOrSubject)
-	app.Handle(http.MethodDelete, version, "/conditions/{condition_id}", hdl.delete, authen, ruleAdminOrSubject)
+	hdl := new(condCore, usrCore, audCore)
+	app.Handle(http.MethodGet, version, "/conditions", hdl.query, authen, limit, ruleAny).Describe(web.RouteDoc{
+		Summary:  "List conditions",
+		Response: v1.PageDocument[AppCondition]{},
+		Security: web.SecurityBearer,
+		Query:    slices.Concat(page.Params(true), []string{order.Param}, filterParams),
+	})
+	app.Handle(http.MethodGet, version, "/conditions/{condition_id}", hdl.queryByID, authen, limit, ruleAdminOrSubject).Describe(web.RouteDoc{
+		Summary:  "Get a condition",
+		Response: AppCondition{},
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodPost, version, "/conditions", hdl.create, authen, limit, ruleUserOnly, tran, idem).Describe(web.RouteDoc{
+		Summary:  "Create a condition",
+		Request:  AppNewCondition{},
+		Response: AppCondition{},
+		Status:   http.StatusCreated,
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodPut, version, "/conditions/{condition_id}", hdl.update, authen, limit, ruleAdminOrSubject, ifMatch, tran).Describe(web.RouteDoc{
+		Summary:  "Update a condition",
+		Request:  AppUpdateCondition{},
+		Response: AppCondition{},
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodDelete, version, "/conditions/{condition_id}", hdl.delete, authen, limit, ruleAdminOrSubject, ifMatch, tran).Describe(web.RouteDoc{
+		Summary:  "Delete a condition",
+		Status:   http.StatusNoContent,
+		Security: web.SecurityBearer,
+	})
 }
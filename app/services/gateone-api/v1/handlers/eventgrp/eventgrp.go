@@ -0,0 +1,48 @@
+// Package eventgrp maintains the group of handlers for discovering the
+// domain events the core packages emit.
+package eventgrp
+
+import (
+	"context"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+)
+
+// Set of query string parameters read by querySchemas.
+const (
+	filterByDomain = "domain"
+	filterByAction = "action"
+)
+
+// filterParams lists the query string parameters read by querySchemas.
+var filterParams = []string{filterByDomain, filterByAction}
+
+type handlers struct{}
+
+func new() *handlers {
+	return &handlers{}
+}
+
+// querySchemas returns the registered event schemas, optionally limited to
+// a domain and action.
+func (h *handlers) querySchemas(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	values := r.URL.Query()
+	domain := values.Get(filterByDomain)
+	action := values.Get(filterByAction)
+
+	items := []AppEventSchema{}
+	for _, s := range delegate.Schemas() {
+		if (domain != "" && s.Domain != domain) || (action != "" && s.Action != action) {
+			continue
+		}
+
+		aes, err := toAppEventSchema(s)
+		if err != nil {
+			return err
+		}
+		items = append(items, aes)
+	}
+
+	return web.Respond(ctx, w, items, http.StatusOK)
+}
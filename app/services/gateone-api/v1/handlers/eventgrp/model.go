@@ -0,0 +1,31 @@
+package eventgrp
+
+import (
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/openapi"
+)
+
+// AppEventSchema represents the schema of the parameters of a domain event.
+type AppEventSchema struct {
+	Domain  string          `json:"domain"`
+	Action  string          `json:"action"`
+	Version int             `json:"version"`
+	Schema  *openapi.Schema `json:"schema"`
+}
+
+func toAppEventSchema(s delegate.Schema) (AppEventSchema, error) {
+	schema, err := openapi.JSONSchema(s.Parms)
+	if err != nil {
+		return AppEventSchema{}, fmt.Errorf("jsonschema: %s.%s: %w", s.Domain, s.Action, err)
+	}
+
+	aes := AppEventSchema{
+		Domain:  s.Domain,
+		Action:  s.Action,
+		Version: s.Version,
+		Schema:  schema,
+	}
+
+	return aes, nil
+}
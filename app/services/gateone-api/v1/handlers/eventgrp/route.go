@@ -0,0 +1,30 @@
+package eventgrp
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log  *logger.Logger
+	Auth *auth.Auth
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.Auth)
+
+	hdl := new()
+	app.Handle(http.MethodGet, version, "/events/schemas", hdl.querySchemas, authen).Describe(web.RouteDoc{
+		Summary:  "List the schemas of the domain events",
+		Response: []AppEventSchema{},
+		Security: web.SecurityBearer,
+		Query:    filterParams,
+	})
+}
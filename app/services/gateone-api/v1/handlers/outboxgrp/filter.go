@@ -0,0 +1,31 @@
+package outboxgrp
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"net/http"
+)
+
+// Set of query string parameters read by parseFilter.
+const (
+	filterByDomain = "domain"
+	filterByAction = "action"
+)
+
+// filterParams lists the query string parameters read by parseFilter.
+var filterParams = []string{filterByDomain, filterByAction}
+
+func parseFilter(r *http.Request) delegate.QueryFilter {
+	values := r.URL.Query()
+
+	var filter delegate.QueryFilter
+
+	if domain := values.Get(filterByDomain); domain != "" {
+		filter.WithDomain(domain)
+	}
+
+	if action := values.Get(filterByAction); action != "" {
+		filter.WithAction(action)
+	}
+
+	return filter
+}
@@ -0,0 +1,55 @@
+package outboxgrp
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"time"
+)
+
+// AppEvent represents an event of the outbox.
+type AppEvent struct {
+	ID          string `json:"id"`
+	Domain      string `json:"domain"`
+	Action      string `json:"action"`
+	Version     int    `json:"version"`
+	Params      string `json:"params"`
+	Attempts    int    `json:"attempts"`
+	LastError   string `json:"lastError"`
+	AvailableAt string `json:"availableAt"`
+	DateCreated string `json:"dateCreated"`
+}
+
+func toAppEvent(ev delegate.Event) AppEvent {
+	return AppEvent{
+		ID:          ev.ID.String(),
+		Domain:      ev.Data.Domain,
+		Action:      ev.Data.Action,
+		Version:     ev.Data.Version,
+		Params:      string(ev.Data.RawParams),
+		Attempts:    ev.Attempts,
+		LastError:   ev.LastError,
+		AvailableAt: ev.AvailableAt.Format(time.RFC3339),
+		DateCreated: ev.DateCreated.Format(time.RFC3339),
+	}
+}
+
+// AppDeadLetter represents an event that failed every delivery attempt.
+type AppDeadLetter struct {
+	AppEvent   `json:",inline"`
+	DateFailed string `json:"dateFailed"`
+}
+
+func toAppDeadLetter(dl delegate.DeadLetter) AppDeadLetter {
+	return AppDeadLetter{
+		AppEvent:   toAppEvent(dl.Event),
+		DateFailed: dl.DateFailed.Format(time.RFC3339),
+	}
+}
+
+func toAppDeadLetters(dls []delegate.DeadLetter) []AppDeadLetter {
+	items := make([]AppDeadLetter, len(dls))
+	for i, dl := range dls {
+		items[i] = toAppDeadLetter(dl)
+	}
+
+	return items
+}
@@ -0,0 +1,95 @@
+// Package outboxgrp maintains the group of handlers for inspecting and
+// replaying the events of the delegate outbox.
+package outboxgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
+	"github.com/fadhilijuma/gateone-service/foundation/validate"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type handlers struct {
+	delegate *delegate.Delegate
+}
+
+func new(delegate *delegate.Delegate) *handlers {
+	return &handlers{
+		delegate: delegate,
+	}
+}
+
+// query returns a list of dead letters with paging.
+func (h *handlers) query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	pg, err := page.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	if pg.Cursor != nil {
+		return validate.NewFieldsError("cursor", errors.New("not supported"))
+	}
+
+	filter := parseFilter(r)
+
+	dls, err := h.delegate.QueryDeadLetters(ctx, filter, pg.Number, pg.RowsPerPage)
+	if err != nil {
+		return h.error(fmt.Errorf("query: %w", err))
+	}
+
+	total, err := h.delegate.CountDeadLetters(ctx, filter)
+	if err != nil {
+		return h.error(fmt.Errorf("count: %w", err))
+	}
+
+	return web.Respond(ctx, w, v1.NewPageDocument(toAppDeadLetters(dls), total, pg.Number, pg.RowsPerPage), http.StatusOK)
+}
+
+// queryByID returns a dead letter by its ID.
+func (h *handlers) queryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	eventID, err := uuid.Parse(web.Param(r, "event_id"))
+	if err != nil {
+		return validate.NewFieldsError("event_id", err)
+	}
+
+	dl, err := h.delegate.QueryDeadLetterByID(ctx, eventID)
+	if err != nil {
+		return h.error(err)
+	}
+
+	return web.Respond(ctx, w, toAppDeadLetter(dl), http.StatusOK)
+}
+
+// replay moves a dead letter back to the outbox to be delivered again.
+func (h *handlers) replay(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	eventID, err := uuid.Parse(web.Param(r, "event_id"))
+	if err != nil {
+		return validate.NewFieldsError("event_id", err)
+	}
+
+	ev, err := h.delegate.Replay(ctx, eventID)
+	if err != nil {
+		return h.error(err)
+	}
+
+	return web.Respond(ctx, w, toAppEvent(ev), http.StatusOK)
+}
+
+// error maps the errors of the delegate to the responses for them.
+func (h *handlers) error(err error) error {
+	switch {
+	case errors.Is(err, delegate.ErrNotFound):
+		return v1.NewTrustedError(err, http.StatusNotFound)
+	case errors.Is(err, delegate.ErrNotDurable):
+		return v1.NewTrustedError(err, http.StatusNotImplemented)
+	}
+
+	return err
+}
@@ -0,0 +1,46 @@
+package outboxgrp
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+	"slices"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log      *logger.Logger
+	Delegate *delegate.Delegate
+	Auth     *auth.Auth
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.Auth)
+	ruleAdmin := mid.Authorize(cfg.Auth, auth.RuleAdminOnly)
+
+	hdl := new(cfg.Delegate)
+	app.Handle(http.MethodGet, version, "/outbox/deadletters", hdl.query, authen, ruleAdmin).Describe(web.RouteDoc{
+		Summary:  "List the dead lettered events",
+		Response: v1.PageDocument[AppDeadLetter]{},
+		Security: web.SecurityBearer,
+		Query:    slices.Concat(page.Params(false), filterParams),
+	})
+	app.Handle(http.MethodGet, version, "/outbox/deadletters/{event_id}", hdl.queryByID, authen, ruleAdmin).Describe(web.RouteDoc{
+		Summary:  "Get a dead lettered event",
+		Response: AppDeadLetter{},
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodPost, version, "/outbox/deadletters/{event_id}/replay", hdl.replay, authen, ruleAdmin).Describe(web.RouteDoc{
+		Summary:  "Replay a dead lettered event",
+		Response: AppEvent{},
+		Security: web.SecurityBearer,
+	})
+}
@@ -1,7 +1,10 @@
 package patientgrp
 
 import (
+	"context"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/patient"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
 	"github.com/fadhilijuma/gateone-service/foundation/validate"
 	"net/http"
 	"strconv"
@@ -9,16 +12,21 @@ import (
 	"github.com/google/uuid"
 )
 
-func parseFilter(r *http.Request) (patient.QueryFilter, error) {
-	const (
-		filterByPatientID = "patient_id"
-		filterByUserID    = "user_id"
-		filterByAge       = "age"
-		filterByName      = "name"
-		filterByCondition = "condition"
-		filterByHealed    = "healed"
-	)
+// Set of query string parameters read by parseFilter.
+const (
+	filterByPatientID = "patient_id"
+	filterByUserID    = "user_id"
+	filterByRegionID  = "region_id"
+	filterByAge       = "age"
+	filterByName      = "name"
+	filterByCondition = "condition"
+	filterByHealed    = "healed"
+)
+
+// filterParams lists the query string parameters read by parseFilter.
+var filterParams = []string{filterByPatientID, filterByUserID, filterByRegionID, filterByAge, filterByName, filterByCondition, filterByHealed}
 
+func parseFilter(r *http.Request) (patient.QueryFilter, error) {
 	values := r.URL.Query()
 
 	var filter patient.QueryFilter
@@ -47,6 +55,14 @@ func parseFilter(r *http.Request) (patient.QueryFilter, error) {
 		filter.WithUserID(id)
 	}
 
+	if regionID := values.Get(filterByRegionID); regionID != "" {
+		id, err := uuid.Parse(regionID)
+		if err != nil {
+			return patient.QueryFilter{}, validate.NewFieldsError(filterByRegionID, err)
+		}
+		filter.WithRegionID(id)
+	}
+
 	if name := values.Get(filterByName); name != "" {
 		filter.WithName(name)
 	}
@@ -63,3 +79,15 @@ func parseFilter(r *http.Request) (patient.QueryFilter, error) {
 
 	return filter, nil
 }
+
+// scopeFilter restricts the filter to the scope of patients the policy lets
+// the caller see, so a list is narrowed instead of forbidden.
+func scopeFilter(ctx context.Context, filter *patient.QueryFilter) {
+	switch mid.GetScope(ctx) {
+	case auth.ScopeAll:
+	case auth.ScopeRegion:
+		filter.WithRegionID(mid.GetClaims(ctx).RegionID)
+	default:
+		filter.WithUserID(mid.GetUserID(ctx))
+	}
+}
@@ -12,13 +12,14 @@ import (
 type AppPatient struct {
 	ID          string   `json:"id"`
 	UserID      string   `json:"userID"`
-	Name        string   `json:"name"`
+	Name        string   `json:"name" log:"redact"`
 	Age         int      `json:"age"`
 	VideoLinks  []string `json:"video_links"`
 	Condition   string   `json:"condition"`
 	Healed      bool     `json:"healed"`
 	DateCreated string   `json:"dateCreated"`
 	DateUpdated string   `json:"dateUpdated"`
+	Version     int      `json:"version"`
 }
 
 func toAppPatient(pn patient.Patient) AppPatient {
@@ -32,6 +33,7 @@ func toAppPatient(pn patient.Patient) AppPatient {
 		Healed:      pn.Healed,
 		DateCreated: pn.DateCreated.Format(time.RFC3339),
 		DateUpdated: pn.DateUpdated.Format(time.RFC3339),
+		Version:     pn.Version,
 	}
 }
 
@@ -46,7 +48,7 @@ func toAppPatients(pns []patient.Patient) []AppPatient {
 
 // AppNewPatient defines the data needed to add a new patient.
 type AppNewPatient struct {
-	Name       string   `json:"name" validate:"required"`
+	Name       string   `json:"name" validate:"required" log:"redact"`
 	Age        int      `json:"age" validate:"required"`
 	VideoLinks []string `json:"video_links" validate:"required"`
 	Condition  string   `json:"condition" validate:"required"`
@@ -77,7 +79,7 @@ func (app AppNewPatient) Validate() error {
 
 // AppUpdatePatient defines the data needed to update a patient.
 type AppUpdatePatient struct {
-	Name       *string  `json:"name"`
+	Name       *string  `json:"name" log:"redact"`
 	Age        *int     `json:"age"`
 	VideoLinks []string `json:"video_links"`
 	Condition  *string  `json:"condition"`
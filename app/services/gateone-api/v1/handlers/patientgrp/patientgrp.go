@@ -5,11 +5,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/patient"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/etag"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
 	"net/http"
 )
@@ -22,17 +27,50 @@ var (
 type handlers struct {
 	patient *patient.Core
 	user    *user.Core
+	audit   *audit.Core
 }
 
-func new(patient *patient.Core, user *user.Core) *handlers {
+func new(patient *patient.Core, user *user.Core, audit *audit.Core) *handlers {
 	return &handlers{
 		patient: patient,
 		user:    user,
+		audit:   audit,
 	}
 }
 
+// executeUnderTransaction constructs a new handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *handlers) executeUnderTransaction(ctx context.Context) (*handlers, error) {
+	tx, ok := transaction.Get(ctx)
+	if !ok {
+		return h, nil
+	}
+
+	patient, err := h.patient.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.user.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	audit, err := h.audit.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(patient, user, audit), nil
+}
+
 // create adds a new patient to the system.
 func (h *handlers) create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	var app AppNewPatient
 	if err := web.Decode(r, &app); err != nil {
 		return v1.NewTrustedError(err, http.StatusBadRequest)
@@ -40,14 +78,28 @@ func (h *handlers) create(ctx context.Context, w http.ResponseWriter, r *http.Re
 
 	pn, err := h.patient.Create(ctx, toCoreNewPatient(ctx, app))
 	if err != nil {
-		return fmt.Errorf("create: app[%+v]: %w", app, err)
+		if errors.Is(err, patient.ErrUserDisabled) {
+			return v1.NewTrustedError(err, http.StatusBadRequest)
+		}
+		return fmt.Errorf("create: app[%+v]: %w", logger.Redact(app), err)
 	}
 
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionCreated, patient.Domain, pn.ID, nil, pn); err != nil {
+		return err
+	}
+
+	etag.Set(w, pn.Version)
+
 	return web.Respond(ctx, w, toAppPatient(pn), http.StatusCreated)
 }
 
 // update updates a patient in the system.
 func (h *handlers) update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	var app AppUpdatePatient
 	if err := web.Decode(r, &app); err != nil {
 		return v1.NewTrustedError(err, http.StatusBadRequest)
@@ -55,22 +107,51 @@ func (h *handlers) update(ctx context.Context, w http.ResponseWriter, r *http.Re
 
 	pn := mid.GetPatient(ctx)
 
+	if err := etag.Check(r, pn.Version); err != nil {
+		return err
+	}
+
 	updPn, err := h.patient.Update(ctx, pn, toCoreUpdatePatient(app))
 	if err != nil {
-		return fmt.Errorf("update: patientID[%s] app[%+v]: %w", updPn.ID, app, err)
+		if errors.Is(err, patient.ErrVersionConflict) {
+			return v1.NewTrustedError(err, http.StatusPreconditionFailed)
+		}
+		return fmt.Errorf("update: patientID[%s] app[%+v]: %w", pn.ID, logger.Redact(app), err)
 	}
 
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionUpdated, patient.Domain, updPn.ID, pn, updPn); err != nil {
+		return err
+	}
+
+	etag.Set(w, updPn.Version)
+
 	return web.Respond(ctx, w, toAppPatient(updPn), http.StatusOK)
 }
 
 // delete removes a patient from the system.
 func (h *handlers) delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	prd := mid.GetPatient(ctx)
 
+	if err := etag.Check(r, prd.Version); err != nil {
+		return err
+	}
+
 	if err := h.patient.Delete(ctx, prd); err != nil {
+		if errors.Is(err, patient.ErrVersionConflict) {
+			return v1.NewTrustedError(err, http.StatusPreconditionFailed)
+		}
 		return fmt.Errorf("delete: patientID[%s]: %w", prd.ID, err)
 	}
 
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionDeleted, patient.Domain, prd.ID, prd, nil); err != nil {
+		return err
+	}
+
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
 }
 
@@ -85,12 +166,17 @@ func (h *handlers) query(ctx context.Context, w http.ResponseWriter, r *http.Req
 	if err != nil {
 		return err
 	}
+	scopeFilter(ctx, &filter)
 
 	orderBy, err := parseOrder(r)
 	if err != nil {
 		return err
 	}
 
+	if page.Cursor != nil {
+		return h.queryByCursor(ctx, w, filter, orderBy, page)
+	}
+
 	prds, err := h.patient.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
 	if err != nil {
 		return fmt.Errorf("query: %w", err)
@@ -104,7 +190,29 @@ func (h *handlers) query(ctx context.Context, w http.ResponseWriter, r *http.Req
 	return web.Respond(ctx, w, v1.NewPageDocument(toAppPatients(prds), total, page.Number, page.RowsPerPage), http.StatusOK)
 }
 
+// queryByCursor returns a list of patients with cursor paging.
+func (h *handlers) queryByCursor(ctx context.Context, w http.ResponseWriter, filter patient.QueryFilter, orderBy order.By, pg page.Page) error {
+	if err := pg.Cursor.Check(orderBy.String()); err != nil {
+		return v1.NewTrustedError(err, http.StatusBadRequest)
+	}
+
+	// One more row than the page holds is queried to tell if there are more.
+	prds, err := h.patient.QueryByCursor(ctx, filter, orderBy, *pg.Cursor, pg.RowsPerPage+1)
+	if err != nil {
+		return fmt.Errorf("querybycursor: %w", err)
+	}
+
+	prds, next, prev := page.Window(pg, orderBy.String(), prds, func(pn patient.Patient) []string {
+		return patient.CursorValues(pn, orderBy)
+	})
+
+	return web.Respond(ctx, w, v1.NewCursorDocument(toAppPatients(prds), next, prev, pg.RowsPerPage), http.StatusOK)
+}
+
 // queryByID returns a patient by its ID.
 func (h *handlers) queryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-	return web.Respond(ctx, w, toAppPatient(mid.GetPatient(ctx)), http.StatusOK)
+	pn := mid.GetPatient(ctx)
+	etag.Set(w, pn.Version)
+
+	return web.Respond(ctx, w, toAppPatient(pn), http.StatusOK)
 }
@@ -1,45 +1,92 @@
 package patientgrp
 
 import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit/stores/auditdb"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/patient"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/patient/stores/patientdb"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user/stores/usercache"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user/stores/userdb"
+	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/idempotency/stores/idempotencydb"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/ratelimit"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Log      *logger.Logger
-	Delegate *delegate.Delegate
-	Auth     *auth.Auth
-	DB       *sqlx.DB
+	Log                *logger.Logger
+	Delegate           *delegate.Delegate
+	Auth               *auth.Auth
+	DB                 *sqlx.DB
+	UserCache          *usercache.Cache
+	RateLimiter        ratelimit.Storer
+	RateLimit          ratelimit.Limit
+	IdempotencyWindow  time.Duration
+	IdempotencyMaxBody int64
+	RequireIfMatch     bool
 }
 
 // Routes adds specific routes for this group.
 func Routes(app *web.App, cfg Config) {
 	const version = "v1"
 
-	usrCore := user.NewCore(cfg.Log, cfg.Delegate, usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB)))
+	usrCore := user.NewCore(cfg.Log, cfg.Delegate, usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB), cfg.UserCache))
 	prdCore := patient.NewCore(cfg.Log, usrCore, cfg.Delegate, patientdb.NewStore(cfg.Log, cfg.DB))
+	audCore := audit.NewCore(cfg.Log, auditdb.NewStore(cfg.Log, cfg.DB))
 
 	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.Log, cfg.RateLimiter, "patients", cfg.RateLimit)
+	tran := mid.ExecuteInTransaction(cfg.Log, sqldb.NewBeginner(cfg.DB))
+	idem := mid.Idempotency(cfg.Log, idempotencydb.NewStore(cfg.Log, cfg.DB), cfg.IdempotencyWindow, cfg.IdempotencyMaxBody)
+	ifMatch := mid.RequireIfMatch(cfg.RequireIfMatch)
+	scope := mid.Scope(cfg.Auth, "patients")
 	ruleAny := mid.Authorize(cfg.Auth, auth.RuleAny)
 	ruleUserOnly := mid.Authorize(cfg.Auth, auth.RuleUserOnly)
-	ruleAdminOrSubject := mid.AuthorizePatient(cfg.Auth, auth.RuleAdminOrSubject, prdCore)
+	ruleAdminOrSubject := mid.AuthorizePatient(cfg.Auth, auth.RuleAdminOrSubject, prdCore, usrCore)
+	ruleAdminRegionSupervisorOrSubject := mid.AuthorizePatient(cfg.Auth, auth.RuleAdminRegionSupervisorOrSubject, prdCore, usrCore)
 
-	hdl := new(prdCore, usrCore)
-	app.Handle(http.MethodGet, version, "/patients", hdl.query, authen, ruleAny)
-	app.Handle(http.MethodGet, version, "/patients/{patient_id}", hdl.queryByID, authen, ruleAdminOrSubject)
-	app.Handle(http.MethodPost, version, "/patients", hdl.create, authen, ruleUserOnly)
-	app.Handle(http.MethodPut, version, "/patients/{patient_id}", hdl.update, authen, ruleAdminOrSubject)
-	app.Handle(http.MethodDelete, version, "/patients/{patient_id}", hdl.delete, authen, ruleAdminOrSubject)
+	hdl := new(prdCore, usrCore, audCore)
+	app.Handle(http.MethodGet, version, "/patients", hdl.query, authen, limit, ruleAny, scope).Describe(web.RouteDoc{
+		Summary:  "List patients",
+		Response: v1.PageDocument[AppPatient]{},
+		Security: web.SecurityBearer,
+		Query:    slices.Concat(page.Params(true), []string{order.Param}, filterParams),
+	})
+	app.Handle(http.MethodGet, version, "/patients/{patient_id}", hdl.queryByID, authen, limit, ruleAdminRegionSupervisorOrSubject).Describe(web.RouteDoc{
+		Summary:  "Get a patient",
+		Response: AppPatient{},
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodPost, version, "/patients", hdl.create, authen, limit, ruleUserOnly, tran, idem).Describe(web.RouteDoc{
+		Summary:  "Create a patient",
+		Request:  AppNewPatient{},
+		Response: AppPatient{},
+		Status:   http.StatusCreated,
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodPut, version, "/patients/{patient_id}", hdl.update, authen, limit, ruleAdminOrSubject, ifMatch, tran).Describe(web.RouteDoc{
+		Summary:  "Update a patient",
+		Request:  AppUpdatePatient{},
+		Response: AppPatient{},
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodDelete, version, "/patients/{patient_id}", hdl.delete, authen, limit, ruleAdminOrSubject, ifMatch, tran).Describe(web.RouteDoc{
+		Summary:  "Delete a patient",
+		Status:   http.StatusNoContent,
+		Security: web.SecurityBearer,
+	})
 }
@@ -7,12 +7,16 @@ import (
 	"net/http"
 )
 
-func parseFilter(r *http.Request) (region.QueryFilter, error) {
-	const (
-		filterByRoleID = "role_id"
-		filterByName   = "name"
-	)
+// Set of query string parameters read by parseFilter.
+const (
+	filterByRoleID = "role_id"
+	filterByName   = "name"
+)
 
+// filterParams lists the query string parameters read by parseFilter.
+var filterParams = []string{filterByRoleID, filterByName}
+
+func parseFilter(r *http.Request) (region.QueryFilter, error) {
 	values := r.URL.Query()
 
 	var filter region.QueryFilter
@@ -15,6 +15,7 @@ type AppRegion struct {
 	Name        string `json:"name"`
 	DateCreated string `json:"dateCreated"`
 	DateUpdated string `json:"dateUpdated"`
+	Version     int    `json:"version"`
 }
 
 func toAppRegion(rn region.Region) AppRegion {
@@ -24,6 +25,7 @@ func toAppRegion(rn region.Region) AppRegion {
 		Name:        rn.Name,
 		DateCreated: rn.DateCreated.Format(time.RFC3339),
 		DateUpdated: rn.DateUpdated.Format(time.RFC3339),
+		Version:     rn.Version,
 	}
 }
 
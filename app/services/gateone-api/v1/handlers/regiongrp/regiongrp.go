@@ -5,10 +5,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/region"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/etag"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
 	"net/http"
@@ -22,17 +26,50 @@ var (
 type handlers struct {
 	region *region.Core
 	user   *user.Core
+	audit  *audit.Core
 }
 
-func new(region *region.Core, user *user.Core) *handlers {
+func new(region *region.Core, user *user.Core, audit *audit.Core) *handlers {
 	return &handlers{
 		region: region,
 		user:   user,
+		audit:  audit,
 	}
 }
 
+// executeUnderTransaction constructs a new handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *handlers) executeUnderTransaction(ctx context.Context) (*handlers, error) {
+	tx, ok := transaction.Get(ctx)
+	if !ok {
+		return h, nil
+	}
+
+	region, err := h.region.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.user.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	audit, err := h.audit.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(region, user, audit), nil
+}
+
 // create adds a new role to the system.
 func (h *handlers) create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	var app AppNewRegion
 	if err := web.Decode(r, &app); err != nil {
 		return v1.NewTrustedError(err, http.StatusBadRequest)
@@ -40,14 +77,28 @@ func (h *handlers) create(ctx context.Context, w http.ResponseWriter, r *http.Re
 
 	rl, err := h.region.Create(ctx, toCoreNewRegion(ctx, app))
 	if err != nil {
+		if errors.Is(err, region.ErrUserDisabled) {
+			return v1.NewTrustedError(err, http.StatusBadRequest)
+		}
 		return fmt.Errorf("create: app[%+v]: %w", app, err)
 	}
 
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionCreated, region.Domain, rl.ID, nil, rl); err != nil {
+		return err
+	}
+
+	etag.Set(w, rl.Version)
+
 	return web.Respond(ctx, w, toAppRegion(rl), http.StatusCreated)
 }
 
 // update updates a condition in the system.
 func (h *handlers) update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	var app AppUpdateRegion
 	if err := web.Decode(r, &app); err != nil {
 		return v1.NewTrustedError(err, http.StatusBadRequest)
@@ -55,22 +106,51 @@ func (h *handlers) update(ctx context.Context, w http.ResponseWriter, r *http.Re
 
 	rn := mid.GetRegion(ctx)
 
+	if err := etag.Check(r, rn.Version); err != nil {
+		return err
+	}
+
 	upRegion, err := h.region.Update(ctx, rn, toCoreUpdateRegion(app))
 	if err != nil {
-		return fmt.Errorf("update: regionID[%s] app[%+v]: %w", upRegion.ID, app, err)
+		if errors.Is(err, region.ErrVersionConflict) {
+			return v1.NewTrustedError(err, http.StatusPreconditionFailed)
+		}
+		return fmt.Errorf("update: regionID[%s] app[%+v]: %w", rn.ID, app, err)
 	}
 
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionUpdated, region.Domain, upRegion.ID, rn, upRegion); err != nil {
+		return err
+	}
+
+	etag.Set(w, upRegion.Version)
+
 	return web.Respond(ctx, w, toAppRegion(upRegion), http.StatusOK)
 }
 
 // delete removes a region from the system.
 func (h *handlers) delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	rn := mid.GetRegion(ctx)
 
+	if err := etag.Check(r, rn.Version); err != nil {
+		return err
+	}
+
 	if err := h.region.Delete(ctx, rn); err != nil {
+		if errors.Is(err, region.ErrVersionConflict) {
+			return v1.NewTrustedError(err, http.StatusPreconditionFailed)
+		}
 		return fmt.Errorf("delete: regionID[%s]: %w", rn.ID, err)
 	}
 
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionDeleted, region.Domain, rn.ID, rn, nil); err != nil {
+		return err
+	}
+
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
 }
 
@@ -91,6 +171,10 @@ func (h *handlers) query(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return err
 	}
 
+	if pg.Cursor != nil {
+		return h.queryByCursor(ctx, w, filter, orderBy, pg)
+	}
+
 	regions, err := h.region.Query(ctx, filter, orderBy, pg.Number, pg.RowsPerPage)
 	if err != nil {
 		return fmt.Errorf("query: %w", err)
@@ -104,7 +188,29 @@ func (h *handlers) query(ctx context.Context, w http.ResponseWriter, r *http.Req
 	return web.Respond(ctx, w, v1.NewPageDocument(toAppRegions(regions), total, pg.Number, pg.RowsPerPage), http.StatusOK)
 }
 
+// queryByCursor returns a list of regions with cursor paging.
+func (h *handlers) queryByCursor(ctx context.Context, w http.ResponseWriter, filter region.QueryFilter, orderBy order.By, pg page.Page) error {
+	if err := pg.Cursor.Check(orderBy.String()); err != nil {
+		return v1.NewTrustedError(err, http.StatusBadRequest)
+	}
+
+	// One more row than the page holds is queried to tell if there are more.
+	regions, err := h.region.QueryByCursor(ctx, filter, orderBy, *pg.Cursor, pg.RowsPerPage+1)
+	if err != nil {
+		return fmt.Errorf("querybycursor: %w", err)
+	}
+
+	regions, next, prev := page.Window(pg, orderBy.String(), regions, func(rgn region.Region) []string {
+		return region.CursorValues(rgn, orderBy)
+	})
+
+	return web.Respond(ctx, w, v1.NewCursorDocument(toAppRegions(regions), next, prev, pg.RowsPerPage), http.StatusOK)
+}
+
 // queryByID returns a region by its ID.
 func (h *handlers) queryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-	return web.Respond(ctx, w, toAppRegion(mid.GetRegion(ctx)), http.StatusOK)
+	rn := mid.GetRegion(ctx)
+	etag.Set(w, rn.Version)
+
+	return web.Respond(ctx, w, toAppRegion(rn), http.StatusOK)
 }
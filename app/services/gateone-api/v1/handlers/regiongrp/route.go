@@ -1,45 +1,90 @@
 package regiongrp
 
 import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit/stores/auditdb"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/region"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/region/stores/regiondb"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user/stores/usercache"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user/stores/userdb"
+	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/idempotency/stores/idempotencydb"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/ratelimit"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Log      *logger.Logger
-	Delegate *delegate.Delegate
-	Auth     *auth.Auth
-	DB       *sqlx.DB
+	Log                *logger.Logger
+	Delegate           *delegate.Delegate
+	Auth               *auth.Auth
+	DB                 *sqlx.DB
+	UserCache          *usercache.Cache
+	RateLimiter        ratelimit.Storer
+	RateLimit          ratelimit.Limit
+	IdempotencyWindow  time.Duration
+	IdempotencyMaxBody int64
+	RequireIfMatch     bool
 }
 
 // Routes adds specific routes for this group.
 func Routes(app *web.App, cfg Config) {
 	const version = "v1"
 
-	usrCore := user.NewCore(cfg.Log, cfg.Delegate, usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB)))
+	usrCore := user.NewCore(cfg.Log, cfg.Delegate, usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB), cfg.UserCache))
 	regionCore := region.NewCore(cfg.Log, usrCore, cfg.Delegate, regiondb.NewStore(cfg.Log, cfg.DB))
+	audCore := audit.NewCore(cfg.Log, auditdb.NewStore(cfg.Log, cfg.DB))
 
 	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.Log, cfg.RateLimiter, "regions", cfg.RateLimit)
+	tran := mid.ExecuteInTransaction(cfg.Log, sqldb.NewBeginner(cfg.DB))
+	idem := mid.Idempotency(cfg.Log, idempotencydb.NewStore(cfg.Log, cfg.DB), cfg.IdempotencyWindow, cfg.IdempotencyMaxBody)
+	ifMatch := mid.RequireIfMatch(cfg.RequireIfMatch)
 	ruleAny := mid.Authorize(cfg.Auth, auth.RuleAny)
 	ruleUserOnly := mid.Authorize(cfg.Auth, auth.RuleUserOnly)
 	ruleAdminOrSubject := mid.AuthorizeRegion(cfg.Auth, auth.RuleAdminOrSubject, regionCore)
 
-	hdl := new(regionCore, usrCore)
-	app.Handle(http.MethodGet, version, "/regions", hdl.query, authen, ruleAny)
-	app.Handle(http.MethodGet, version, "/regions/{region_id}", hdl.queryByID, authen, ruleAdminOrSubject)
-	app.Handle(http.MethodPost, version, "/regions", hdl.create, authen, ruleUserOnly)
-	app.Handle(http.MethodPut, version, "/regions/{region_id}", hdl.update, authen, ruleAdminOrSubject)
-	app.Handle(http.MethodDelete, version, "/regions/{region_id}", hdl.delete, authen, ruleAdminOrSubject)
+	hdl := new(regionCore, usrCore, audCore)
+	app.Handle(http.MethodGet, version, "/regions", hdl.query, authen, limit, ruleAny).Describe(web.RouteDoc{
+		Summary:  "List regions",
+		Response: v1.PageDocument[AppRegion]{},
+		Security: web.SecurityBearer,
+		Query:    slices.Concat(page.Params(true), []string{order.Param}, filterParams),
+	})
+	app.Handle(http.MethodGet, version, "/regions/{region_id}", hdl.queryByID, authen, limit, ruleAdminOrSubject).Describe(web.RouteDoc{
+		Summary:  "Get a region",
+		Response: AppRegion{},
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodPost, version, "/regions", hdl.create, authen, limit, ruleUserOnly, tran, idem).Describe(web.RouteDoc{
+		Summary:  "Create a region",
+		Request:  AppNewRegion{},
+		Response: AppRegion{},
+		Status:   http.StatusCreated,
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodPut, version, "/regions/{region_id}", hdl.update, authen, limit, ruleAdminOrSubject, ifMatch, tran).Describe(web.RouteDoc{
+		Summary:  "Update a region",
+		Request:  AppUpdateRegion{},
+		Response: AppRegion{},
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodDelete, version, "/regions/{region_id}", hdl.delete, authen, limit, ruleAdminOrSubject, ifMatch, tran).Describe(web.RouteDoc{
+		Summary:  "Delete a region",
+		Status:   http.StatusNoContent,
+		Security: web.SecurityBearer,
+	})
 }
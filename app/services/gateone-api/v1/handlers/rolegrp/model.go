@@ -15,6 +15,7 @@ type AppRole struct {
 	Name        string `json:"name"`
 	DateCreated string `json:"dateCreated"`
 	DateUpdated string `json:"dateUpdated"`
+	Version     int    `json:"version"`
 }
 
 func toAppRole(rl role.Role) AppRole {
@@ -24,6 +25,7 @@ func toAppRole(rl role.Role) AppRole {
 		Name:        rl.Name,
 		DateCreated: rl.DateCreated.Format(time.RFC3339),
 		DateUpdated: rl.DateUpdated.Format(time.RFC3339),
+		Version:     rl.Version,
 	}
 }
 
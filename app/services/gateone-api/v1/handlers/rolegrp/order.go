@@ -1,10 +1,8 @@
 package rolegrp
 
 import (
-	"errors"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/role"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
-	"github.com/fadhilijuma/gateone-service/foundation/validate"
 	"net/http"
 )
 
@@ -26,11 +24,5 @@ func parseOrder(r *http.Request) (order.By, error) {
 		return order.By{}, err
 	}
 
-	if _, exists := orderByFields[orderBy.Field]; !exists {
-		return order.By{}, validate.NewFieldsError(orderBy.Field, errors.New("order field does not exist"))
-	}
-
-	orderBy.Field = orderByFields[orderBy.Field]
-
-	return orderBy, nil
+	return orderBy.Map(orderByFields)
 }
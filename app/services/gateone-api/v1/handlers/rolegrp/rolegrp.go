@@ -5,10 +5,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/role"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/etag"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
 	"net/http"
@@ -20,19 +24,52 @@ var (
 )
 
 type handlers struct {
-	role *role.Core
-	user *user.Core
+	role  *role.Core
+	user  *user.Core
+	audit *audit.Core
 }
 
-func new(role *role.Core, user *user.Core) *handlers {
+func new(role *role.Core, user *user.Core, audit *audit.Core) *handlers {
 	return &handlers{
-		role: role,
-		user: user,
+		role:  role,
+		user:  user,
+		audit: audit,
 	}
 }
 
+// executeUnderTransaction constructs a new handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *handlers) executeUnderTransaction(ctx context.Context) (*handlers, error) {
+	tx, ok := transaction.Get(ctx)
+	if !ok {
+		return h, nil
+	}
+
+	role, err := h.role.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.user.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	audit, err := h.audit.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(role, user, audit), nil
+}
+
 // create adds a new role to the system.
 func (h *handlers) create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	var app AppNewRole
 	if err := web.Decode(r, &app); err != nil {
 		return v1.NewTrustedError(err, http.StatusBadRequest)
@@ -40,14 +77,28 @@ func (h *handlers) create(ctx context.Context, w http.ResponseWriter, r *http.Re
 
 	rl, err := h.role.Create(ctx, toCoreNewPatient(ctx, app))
 	if err != nil {
+		if errors.Is(err, role.ErrUserDisabled) {
+			return v1.NewTrustedError(err, http.StatusBadRequest)
+		}
 		return fmt.Errorf("create: app[%+v]: %w", app, err)
 	}
 
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionCreated, role.Domain, rl.ID, nil, rl); err != nil {
+		return err
+	}
+
+	etag.Set(w, rl.Version)
+
 	return web.Respond(ctx, w, toAppRole(rl), http.StatusCreated)
 }
 
 // update updates a condition in the system.
 func (h *handlers) update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	var app AppUpdateRole
 	if err := web.Decode(r, &app); err != nil {
 		return v1.NewTrustedError(err, http.StatusBadRequest)
@@ -55,22 +106,51 @@ func (h *handlers) update(ctx context.Context, w http.ResponseWriter, r *http.Re
 
 	rl := mid.GetRole(ctx)
 
+	if err := etag.Check(r, rl.Version); err != nil {
+		return err
+	}
+
 	upRole, err := h.role.Update(ctx, rl, toCoreUpdateRole(app))
 	if err != nil {
-		return fmt.Errorf("update: roleID[%s] app[%+v]: %w", upRole.ID, app, err)
+		if errors.Is(err, role.ErrVersionConflict) {
+			return v1.NewTrustedError(err, http.StatusPreconditionFailed)
+		}
+		return fmt.Errorf("update: roleID[%s] app[%+v]: %w", rl.ID, app, err)
 	}
 
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionUpdated, role.Domain, upRole.ID, rl, upRole); err != nil {
+		return err
+	}
+
+	etag.Set(w, upRole.Version)
+
 	return web.Respond(ctx, w, toAppRole(upRole), http.StatusOK)
 }
 
 // delete removes a role from the system.
 func (h *handlers) delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	rl := mid.GetRole(ctx)
 
+	if err := etag.Check(r, rl.Version); err != nil {
+		return err
+	}
+
 	if err := h.role.Delete(ctx, rl); err != nil {
+		if errors.Is(err, role.ErrVersionConflict) {
+			return v1.NewTrustedError(err, http.StatusPreconditionFailed)
+		}
 		return fmt.Errorf("delete: roleID[%s]: %w", rl.ID, err)
 	}
 
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionDeleted, role.Domain, rl.ID, rl, nil); err != nil {
+		return err
+	}
+
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
 }
 
@@ -91,6 +171,10 @@ func (h *handlers) query(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return err
 	}
 
+	if pg.Cursor != nil {
+		return h.queryByCursor(ctx, w, filter, orderBy, pg)
+	}
+
 	roles, err := h.role.Query(ctx, filter, orderBy, pg.Number, pg.RowsPerPage)
 	if err != nil {
 		return fmt.Errorf("query: %w", err)
@@ -104,7 +188,29 @@ func (h *handlers) query(ctx context.Context, w http.ResponseWriter, r *http.Req
 	return web.Respond(ctx, w, v1.NewPageDocument(toAppRoles(roles), total, pg.Number, pg.RowsPerPage), http.StatusOK)
 }
 
+// queryByCursor returns a list of roles with cursor paging.
+func (h *handlers) queryByCursor(ctx context.Context, w http.ResponseWriter, filter role.QueryFilter, orderBy order.By, pg page.Page) error {
+	if err := pg.Cursor.Check(orderBy.String()); err != nil {
+		return v1.NewTrustedError(err, http.StatusBadRequest)
+	}
+
+	// One more row than the page holds is queried to tell if there are more.
+	roles, err := h.role.QueryByCursor(ctx, filter, orderBy, *pg.Cursor, pg.RowsPerPage+1)
+	if err != nil {
+		return fmt.Errorf("querybycursor: %w", err)
+	}
+
+	roles, next, prev := page.Window(pg, orderBy.String(), roles, func(rl role.Role) []string {
+		return role.CursorValues(rl, orderBy)
+	})
+
+	return web.Respond(ctx, w, v1.NewCursorDocument(toAppRoles(roles), next, prev, pg.RowsPerPage), http.StatusOK)
+}
+
 // queryByID returns a role by its ID.
 func (h *handlers) queryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-	return web.Respond(ctx, w, toAppRole(mid.GetRole(ctx)), http.StatusOK)
+	rl := mid.GetRole(ctx)
+	etag.Set(w, rl.Version)
+
+	return web.Respond(ctx, w, toAppRole(rl), http.StatusOK)
 }
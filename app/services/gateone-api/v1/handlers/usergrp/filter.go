@@ -1,7 +1,10 @@
 package usergrp
 
 import (
+	"context"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
 	"github.com/fadhilijuma/gateone-service/foundation/validate"
 	"net/http"
 	"net/mail"
@@ -10,15 +13,20 @@ import (
 	"github.com/google/uuid"
 )
 
-func parseFilter(r *http.Request) (user.QueryFilter, error) {
-	const (
-		filterByUserID           = "user_id"
-		filterByEmail            = "email"
-		filterByStartCreatedDate = "start_created_date"
-		filterByEndCreatedDate   = "end_created_date"
-		filterByName             = "name"
-	)
+// Set of query string parameters read by parseFilter.
+const (
+	filterByUserID           = "user_id"
+	filterByEmail            = "email"
+	filterByRegionID         = "region_id"
+	filterByStartCreatedDate = "start_created_date"
+	filterByEndCreatedDate   = "end_created_date"
+	filterByName             = "name"
+)
+
+// filterParams lists the query string parameters read by parseFilter.
+var filterParams = []string{filterByUserID, filterByEmail, filterByRegionID, filterByStartCreatedDate, filterByEndCreatedDate, filterByName}
 
+func parseFilter(r *http.Request) (user.QueryFilter, error) {
 	values := r.URL.Query()
 
 	var filter user.QueryFilter
@@ -31,6 +39,14 @@ func parseFilter(r *http.Request) (user.QueryFilter, error) {
 		filter.WithUserID(id)
 	}
 
+	if regionID := values.Get(filterByRegionID); regionID != "" {
+		id, err := uuid.Parse(regionID)
+		if err != nil {
+			return user.QueryFilter{}, validate.NewFieldsError(filterByRegionID, err)
+		}
+		filter.WithRegionID(id)
+	}
+
 	if email := values.Get(filterByEmail); email != "" {
 		addr, err := mail.ParseAddress(email)
 		if err != nil {
@@ -61,3 +77,15 @@ func parseFilter(r *http.Request) (user.QueryFilter, error) {
 
 	return filter, nil
 }
+
+// scopeFilter restricts the filter to the scope of users the policy lets
+// the caller see, so a list is narrowed instead of forbidden.
+func scopeFilter(ctx context.Context, filter *user.QueryFilter) {
+	switch mid.GetScope(ctx) {
+	case auth.ScopeAll:
+	case auth.ScopeRegion:
+		filter.WithRegionID(mid.GetClaims(ctx).RegionID)
+	default:
+		filter.WithUserID(mid.GetUserID(ctx))
+	}
+}
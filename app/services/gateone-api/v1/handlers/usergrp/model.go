@@ -6,19 +6,23 @@ import (
 	"github.com/fadhilijuma/gateone-service/foundation/validate"
 	"net/mail"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // AppUser represents information about an individual user.
 type AppUser struct {
 	ID           string   `json:"id"`
-	Name         string   `json:"name"`
-	Email        string   `json:"email"`
+	Name         string   `json:"name" log:"redact"`
+	Email        string   `json:"email" log:"redact"`
 	Roles        []string `json:"roles"`
-	PasswordHash []byte   `json:"-"`
+	RegionID     string   `json:"regionID,omitempty"`
+	PasswordHash []byte   `json:"-" log:"redact"`
 	Department   string   `json:"department"`
 	Enabled      bool     `json:"enabled"`
 	DateCreated  string   `json:"dateCreated"`
 	DateUpdated  string   `json:"dateUpdated"`
+	Version      int      `json:"version"`
 }
 
 func toAppUser(usr user.User) AppUser {
@@ -27,16 +31,23 @@ func toAppUser(usr user.User) AppUser {
 		roles[i] = role.Name()
 	}
 
+	var regionID string
+	if usr.RegionID != uuid.Nil {
+		regionID = usr.RegionID.String()
+	}
+
 	return AppUser{
 		ID:           usr.ID.String(),
 		Name:         usr.Name,
 		Email:        usr.Email.Address,
 		Roles:        roles,
+		RegionID:     regionID,
 		PasswordHash: usr.PasswordHash,
 		Department:   usr.Department,
 		Enabled:      usr.Enabled,
 		DateCreated:  usr.DateCreated.Format(time.RFC3339),
 		DateUpdated:  usr.DateUpdated.Format(time.RFC3339),
+		Version:      usr.Version,
 	}
 }
 
@@ -51,12 +62,13 @@ func toAppUsers(users []user.User) []AppUser {
 
 // AppNewUser defines the data needed to add a new user.
 type AppNewUser struct {
-	Name            string   `json:"name" validate:"required"`
-	Email           string   `json:"email" validate:"required,email"`
+	Name            string   `json:"name" validate:"required" log:"redact"`
+	Email           string   `json:"email" validate:"required,email" log:"redact"`
 	Roles           []string `json:"roles" validate:"required"`
+	RegionID        string   `json:"regionID" validate:"omitempty,uuid"`
 	Department      string   `json:"department"`
-	Password        string   `json:"password" validate:"required"`
-	PasswordConfirm string   `json:"passwordConfirm" validate:"eqfield=Password"`
+	Password        string   `json:"password" validate:"required" log:"redact"`
+	PasswordConfirm string   `json:"passwordConfirm" validate:"eqfield=Password" log:"redact"`
 }
 
 func toCoreNewUser(app AppNewUser) (user.NewUser, error) {
@@ -74,10 +86,19 @@ func toCoreNewUser(app AppNewUser) (user.NewUser, error) {
 		return user.NewUser{}, fmt.Errorf("parse: %w", err)
 	}
 
+	var regionID uuid.UUID
+	if app.RegionID != "" {
+		regionID, err = uuid.Parse(app.RegionID)
+		if err != nil {
+			return user.NewUser{}, fmt.Errorf("parse: %w", err)
+		}
+	}
+
 	usr := user.NewUser{
 		Name:            app.Name,
 		Email:           *addr,
 		Roles:           roles,
+		RegionID:        regionID,
 		Department:      app.Department,
 		Password:        app.Password,
 		PasswordConfirm: app.PasswordConfirm,
@@ -97,12 +118,13 @@ func (app AppNewUser) Validate() error {
 
 // AppUpdateUser defines the data needed to update a user.
 type AppUpdateUser struct {
-	Name            *string  `json:"name"`
-	Email           *string  `json:"email" validate:"omitempty,email"`
+	Name            *string  `json:"name" log:"redact"`
+	Email           *string  `json:"email" validate:"omitempty,email" log:"redact"`
 	Roles           []string `json:"roles"`
+	RegionID        *string  `json:"regionID" validate:"omitempty,uuid"`
 	Department      *string  `json:"department"`
-	Password        *string  `json:"password"`
-	PasswordConfirm *string  `json:"passwordConfirm" validate:"omitempty,eqfield=Password"`
+	Password        *string  `json:"password" log:"redact"`
+	PasswordConfirm *string  `json:"passwordConfirm" validate:"omitempty,eqfield=Password" log:"redact"`
 	Enabled         *bool    `json:"enabled"`
 }
 
@@ -128,10 +150,20 @@ func toCoreUpdateUser(app AppUpdateUser) (user.UpdateUser, error) {
 		}
 	}
 
+	var regionID *uuid.UUID
+	if app.RegionID != nil {
+		id, err := uuid.Parse(*app.RegionID)
+		if err != nil {
+			return user.UpdateUser{}, fmt.Errorf("parse: %w", err)
+		}
+		regionID = &id
+	}
+
 	nu := user.UpdateUser{
 		Name:            app.Name,
 		Email:           addr,
 		Roles:           roles,
+		RegionID:        regionID,
 		Department:      app.Department,
 		Password:        app.Password,
 		PasswordConfirm: app.PasswordConfirm,
@@ -151,7 +183,7 @@ func (app AppUpdateUser) Validate() error {
 }
 
 type token struct {
-	Token string `json:"token"`
+	Token string `json:"token" log:"redact"`
 }
 
 func toToken(v string) token {
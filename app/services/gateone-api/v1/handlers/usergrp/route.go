@@ -1,42 +1,101 @@
 package usergrp
 
 import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit/stores/auditdb"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user/stores/usercache"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user/stores/userdb"
+	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/idempotency/stores/idempotencydb"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/ratelimit"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Log      *logger.Logger
-	Delegate *delegate.Delegate
-	Auth     *auth.Auth
-	DB       *sqlx.DB
+	Log                *logger.Logger
+	Delegate           *delegate.Delegate
+	Auth               *auth.Auth
+	DB                 *sqlx.DB
+	UserCache          *usercache.Cache
+	RateLimiter        ratelimit.Storer
+	RateLimit          ratelimit.Limit
+	IdempotencyWindow  time.Duration
+	IdempotencyMaxBody int64
+	RequireIfMatch     bool
+	TokenRateLimit     ratelimit.Limit
 }
 
 // Routes adds specific routes for this group.
 func Routes(app *web.App, cfg Config) {
 	const version = "v1"
 
-	usrCore := user.NewCore(cfg.Log, cfg.Delegate, usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB)))
+	usrCore := user.NewCore(cfg.Log, cfg.Delegate, usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB), cfg.UserCache))
+	audCore := audit.NewCore(cfg.Log, auditdb.NewStore(cfg.Log, cfg.DB))
 
 	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.Log, cfg.RateLimiter, "users", cfg.RateLimit)
+	tran := mid.ExecuteInTransaction(cfg.Log, sqldb.NewBeginner(cfg.DB))
+	idem := mid.Idempotency(cfg.Log, idempotencydb.NewStore(cfg.Log, cfg.DB), cfg.IdempotencyWindow, cfg.IdempotencyMaxBody)
+	ifMatch := mid.RequireIfMatch(cfg.RequireIfMatch)
+	scope := mid.Scope(cfg.Auth, "users")
+	limitToken := mid.RateLimit(cfg.Log, cfg.RateLimiter, "tokens", cfg.TokenRateLimit)
 	ruleAdmin := mid.Authorize(cfg.Auth, auth.RuleAdminOnly)
+	ruleAdminOrSupervisor := mid.Authorize(cfg.Auth, auth.RuleAdminOrSupervisor)
 	ruleAdminOrSubject := mid.AuthorizeUser(cfg.Auth, auth.RuleAdminOrSubject, usrCore)
+	ruleAdminRegionSupervisorOrSubject := mid.AuthorizeUser(cfg.Auth, auth.RuleAdminRegionSupervisorOrSubject, usrCore)
 
-	hdl := new(usrCore, cfg.Auth)
-	app.Handle(http.MethodGet, version, "/users/token/{kid}", hdl.token)
-	app.Handle(http.MethodGet, version, "/users", hdl.query, authen, ruleAdmin)
-	app.Handle(http.MethodGet, version, "/users/{user_id}", hdl.queryByID, authen, ruleAdminOrSubject)
-	app.Handle(http.MethodPost, version, "/users", hdl.create, authen, ruleAdmin)
-	app.Handle(http.MethodPut, version, "/users/{user_id}", hdl.update, authen, ruleAdminOrSubject)
-	app.Handle(http.MethodDelete, version, "/users/{user_id}", hdl.delete, authen, ruleAdminOrSubject)
+	hdl := new(usrCore, cfg.Auth, audCore)
+	app.Handle(http.MethodGet, version, "/users/token", hdl.token, limitToken).Describe(web.RouteDoc{
+		Summary:  "Issue a token",
+		Response: token{},
+		Security: web.SecurityBasic,
+	})
+	app.Handle(http.MethodGet, version, "/users/token/{kid}", hdl.token, limitToken).Describe(web.RouteDoc{
+		Summary:  "Issue a token signed with a key",
+		Response: token{},
+		Security: web.SecurityBasic,
+	})
+	app.Handle(http.MethodGet, version, "/users", hdl.query, authen, limit, ruleAdminOrSupervisor, scope).Describe(web.RouteDoc{
+		Summary:  "List users",
+		Response: v1.PageDocument[AppUser]{},
+		Security: web.SecurityBearer,
+		Query:    slices.Concat(page.Params(true), []string{order.Param}, filterParams),
+	})
+	app.Handle(http.MethodGet, version, "/users/{user_id}", hdl.queryByID, authen, limit, ruleAdminRegionSupervisorOrSubject).Describe(web.RouteDoc{
+		Summary:  "Get a user",
+		Response: AppUser{},
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodPost, version, "/users", hdl.create, authen, limit, ruleAdmin, tran, idem).Describe(web.RouteDoc{
+		Summary:  "Create a user",
+		Request:  AppNewUser{},
+		Response: AppUser{},
+		Status:   http.StatusCreated,
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodPut, version, "/users/{user_id}", hdl.update, authen, limit, ruleAdminOrSubject, ifMatch, tran).Describe(web.RouteDoc{
+		Summary:  "Update a user",
+		Request:  AppUpdateUser{},
+		Response: AppUser{},
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodDelete, version, "/users/{user_id}", hdl.delete, authen, limit, ruleAdminOrSubject, ifMatch, tran).Describe(web.RouteDoc{
+		Summary:  "Delete a user",
+		Status:   http.StatusNoContent,
+		Security: web.SecurityBearer,
+	})
 }
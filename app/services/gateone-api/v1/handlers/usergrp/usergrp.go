@@ -5,34 +5,65 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/etag"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
-	"github.com/fadhilijuma/gateone-service/foundation/validate"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
 	"net/http"
 	"net/mail"
-	"time"
 
-	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
 type handlers struct {
-	user *user.Core
-	auth *auth.Auth
+	user  *user.Core
+	auth  *auth.Auth
+	audit *audit.Core
 }
 
-func new(user *user.Core, auth *auth.Auth) *handlers {
+func new(user *user.Core, auth *auth.Auth, audit *audit.Core) *handlers {
 	return &handlers{
-		user: user,
-		auth: auth,
+		user:  user,
+		auth:  auth,
+		audit: audit,
 	}
 }
 
+// executeUnderTransaction constructs a new handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *handlers) executeUnderTransaction(ctx context.Context) (*handlers, error) {
+	tx, ok := transaction.Get(ctx)
+	if !ok {
+		return h, nil
+	}
+
+	user, err := h.user.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	audit, err := h.audit.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(user, h.auth, audit), nil
+}
+
 // create adds a new user to the system.
 func (h *handlers) create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	var app AppNewUser
 	if err := web.Decode(r, &app); err != nil {
 		return v1.NewTrustedError(err, http.StatusBadRequest)
@@ -48,14 +79,25 @@ func (h *handlers) create(ctx context.Context, w http.ResponseWriter, r *http.Re
 		if errors.Is(err, user.ErrUniqueEmail) {
 			return v1.NewTrustedError(err, http.StatusConflict)
 		}
-		return fmt.Errorf("create: usr[%+v]: %w", usr, err)
+		return fmt.Errorf("create: usr[%+v]: %w", logger.Redact(usr), err)
+	}
+
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionCreated, user.Domain, usr.ID, nil, usr); err != nil {
+		return err
 	}
 
+	etag.Set(w, usr.Version)
+
 	return web.Respond(ctx, w, toAppUser(usr), http.StatusCreated)
 }
 
 // update updates a user in the system.
 func (h *handlers) update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	var app AppUpdateUser
 	if err := web.Decode(r, &app); err != nil {
 		return v1.NewTrustedError(err, http.StatusBadRequest)
@@ -66,24 +108,96 @@ func (h *handlers) update(ctx context.Context, w http.ResponseWriter, r *http.Re
 		return v1.NewTrustedError(err, http.StatusBadRequest)
 	}
 
-	usr := mid.GetUser(ctx)
+	if err := h.authorizeUpdate(ctx, mid.GetClaims(ctx), app); err != nil {
+		return err
+	}
+
+	usr, err := h.queryCurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := etag.Check(r, usr.Version); err != nil {
+		return err
+	}
 
 	updUsr, err := h.user.Update(ctx, usr, uu)
 	if err != nil {
-		return fmt.Errorf("update: userID[%s] uu[%+v]: %w", usr.ID, uu, err)
+		if errors.Is(err, user.ErrVersionConflict) {
+			return v1.NewTrustedError(err, http.StatusPreconditionFailed)
+		}
+		return fmt.Errorf("update: userID[%s] uu[%+v]: %w", usr.ID, logger.Redact(uu), err)
+	}
+
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionUpdated, user.Domain, updUsr.ID, usr, updUsr); err != nil {
+		return err
 	}
 
+	etag.Set(w, updUsr.Version)
+
 	return web.Respond(ctx, w, toAppUser(updUsr), http.StatusOK)
 }
 
+// queryCurrentUser reads the user the request is for again inside the
+// transaction. The cached copy may still carry a version from before a
+// change made by this or another instance, so the If-Match check and the
+// change use the version in the database.
+func (h *handlers) queryCurrentUser(ctx context.Context) (user.User, error) {
+	userID := mid.GetUser(ctx).ID
+
+	usr, err := h.user.QueryByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, user.ErrNotFound) {
+			return user.User{}, v1.NewTrustedError(err, http.StatusNotFound)
+		}
+		return user.User{}, fmt.Errorf("querybyid: userID[%s]: %w", userID, err)
+	}
+
+	return usr, nil
+}
+
+// authorizeUpdate makes sure only an admin changes the region or the roles
+// of a user. The route lets users update their own record, and the region
+// and roles decide which records their lists are scoped to.
+func (h *handlers) authorizeUpdate(ctx context.Context, claims auth.Claims, app AppUpdateUser) error {
+	if app.RegionID == nil && app.Roles == nil {
+		return nil
+	}
+
+	if err := h.auth.Authorize(ctx, claims, uuid.Nil, auth.RuleAdminOnly); err != nil {
+		return v1.NewTrustedError(errors.New("only an admin can change the region or roles of a user"), http.StatusForbidden)
+	}
+
+	return nil
+}
+
 // delete removes a user from the system.
 func (h *handlers) delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-	usr := mid.GetUser(ctx)
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	usr, err := h.queryCurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := etag.Check(r, usr.Version); err != nil {
+		return err
+	}
 
-	if err := h.user.Delete(ctx, mid.GetUser(ctx)); err != nil {
+	if err := h.user.Delete(ctx, usr); err != nil {
+		if errors.Is(err, user.ErrVersionConflict) {
+			return v1.NewTrustedError(err, http.StatusPreconditionFailed)
+		}
 		return fmt.Errorf("delete: userID[%s]: %w", usr.ID, err)
 	}
 
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionDeleted, user.Domain, usr.ID, usr, nil); err != nil {
+		return err
+	}
+
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
 }
 
@@ -98,12 +212,17 @@ func (h *handlers) query(ctx context.Context, w http.ResponseWriter, r *http.Req
 	if err != nil {
 		return err
 	}
+	scopeFilter(ctx, &filter)
 
 	orderBy, err := parseOrder(r)
 	if err != nil {
 		return err
 	}
 
+	if page.Cursor != nil {
+		return h.queryByCursor(ctx, w, filter, orderBy, page)
+	}
+
 	users, err := h.user.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
 	if err != nil {
 		return fmt.Errorf("query: %w", err)
@@ -117,16 +236,46 @@ func (h *handlers) query(ctx context.Context, w http.ResponseWriter, r *http.Req
 	return web.Respond(ctx, w, v1.NewPageDocument(toAppUsers(users), total, page.Number, page.RowsPerPage), http.StatusOK)
 }
 
+// queryByCursor returns a list of users with cursor paging.
+func (h *handlers) queryByCursor(ctx context.Context, w http.ResponseWriter, filter user.QueryFilter, orderBy order.By, pg page.Page) error {
+	if err := pg.Cursor.Check(orderBy.String()); err != nil {
+		return v1.NewTrustedError(err, http.StatusBadRequest)
+	}
+
+	// One more row than the page holds is queried to tell if there are more.
+	users, err := h.user.QueryByCursor(ctx, filter, orderBy, *pg.Cursor, pg.RowsPerPage+1)
+	if err != nil {
+		return fmt.Errorf("querybycursor: %w", err)
+	}
+
+	users, next, prev := page.Window(pg, orderBy.String(), users, func(usr user.User) []string {
+		return user.CursorValues(usr, orderBy)
+	})
+
+	return web.Respond(ctx, w, v1.NewCursorDocument(toAppUsers(users), next, prev, pg.RowsPerPage), http.StatusOK)
+}
+
 // queryByID returns a user by its ID.
 func (h *handlers) queryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-	return web.Respond(ctx, w, toAppUser(mid.GetUser(ctx)), http.StatusOK)
+	usr := mid.GetUser(ctx)
+	etag.Set(w, usr.Version)
+
+	return web.Respond(ctx, w, toAppUser(usr), http.StatusOK)
 }
 
-// token provides an API token for the authenticated user.
+// token provides an API token for the authenticated user. The token is signed
+// with the specified kid or, when none is provided, the active signing key.
 func (h *handlers) token(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	kid := web.Param(r, "kid")
 	if kid == "" {
-		return validate.NewFieldsError("kid", errors.New("missing kid"))
+		activeKID, err := h.auth.ActiveKID()
+		if err != nil {
+			if errors.Is(err, auth.ErrNoActiveKey) {
+				return v1.NewTrustedError(errors.New("no signing key is active, try again later"), http.StatusServiceUnavailable)
+			}
+			return fmt.Errorf("activekid: %w", err)
+		}
+		kid = activeKID
 	}
 
 	email, pass, ok := r.BasicAuth()
@@ -145,25 +294,15 @@ func (h *handlers) token(ctx context.Context, w http.ResponseWriter, r *http.Req
 		case errors.Is(err, user.ErrNotFound):
 			return v1.NewTrustedError(err, http.StatusNotFound)
 		case errors.Is(err, user.ErrAuthenticationFailure):
-			return auth.NewAuthError(err.Error())
+			return v1.NewTrustedError(err, http.StatusUnauthorized)
 		default:
 			return fmt.Errorf("authenticate: %w", err)
 		}
 	}
 
-	claims := auth.Claims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			Subject:   usr.ID.String(),
-			Issuer:    "service project",
-			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		},
-		Roles: usr.Roles,
-	}
-
-	token, err := h.auth.GenerateToken(kid, claims)
+	token, err := h.auth.IssueToken(kid, usr)
 	if err != nil {
-		return fmt.Errorf("generatetoken: %w", err)
+		return fmt.Errorf("issuetoken: %w", err)
 	}
 
 	return web.Respond(ctx, w, toToken(token), http.StatusOK)
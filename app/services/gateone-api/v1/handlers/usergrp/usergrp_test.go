@@ -0,0 +1,66 @@
+package usergrp
+
+import (
+	"context"
+	"errors"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+func Test_AuthorizeUpdate(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	a, err := auth.New(auth.Config{Log: log, Issuer: "service project"})
+	if err != nil {
+		t.Fatalf("Should be able to create an authenticator: %s", err)
+	}
+
+	h := new(nil, a, nil)
+
+	regionID := uuid.NewString()
+	name := "Name"
+
+	tests := []struct {
+		name    string
+		roles   []user.Role
+		app     AppUpdateUser
+		allowed bool
+	}{
+		{"supervisor-region", []user.Role{user.RoleSupervisor}, AppUpdateUser{RegionID: &regionID}, false},
+		{"supervisor-roles", []user.Role{user.RoleSupervisor}, AppUpdateUser{Roles: []string{"ADMIN"}}, false},
+		{"user-region", []user.Role{user.RoleUser}, AppUpdateUser{RegionID: &regionID}, false},
+		{"supervisor-name", []user.Role{user.RoleSupervisor}, AppUpdateUser{Name: &name}, true},
+		{"admin-region", []user.Role{user.RoleAdmin}, AppUpdateUser{RegionID: &regionID, Roles: []string{"USER"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: uuid.NewString()},
+				Roles:            tt.roles,
+			}
+
+			err := h.authorizeUpdate(context.Background(), claims, tt.app)
+
+			if tt.allowed {
+				if err != nil {
+					t.Errorf("Should be allowed to update: %s", err)
+				}
+				return
+			}
+
+			var trsErr *v1.TrustedError
+			if !errors.As(err, &trsErr) || trsErr.Status != http.StatusForbidden {
+				t.Errorf("Should be forbidden to update: got %v", err)
+			}
+		})
+	}
+}
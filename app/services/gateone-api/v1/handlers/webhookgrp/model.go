@@ -0,0 +1,139 @@
+package webhookgrp
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/webhook"
+	"github.com/fadhilijuma/gateone-service/foundation/validate"
+	"time"
+)
+
+// AppSubscription represents information about an individual webhook
+// subscription. The secret is never returned.
+type AppSubscription struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	EventTypes  []string `json:"eventTypes"`
+	Enabled     bool     `json:"enabled"`
+	Failures    int      `json:"failures"`
+	DateCreated string   `json:"dateCreated"`
+	DateUpdated string   `json:"dateUpdated"`
+	Version     int      `json:"version"`
+}
+
+func toAppSubscription(sub webhook.Subscription) AppSubscription {
+	return AppSubscription{
+		ID:          sub.ID.String(),
+		URL:         sub.URL,
+		EventTypes:  sub.EventTypes,
+		Enabled:     sub.Enabled,
+		Failures:    sub.Failures,
+		DateCreated: sub.DateCreated.Format(time.RFC3339),
+		DateUpdated: sub.DateUpdated.Format(time.RFC3339),
+		Version:     sub.Version,
+	}
+}
+
+func toAppSubscriptions(subs []webhook.Subscription) []AppSubscription {
+	items := make([]AppSubscription, len(subs))
+	for i, sub := range subs {
+		items[i] = toAppSubscription(sub)
+	}
+
+	return items
+}
+
+// AppNewSubscription defines the data needed to add a new webhook
+// subscription. Event types are a domain and action, such as
+// patient.created.
+type AppNewSubscription struct {
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"eventTypes" validate:"required,min=1"`
+	Secret     string   `json:"secret" validate:"required,min=16" log:"redact"`
+}
+
+func toCoreNewSubscription(app AppNewSubscription) webhook.NewSubscription {
+	return webhook.NewSubscription{
+		URL:        app.URL,
+		EventTypes: app.EventTypes,
+		Secret:     app.Secret,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewSubscription) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AppUpdateSubscription defines the data needed to update a webhook
+// subscription. Enabling a disabled subscription clears its failures.
+type AppUpdateSubscription struct {
+	URL        *string  `json:"url" validate:"omitempty,url"`
+	EventTypes []string `json:"eventTypes" validate:"omitempty,min=1"`
+	Secret     *string  `json:"secret" validate:"omitempty,min=16" log:"redact"`
+	Enabled    *bool    `json:"enabled"`
+}
+
+func toCoreUpdateSubscription(app AppUpdateSubscription) webhook.UpdateSubscription {
+	return webhook.UpdateSubscription{
+		URL:        app.URL,
+		EventTypes: app.EventTypes,
+		Secret:     app.Secret,
+		Enabled:    app.Enabled,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdateSubscription) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AppDelivery represents an attempted delivery of an event to a webhook
+// subscription.
+type AppDelivery struct {
+	ID            string `json:"id"`
+	EventID       string `json:"eventID"`
+	EventType     string `json:"eventType"`
+	Status        string `json:"status"`
+	Attempts      int    `json:"attempts"`
+	StatusCode    int    `json:"statusCode,omitzero"`
+	LastError     string `json:"lastError,omitzero"`
+	NextAttemptAt string `json:"nextAttemptAt,omitzero"`
+	DateCreated   string `json:"dateCreated"`
+	DateUpdated   string `json:"dateUpdated"`
+}
+
+func toAppDelivery(dl webhook.Delivery) AppDelivery {
+	app := AppDelivery{
+		ID:          dl.ID.String(),
+		EventID:     dl.EventID.String(),
+		EventType:   dl.EventType,
+		Status:      dl.Status,
+		Attempts:    dl.Attempts,
+		StatusCode:  dl.StatusCode,
+		LastError:   dl.LastError,
+		DateCreated: dl.DateCreated.Format(time.RFC3339),
+		DateUpdated: dl.DateUpdated.Format(time.RFC3339),
+	}
+
+	if dl.Status == webhook.StatusPending {
+		app.NextAttemptAt = dl.NextAttemptAt.Format(time.RFC3339)
+	}
+
+	return app
+}
+
+func toAppDeliveries(dls []webhook.Delivery) []AppDelivery {
+	items := make([]AppDelivery, len(dls))
+	for i, dl := range dls {
+		items[i] = toAppDelivery(dl)
+	}
+
+	return items
+}
@@ -0,0 +1,82 @@
+package webhookgrp
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit/stores/auditdb"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/webhook"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/webhook/stores/webhookdb"
+	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/ratelimit"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log            *logger.Logger
+	Auth           *auth.Auth
+	DB             *sqlx.DB
+	RateLimiter    ratelimit.Storer
+	RateLimit      ratelimit.Limit
+	RequireIfMatch bool
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	// The core is constructed without a delegate since it only manages the
+	// subscriptions, the deliveries are queued by the core main constructs.
+	whCore := webhook.NewCore(cfg.Log, nil, webhookdb.NewStore(cfg.Log, cfg.DB))
+	audCore := audit.NewCore(cfg.Log, auditdb.NewStore(cfg.Log, cfg.DB))
+
+	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.Log, cfg.RateLimiter, "webhooks", cfg.RateLimit)
+	tran := mid.ExecuteInTransaction(cfg.Log, sqldb.NewBeginner(cfg.DB))
+	ifMatch := mid.RequireIfMatch(cfg.RequireIfMatch)
+	ruleAdmin := mid.Authorize(cfg.Auth, auth.RuleAdminOnly)
+
+	hdl := new(whCore, audCore)
+	app.Handle(http.MethodGet, version, "/webhooks", hdl.query, authen, limit, ruleAdmin).Describe(web.RouteDoc{
+		Summary:  "List webhook subscriptions",
+		Response: v1.PageDocument[AppSubscription]{},
+		Security: web.SecurityBearer,
+		Query:    page.Params(false),
+	})
+	app.Handle(http.MethodGet, version, "/webhooks/{webhook_id}", hdl.queryByID, authen, limit, ruleAdmin).Describe(web.RouteDoc{
+		Summary:  "Get a webhook subscription",
+		Response: AppSubscription{},
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodGet, version, "/webhooks/{webhook_id}/deliveries", hdl.queryDeliveries, authen, limit, ruleAdmin).Describe(web.RouteDoc{
+		Summary:  "List the deliveries to a webhook subscription",
+		Response: v1.PageDocument[AppDelivery]{},
+		Security: web.SecurityBearer,
+		Query:    page.Params(false),
+	})
+	app.Handle(http.MethodPost, version, "/webhooks", hdl.create, authen, limit, ruleAdmin, tran).Describe(web.RouteDoc{
+		Summary:  "Create a webhook subscription",
+		Request:  AppNewSubscription{},
+		Response: AppSubscription{},
+		Status:   http.StatusCreated,
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodPut, version, "/webhooks/{webhook_id}", hdl.update, authen, limit, ruleAdmin, ifMatch, tran).Describe(web.RouteDoc{
+		Summary:  "Update a webhook subscription",
+		Request:  AppUpdateSubscription{},
+		Response: AppSubscription{},
+		Security: web.SecurityBearer,
+	})
+	app.Handle(http.MethodDelete, version, "/webhooks/{webhook_id}", hdl.delete, authen, limit, ruleAdmin, ifMatch, tran).Describe(web.RouteDoc{
+		Summary:  "Delete a webhook subscription",
+		Status:   http.StatusNoContent,
+		Security: web.SecurityBearer,
+	})
+}
@@ -0,0 +1,245 @@
+// Package webhookgrp maintains the group of handlers for managing the
+// webhook subscriptions and reading their delivery logs.
+package webhookgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/webhook"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/etag"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
+	"github.com/fadhilijuma/gateone-service/foundation/validate"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type handlers struct {
+	webhook *webhook.Core
+	audit   *audit.Core
+}
+
+func new(webhook *webhook.Core, audit *audit.Core) *handlers {
+	return &handlers{
+		webhook: webhook,
+		audit:   audit,
+	}
+}
+
+// executeUnderTransaction constructs a new handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *handlers) executeUnderTransaction(ctx context.Context) (*handlers, error) {
+	tx, ok := transaction.Get(ctx)
+	if !ok {
+		return h, nil
+	}
+
+	webhook, err := h.webhook.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	audit, err := h.audit.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(webhook, audit), nil
+}
+
+// create adds a new webhook subscription to the system.
+func (h *handlers) create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	var app AppNewSubscription
+	if err := web.Decode(r, &app); err != nil {
+		return v1.NewTrustedError(err, http.StatusBadRequest)
+	}
+
+	sub, err := h.webhook.Create(ctx, toCoreNewSubscription(app))
+	if err != nil {
+		switch {
+		case errors.Is(err, webhook.ErrInvalidURL):
+			return validate.NewFieldsError("url", err)
+		case errors.Is(err, webhook.ErrUnknownEventType):
+			return validate.NewFieldsError("eventTypes", err)
+		}
+		return fmt.Errorf("create: url[%s]: %w", app.URL, err)
+	}
+
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionCreated, webhook.Domain, sub.ID, nil, sub); err != nil {
+		return err
+	}
+
+	etag.Set(w, sub.Version)
+
+	return web.Respond(ctx, w, toAppSubscription(sub), http.StatusCreated)
+}
+
+// update updates a webhook subscription in the system.
+func (h *handlers) update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	var app AppUpdateSubscription
+	if err := web.Decode(r, &app); err != nil {
+		return v1.NewTrustedError(err, http.StatusBadRequest)
+	}
+
+	sub, err := h.querySubscription(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := etag.Check(r, sub.Version); err != nil {
+		return err
+	}
+
+	upSub, err := h.webhook.Update(ctx, sub, toCoreUpdateSubscription(app))
+	if err != nil {
+		switch {
+		case errors.Is(err, webhook.ErrVersionConflict):
+			return v1.NewTrustedError(err, http.StatusPreconditionFailed)
+		case errors.Is(err, webhook.ErrInvalidURL):
+			return validate.NewFieldsError("url", err)
+		case errors.Is(err, webhook.ErrUnknownEventType):
+			return validate.NewFieldsError("eventTypes", err)
+		}
+		return fmt.Errorf("update: subscriptionID[%s]: %w", sub.ID, err)
+	}
+
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionUpdated, webhook.Domain, upSub.ID, sub, upSub); err != nil {
+		return err
+	}
+
+	etag.Set(w, upSub.Version)
+
+	return web.Respond(ctx, w, toAppSubscription(upSub), http.StatusOK)
+}
+
+// delete removes a webhook subscription and its delivery log from the
+// system.
+func (h *handlers) delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	sub, err := h.querySubscription(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := etag.Check(r, sub.Version); err != nil {
+		return err
+	}
+
+	if err := h.webhook.Delete(ctx, sub); err != nil {
+		if errors.Is(err, webhook.ErrVersionConflict) {
+			return v1.NewTrustedError(err, http.StatusPreconditionFailed)
+		}
+		return fmt.Errorf("delete: subscriptionID[%s]: %w", sub.ID, err)
+	}
+
+	if err := mid.AuditChange(ctx, r, h.audit, audit.ActionDeleted, webhook.Domain, sub.ID, sub, nil); err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// query returns a list of webhook subscriptions with paging.
+func (h *handlers) query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	pg, err := page.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	if pg.Cursor != nil {
+		return validate.NewFieldsError("cursor", errors.New("not supported"))
+	}
+
+	subs, err := h.webhook.Query(ctx, pg.Number, pg.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.webhook.Count(ctx)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, v1.NewPageDocument(toAppSubscriptions(subs), total, pg.Number, pg.RowsPerPage), http.StatusOK)
+}
+
+// queryByID returns a webhook subscription by its ID.
+func (h *handlers) queryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	sub, err := h.querySubscription(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	etag.Set(w, sub.Version)
+
+	return web.Respond(ctx, w, toAppSubscription(sub), http.StatusOK)
+}
+
+// queryDeliveries returns the delivery log of a webhook subscription with
+// paging, the most recent deliveries first.
+func (h *handlers) queryDeliveries(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	pg, err := page.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	if pg.Cursor != nil {
+		return validate.NewFieldsError("cursor", errors.New("not supported"))
+	}
+
+	sub, err := h.querySubscription(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	dls, err := h.webhook.QueryDeliveries(ctx, sub.ID, pg.Number, pg.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("querydeliveries: %w", err)
+	}
+
+	total, err := h.webhook.CountDeliveries(ctx, sub.ID)
+	if err != nil {
+		return fmt.Errorf("countdeliveries: %w", err)
+	}
+
+	return web.Respond(ctx, w, v1.NewPageDocument(toAppDeliveries(dls), total, pg.Number, pg.RowsPerPage), http.StatusOK)
+}
+
+// querySubscription returns the subscription named by the path of the
+// request.
+func (h *handlers) querySubscription(ctx context.Context, r *http.Request) (webhook.Subscription, error) {
+	subID, err := uuid.Parse(web.Param(r, "webhook_id"))
+	if err != nil {
+		return webhook.Subscription{}, validate.NewFieldsError("webhook_id", err)
+	}
+
+	sub, err := h.webhook.QueryByID(ctx, subID)
+	if err != nil {
+		if errors.Is(err, webhook.ErrNotFound) {
+			return webhook.Subscription{}, v1.NewTrustedError(err, http.StatusNotFound)
+		}
+		return webhook.Subscription{}, fmt.Errorf("querybyid: subscriptionID[%s]: %w", subID, err)
+	}
+
+	return sub, nil
+}
@@ -0,0 +1,30 @@
+package wellknowngrp
+
+import "sort"
+
+// Discovery represents the OpenID style discovery document.
+type Discovery struct {
+	Issuer            string   `json:"issuer"`
+	JWKSURI           string   `json:"jwks_uri"`
+	TokenEndpoint     string   `json:"token_endpoint"`
+	SubjectTypes      []string `json:"subject_types_supported"`
+	SigningAlgorithms []string `json:"id_token_signing_alg_values_supported"`
+	Claims            []string `json:"claims_supported"`
+}
+
+func toDiscovery(baseURL string, issuer string, algs map[string]struct{}) Discovery {
+	signingAlgs := make([]string, 0, len(algs))
+	for alg := range algs {
+		signingAlgs = append(signingAlgs, alg)
+	}
+	sort.Strings(signingAlgs)
+
+	return Discovery{
+		Issuer:            issuer,
+		JWKSURI:           baseURL + "/.well-known/jwks.json",
+		TokenEndpoint:     baseURL + "/v1/users/token",
+		SubjectTypes:      []string{"public"},
+		SigningAlgorithms: signingAlgs,
+		Claims:            []string{"sub", "iss", "aud", "exp", "nbf", "iat", "jti", "roles"},
+	}
+}
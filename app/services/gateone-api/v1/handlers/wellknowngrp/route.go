@@ -0,0 +1,32 @@
+package wellknowngrp
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/foundation/jwks"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+	"strings"
+)
+
+// Config contains all the mandatory systems required by handlers. PublicURL
+// is the external base URL of the service, such as https://api.example.com,
+// which the discovery document advertises.
+type Config struct {
+	Log       *logger.Logger
+	Auth      *auth.Auth
+	PublicURL string
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	hdl := new(cfg.Auth, strings.TrimSuffix(cfg.PublicURL, "/"))
+	app.Handle(http.MethodGet, "", "/.well-known/jwks.json", hdl.jwks).Describe(web.RouteDoc{
+		Summary:  "Get the token signing keys",
+		Response: jwks.Set{},
+	})
+	app.Handle(http.MethodGet, "", "/.well-known/openid-configuration", hdl.discovery).Describe(web.RouteDoc{
+		Summary:  "Get the discovery document",
+		Response: Discovery{},
+	})
+}
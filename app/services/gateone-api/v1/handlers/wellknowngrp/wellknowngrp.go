@@ -0,0 +1,75 @@
+// Package wellknowngrp maintains the group of handlers for the well known
+// documents other services use to verify our tokens.
+package wellknowngrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+)
+
+type handlers struct {
+	auth      *auth.Auth
+	publicURL string
+}
+
+func new(auth *auth.Auth, publicURL string) *handlers {
+	return &handlers{
+		auth:      auth,
+		publicURL: publicURL,
+	}
+}
+
+// jwks returns the public keys used to sign tokens as a JSON Web Key Set.
+func (h *handlers) jwks(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	set, err := h.auth.PublicKeySet()
+	if err != nil {
+		return fmt.Errorf("publickeyset: %w", err)
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	return web.Respond(ctx, w, set, http.StatusOK)
+}
+
+// discovery returns the OpenID style discovery document describing where
+// tokens are issued and where the keys to verify them can be found.
+func (h *handlers) discovery(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	set, err := h.auth.PublicKeySet()
+	if err != nil {
+		return fmt.Errorf("publickeyset: %w", err)
+	}
+
+	algs := make(map[string]struct{})
+	for _, key := range set.Keys {
+		algs[key.Algorithm] = struct{}{}
+	}
+
+	if len(algs) == 0 {
+		return v1.NewTrustedError(errors.New("no signing keys available"), http.StatusServiceUnavailable)
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	return web.Respond(ctx, w, toDiscovery(h.baseURL(r), h.auth.Issuer(), algs), http.StatusOK)
+}
+
+// baseURL returns the external URL of the service. The configured public
+// URL is used when set. The forwarded headers are never read since the
+// client controls them and the response is cached by shared caches.
+func (h *handlers) baseURL(r *http.Request) string {
+	if h.publicURL != "" {
+		return h.publicURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,140 @@
+// This program performs administrative tasks for the gateone service.
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/ardanlabs/conf/v3"
+	"github.com/fadhilijuma/gateone-service/foundation/keystore"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/google/uuid"
+)
+
+var build = "develop"
+
+type config struct {
+	conf.Version
+	Args conf.Args
+	Keys struct {
+		Folder    string        `conf:"default:configs/keys/"`
+		Algorithm string        `conf:"default:RS256,help:RS256, ES256 or EdDSA"`
+		Bits      int           `conf:"default:2048,help:size of RS256 keys"`
+		NotBefore time.Duration `conf:"default:0s,help:delay before the new key signs tokens"`
+	}
+}
+
+func main() {
+	if err := run(); err != nil {
+		if !errors.Is(err, conf.ErrHelpWanted) {
+			fmt.Println("msg", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	cfg := config{
+		Version: conf.Version{
+			Build: build,
+			Desc:  "Gateone Admin",
+		},
+	}
+
+	const prefix = "GATEONE"
+	help, err := conf.Parse(prefix, &cfg)
+	if err != nil {
+		if errors.Is(err, conf.ErrHelpWanted) {
+			fmt.Println(help)
+			return err
+		}
+		return fmt.Errorf("parsing config: %w", err)
+	}
+
+	switch cfg.Args.Num(0) {
+	case "genkey":
+		return genKey(cfg.Keys.Folder, cfg.Keys.Algorithm, cfg.Keys.Bits, cfg.Keys.NotBefore)
+
+	default:
+		fmt.Println("genkey: generate a new private/public key pair in the keys folder")
+		fmt.Println("provide a command to get more help.")
+		return errors.New("command not provided")
+	}
+}
+
+// genKey creates an x509 private key for signing auth tokens with the
+// specified algorithm and writes it into the keys folder under a new kid. A
+// metadata file is written next to it so the key only starts signing tokens
+// once the delay has passed. This gives every instance of the service time
+// to load the key and publish it before it is used.
+func genKey(folder string, algorithm string, bits int, delay time.Duration) error {
+	var privateKey crypto.Signer
+	var err error
+
+	switch algorithm {
+	case "RS256":
+		privateKey, err = rsa.GenerateKey(rand.Reader, bits)
+	case "ES256":
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "EdDSA":
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+
+	if err != nil {
+		return fmt.Errorf("generating key: %w", err)
+	}
+
+	privateBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return fmt.Errorf("marshaling private key: %w", err)
+	}
+
+	kid := uuid.NewString()
+
+	privateBlock := pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: privateBytes,
+	}
+
+	meta := keystore.Metadata{
+		NotBefore: time.Now().UTC().Add(delay).Truncate(time.Second),
+	}
+
+	metaData, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("encoding metadata: %w", err)
+	}
+
+	// Write the metadata first so the service never loads the key without
+	// its not before time and starts signing with it early.
+	metaFile := filepath.Join(folder, kid+".json")
+	if err := os.WriteFile(metaFile, metaData, 0600); err != nil {
+		return fmt.Errorf("writing metadata file: %w", err)
+	}
+
+	privateFile := filepath.Join(folder, kid+".pem")
+	if err := os.WriteFile(privateFile, pem.EncodeToMemory(&privateBlock), 0600); err != nil {
+		return fmt.Errorf("writing private file: %w", err)
+	}
+
+	fmt.Println("kid:        ", kid)
+	fmt.Println("algorithm:  ", algorithm)
+	fmt.Println("private key:", privateFile)
+	fmt.Println("metadata:   ", metaFile)
+	fmt.Println("not before: ", meta.NotBefore.Format(time.RFC3339))
+
+	return nil
+}
@@ -0,0 +1,298 @@
+// Package audit provides the log of every change made to the data of the
+// other core packages. Entries are only ever appended and are chained by
+// their hashes, so an entry that was changed or removed is detected when the
+// chain is verified.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound = errors.New("audit entry not found")
+)
+
+// Set of actions that are recorded.
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
+// Storer interface declares the behavior this package needs to persists and
+// retrieve data.
+type Storer interface {
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+	QueryHead(ctx context.Context) (Entry, error)
+	Create(ctx context.Context, e Entry) error
+	Query(ctx context.Context, filter QueryFilter, pageNumber int, rowsPerPage int) ([]Entry, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryByID(ctx context.Context, entryID uuid.UUID) (Entry, error)
+	QueryBySeq(ctx context.Context, fromSeq int64, rows int) ([]Entry, error)
+}
+
+// Core manages the set of APIs for audit access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+}
+
+// NewCore constructs an audit core API for use.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	storer, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	core := Core{
+		log:    c.log,
+		storer: storer,
+	}
+
+	return &core, nil
+}
+
+// Create appends an entry for the change to the log. It must be called in
+// the transaction making the change, so the change is only committed with
+// its entry. The head of the chain stays locked until the transaction ends.
+func (c *Core) Create(ctx context.Context, ne NewEntry) (Entry, error) {
+	changes, err := Diff(ne.Before, ne.After)
+	if err != nil {
+		return Entry{}, fmt.Errorf("diff: %w", err)
+	}
+
+	head, err := c.storer.QueryHead(ctx)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return Entry{}, fmt.Errorf("queryhead: %w", err)
+	}
+
+	e := Entry{
+		ID:          uuid.New(),
+		Seq:         head.Seq + 1,
+		Actor:       ne.Actor,
+		Action:      ne.Action,
+		EntityType:  ne.EntityType,
+		EntityID:    ne.EntityID,
+		Changes:     changes,
+		DateCreated: time.Now().Truncate(time.Microsecond),
+		PrevHash:    head.Hash,
+	}
+
+	if e.Hash, err = Hash(e); err != nil {
+		return Entry{}, fmt.Errorf("hash: %w", err)
+	}
+
+	if err := c.storer.Create(ctx, e); err != nil {
+		return Entry{}, fmt.Errorf("create: %w", err)
+	}
+
+	return e, nil
+}
+
+// Query retrieves a list of entries, the most recent first.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, pageNumber int, rowsPerPage int) ([]Entry, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	entries, err := c.storer.Query(ctx, filter, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Count returns the total number of entries.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	if err := filter.Validate(); err != nil {
+		return 0, err
+	}
+
+	return c.storer.Count(ctx, filter)
+}
+
+// QueryByID finds the entry by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, entryID uuid.UUID) (Entry, error) {
+	e, err := c.storer.QueryByID(ctx, entryID)
+	if err != nil {
+		return Entry{}, fmt.Errorf("query: entryID[%s]: %w", entryID, err)
+	}
+
+	return e, nil
+}
+
+// Verify walks the chain from the first entry and checks every entry
+// follows the one before it and still has the hash it was recorded with.
+func (c *Core) Verify(ctx context.Context) (Verification, error) {
+	const rows = 1000
+
+	var v Verification
+	var prev Entry
+
+	for {
+		entries, err := c.storer.QueryBySeq(ctx, prev.Seq+1, rows)
+		if err != nil {
+			return Verification{}, fmt.Errorf("querybyseq: %w", err)
+		}
+
+		for _, e := range entries {
+			hash, err := Hash(e)
+			if err != nil {
+				return Verification{}, fmt.Errorf("hash: seq[%d]: %w", e.Seq, err)
+			}
+
+			switch {
+			case e.Seq != prev.Seq+1:
+				v.BrokenSeq, v.Reason = prev.Seq+1, "entry is missing"
+			case e.PrevHash != prev.Hash:
+				v.BrokenSeq, v.Reason = e.Seq, "previous hash doesn't match"
+			case e.Hash != hash:
+				v.BrokenSeq, v.Reason = e.Seq, "hash doesn't match"
+			}
+
+			if v.Reason != "" {
+				return v, nil
+			}
+
+			v.Entries++
+			prev = e
+		}
+
+		if len(entries) < rows {
+			break
+		}
+	}
+
+	v.Valid = true
+
+	return v, nil
+}
+
+// =============================================================================
+
+// Hash returns the hash of the entry, which covers every field of the entry
+// and the hash of the entry before it.
+func Hash(e Entry) (string, error) {
+	changes, err := MarshalChanges(e.Changes)
+	if err != nil {
+		return "", err
+	}
+
+	fields := []string{
+		strconv.FormatInt(e.Seq, 10),
+		e.ID.String(),
+		e.Actor.UserID.String(),
+		e.Actor.TraceID,
+		e.Actor.ClientIP,
+		e.Action,
+		e.EntityType,
+		e.EntityID.String(),
+		string(changes),
+		e.DateCreated.UTC().Format(time.RFC3339Nano),
+		e.PrevHash,
+	}
+
+	// Encoding the fields as a JSON array keeps their boundaries unambiguous.
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// MarshalChanges returns the changes encoded as JSON, the form they are
+// stored and hashed in.
+func MarshalChanges(changes []Change) ([]byte, error) {
+	if changes == nil {
+		changes = []Change{}
+	}
+
+	return json.Marshal(changes, json.Deterministic(true))
+}
+
+// Diff returns the fields whose JSON encoded values differ between before
+// and after, ordered by field name. Before and after must encode as JSON
+// objects, a nil value has no fields.
+func Diff(before any, after any) ([]Change, error) {
+	bFields, err := fields(before)
+	if err != nil {
+		return nil, fmt.Errorf("before: %w", err)
+	}
+
+	aFields, err := fields(after)
+	if err != nil {
+		return nil, fmt.Errorf("after: %w", err)
+	}
+
+	names := make([]string, 0, len(bFields)+len(aFields))
+	for name := range bFields {
+		names = append(names, name)
+	}
+	for name := range aFields {
+		if _, exists := bFields[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []Change
+	for _, name := range names {
+		if bytes.Equal(bFields[name], aFields[name]) {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Field:  name,
+			Before: bFields[name],
+			After:  aFields[name],
+		})
+	}
+
+	return changes, nil
+}
+
+func fields(v any) (map[string]jsontext.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v, json.Deterministic(true))
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]jsontext.Value
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("expected a value encoded as an object: %w", err)
+	}
+
+	return m, nil
+}
@@ -0,0 +1,148 @@
+package audit_test
+
+import (
+	"context"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps the entries in memory ordered by their sequence.
+type memoryStore struct {
+	entries []audit.Entry
+}
+
+func (ms *memoryStore) ExecuteUnderTransaction(tx transaction.Transaction) (audit.Storer, error) {
+	return ms, nil
+}
+
+func (ms *memoryStore) QueryHead(ctx context.Context) (audit.Entry, error) {
+	if len(ms.entries) == 0 {
+		return audit.Entry{}, audit.ErrNotFound
+	}
+
+	return ms.entries[len(ms.entries)-1], nil
+}
+
+func (ms *memoryStore) Create(ctx context.Context, e audit.Entry) error {
+	ms.entries = append(ms.entries, e)
+	return nil
+}
+
+func (ms *memoryStore) Query(ctx context.Context, filter audit.QueryFilter, pageNumber int, rowsPerPage int) ([]audit.Entry, error) {
+	return ms.entries, nil
+}
+
+func (ms *memoryStore) Count(ctx context.Context, filter audit.QueryFilter) (int, error) {
+	return len(ms.entries), nil
+}
+
+func (ms *memoryStore) QueryByID(ctx context.Context, entryID uuid.UUID) (audit.Entry, error) {
+	for _, e := range ms.entries {
+		if e.ID == entryID {
+			return e, nil
+		}
+	}
+
+	return audit.Entry{}, audit.ErrNotFound
+}
+
+func (ms *memoryStore) QueryBySeq(ctx context.Context, fromSeq int64, rows int) ([]audit.Entry, error) {
+	i := sort.Search(len(ms.entries), func(i int) bool { return ms.entries[i].Seq >= fromSeq })
+
+	entries := ms.entries[i:]
+	if len(entries) > rows {
+		entries = entries[:rows]
+	}
+
+	return entries, nil
+}
+
+// =============================================================================
+
+type record struct {
+	ID      uuid.UUID
+	Name    string
+	Healed  bool
+	Version int
+}
+
+func Test_Chain(t *testing.T) {
+	ctx := context.Background()
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	store := &memoryStore{}
+	core := audit.NewCore(log, store)
+
+	actor := audit.Actor{
+		UserID:   uuid.New(),
+		TraceID:  uuid.NewString(),
+		ClientIP: "10.0.0.1",
+	}
+
+	rec := record{ID: uuid.New(), Name: "Patient", Version: 1}
+	upd := rec
+	upd.Healed = true
+	upd.Version++
+
+	entries := []audit.NewEntry{
+		{Actor: actor, Action: audit.ActionCreated, EntityType: "patient", EntityID: rec.ID, After: rec},
+		{Actor: actor, Action: audit.ActionUpdated, EntityType: "patient", EntityID: rec.ID, Before: rec, After: upd},
+		{Actor: actor, Action: audit.ActionDeleted, EntityType: "patient", EntityID: rec.ID, Before: upd},
+	}
+
+	for _, ne := range entries {
+		if _, err := core.Create(ctx, ne); err != nil {
+			t.Fatalf("Should be able to create an entry: %s", err)
+		}
+	}
+
+	if store.entries[0].PrevHash != "" || store.entries[1].PrevHash != store.entries[0].Hash {
+		t.Fatalf("Should chain every entry to the one before it")
+	}
+
+	changes := store.entries[1].Changes
+	if len(changes) != 2 || changes[0].Field != "Healed" || changes[1].Field != "Version" {
+		t.Fatalf("Should only record the changed fields: got %v", changes)
+	}
+	if string(changes[0].Before) != "false" || string(changes[0].After) != "true" {
+		t.Errorf("Should record the values before and after: got %s %s", changes[0].Before, changes[0].After)
+	}
+
+	v, err := core.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Should be able to verify the chain: %s", err)
+	}
+	if !v.Valid || v.Entries != 3 {
+		t.Fatalf("Should verify an untouched chain: got %+v", v)
+	}
+
+	// Changing what an entry recorded breaks its hash.
+	tampered := append([]audit.Entry(nil), store.entries...)
+	tampered[1].Changes = tampered[1].Changes[:1]
+	store.entries = tampered
+
+	v, err = core.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Should be able to verify the chain: %s", err)
+	}
+	if v.Valid || v.BrokenSeq != 2 {
+		t.Errorf("Should detect the changed entry: got %+v", v)
+	}
+
+	// Removing an entry breaks the sequence.
+	store.entries = []audit.Entry{tampered[0], tampered[2]}
+
+	v, err = core.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Should be able to verify the chain: %s", err)
+	}
+	if v.Valid || v.BrokenSeq != 2 {
+		t.Errorf("Should detect the removed entry: got %+v", v)
+	}
+}
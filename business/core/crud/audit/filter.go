@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/foundation/validate"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+// We are using pointer semantics because the With API mutates the value.
+type QueryFilter struct {
+	ActorID          *uuid.UUID
+	Action           *string
+	EntityType       *string
+	EntityID         *uuid.UUID
+	StartCreatedDate *time.Time
+	EndCreatedDate   *time.Time
+}
+
+// Validate can perform a check of the data against the validate tags.
+func (qf *QueryFilter) Validate() error {
+	if err := validate.Check(qf); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+// WithActorID sets the ActorID field of the QueryFilter value.
+func (qf *QueryFilter) WithActorID(actorID uuid.UUID) {
+	qf.ActorID = &actorID
+}
+
+// WithAction sets the Action field of the QueryFilter value.
+func (qf *QueryFilter) WithAction(action string) {
+	qf.Action = &action
+}
+
+// WithEntityType sets the EntityType field of the QueryFilter value.
+func (qf *QueryFilter) WithEntityType(entityType string) {
+	qf.EntityType = &entityType
+}
+
+// WithEntityID sets the EntityID field of the QueryFilter value.
+func (qf *QueryFilter) WithEntityID(entityID uuid.UUID) {
+	qf.EntityID = &entityID
+}
+
+// WithStartDateCreated sets the StartCreatedDate field of the QueryFilter value.
+func (qf *QueryFilter) WithStartDateCreated(startDate time.Time) {
+	d := startDate.UTC()
+	qf.StartCreatedDate = &d
+}
+
+// WithEndCreatedDate sets the EndCreatedDate field of the QueryFilter value.
+func (qf *QueryFilter) WithEndCreatedDate(endDate time.Time) {
+	d := endDate.UTC()
+	qf.EndCreatedDate = &d
+}
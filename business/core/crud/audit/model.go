@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/google/uuid"
+)
+
+// Actor represents who made a change and from where.
+type Actor struct {
+	UserID   uuid.UUID
+	TraceID  string
+	ClientIP string
+}
+
+// Change represents the JSON encoded value of a field before and after a
+// change. A field that didn't exist before or after is null there.
+type Change struct {
+	Field  string
+	Before jsontext.Value
+	After  jsontext.Value
+}
+
+// Entry represents a change recorded in the audit log. Entries are chained
+// in Seq order, every entry holding the hash of the one before it.
+type Entry struct {
+	ID          uuid.UUID
+	Seq         int64
+	Actor       Actor
+	Action      string
+	EntityType  string
+	EntityID    uuid.UUID
+	Changes     []Change
+	DateCreated time.Time
+	PrevHash    string
+	Hash        string
+}
+
+// NewEntry contains information needed to record a change. Before is nil
+// for a created entity and After is nil for a deleted one.
+type NewEntry struct {
+	Actor      Actor
+	Action     string
+	EntityType string
+	EntityID   uuid.UUID
+	Before     any
+	After      any
+}
+
+// Verification represents the outcome of checking the chain of entries.
+// When the chain is broken, BrokenSeq is the first entry that doesn't
+// match and Reason describes why.
+type Verification struct {
+	Entries   int
+	Valid     bool
+	BrokenSeq int64
+	Reason    string
+}
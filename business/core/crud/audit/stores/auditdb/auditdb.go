@@ -0,0 +1,211 @@
+// Package auditdb contains audit log related CRUD functionality.
+package auditdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
+	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for audit log database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (audit.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// QueryHead locks the head of the chain against other appends until the
+// transaction ends and returns the last entry of the chain. The lock is the
+// single row of audit_head, so two transactions can't chain an entry to the
+// same head while the audit log itself stays open to readers and the other
+// writes of the transaction.
+func (s *Store) QueryHead(ctx context.Context) (audit.Entry, error) {
+	const lock = `
+	SELECT
+		id
+	FROM
+		audit_head
+	FOR UPDATE`
+
+	if err := sqldb.ExecContext(ctx, s.log, s.db, lock); err != nil {
+		return audit.Entry{}, fmt.Errorf("lock: %w", err)
+	}
+
+	const q = `
+	SELECT
+		seq, entry_id, actor_id, trace_id, client_ip, action, entity_type, entity_id, changes, date_created, prev_hash, hash
+	FROM
+		audit_log
+	ORDER BY
+		seq DESC
+	LIMIT 1`
+
+	var dbE dbEntry
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, struct{}{}, &dbE); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return audit.Entry{}, fmt.Errorf("namedquerystruct: %w", audit.ErrNotFound)
+		}
+		return audit.Entry{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreEntry(dbE)
+}
+
+// Create appends a new entry to the audit log.
+func (s *Store) Create(ctx context.Context, e audit.Entry) error {
+	dbE, err := toDBEntry(e)
+	if err != nil {
+		return err
+	}
+
+	const q = `
+	INSERT INTO audit_log
+		(seq, entry_id, actor_id, trace_id, client_ip, action, entity_type, entity_id, changes, date_created, prev_hash, hash)
+	VALUES
+		(:seq, :entry_id, :actor_id, :trace_id, :client_ip, :action, :entity_type, :entity_id, :changes, :date_created, :prev_hash, :hash)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, dbE); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of entries from the database, the most recent
+// first.
+func (s *Store) Query(ctx context.Context, filter audit.QueryFilter, pageNumber int, rowsPerPage int) ([]audit.Entry, error) {
+	data := map[string]interface{}{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		seq, entry_id, actor_id, trace_id, client_ip, action, entity_type, entity_id, changes, date_created, prev_hash, hash
+	FROM
+		audit_log`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+
+	buf.WriteString(" ORDER BY seq DESC")
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbEntries []dbEntry
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbEntries); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreEntrySlice(dbEntries)
+}
+
+// Count returns the total number of entries in the DB.
+func (s *Store) Count(ctx context.Context, filter audit.QueryFilter) (int, error) {
+	data := map[string]interface{}{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		audit_log`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryByID gets the specified entry from the database.
+func (s *Store) QueryByID(ctx context.Context, entryID uuid.UUID) (audit.Entry, error) {
+	data := struct {
+		ID uuid.UUID `db:"entry_id"`
+	}{
+		ID: entryID,
+	}
+
+	const q = `
+	SELECT
+		seq, entry_id, actor_id, trace_id, client_ip, action, entity_type, entity_id, changes, date_created, prev_hash, hash
+	FROM
+		audit_log
+	WHERE
+		entry_id = :entry_id`
+
+	var dbE dbEntry
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbE); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return audit.Entry{}, fmt.Errorf("namedquerystruct: %w", audit.ErrNotFound)
+		}
+		return audit.Entry{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreEntry(dbE)
+}
+
+// QueryBySeq retrieves up to rows entries in chain order starting with the
+// specified sequence number.
+func (s *Store) QueryBySeq(ctx context.Context, fromSeq int64, rows int) ([]audit.Entry, error) {
+	data := struct {
+		FromSeq int64 `db:"from_seq"`
+		Rows    int   `db:"rows"`
+	}{
+		FromSeq: fromSeq,
+		Rows:    rows,
+	}
+
+	const q = `
+	SELECT
+		seq, entry_id, actor_id, trace_id, client_ip, action, entity_type, entity_id, changes, date_created, prev_hash, hash
+	FROM
+		audit_log
+	WHERE
+		seq >= :from_seq
+	ORDER BY
+		seq
+	LIMIT :rows`
+
+	var dbEntries []dbEntry
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbEntries); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreEntrySlice(dbEntries)
+}
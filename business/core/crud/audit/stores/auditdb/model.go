@@ -0,0 +1,88 @@
+package auditdb
+
+import (
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/google/uuid"
+)
+
+type dbEntry struct {
+	Seq         int64     `db:"seq"`
+	ID          uuid.UUID `db:"entry_id"`
+	ActorID     uuid.UUID `db:"actor_id"`
+	TraceID     string    `db:"trace_id"`
+	ClientIP    string    `db:"client_ip"`
+	Action      string    `db:"action"`
+	EntityType  string    `db:"entity_type"`
+	EntityID    uuid.UUID `db:"entity_id"`
+	Changes     string    `db:"changes" log:"redact"`
+	DateCreated time.Time `db:"date_created"`
+	PrevHash    string    `db:"prev_hash"`
+	Hash        string    `db:"hash"`
+}
+
+func toDBEntry(e audit.Entry) (dbEntry, error) {
+	changes, err := audit.MarshalChanges(e.Changes)
+	if err != nil {
+		return dbEntry{}, fmt.Errorf("marshal changes: %w", err)
+	}
+
+	dbE := dbEntry{
+		Seq:         e.Seq,
+		ID:          e.ID,
+		ActorID:     e.Actor.UserID,
+		TraceID:     e.Actor.TraceID,
+		ClientIP:    e.Actor.ClientIP,
+		Action:      e.Action,
+		EntityType:  e.EntityType,
+		EntityID:    e.EntityID,
+		Changes:     string(changes),
+		DateCreated: e.DateCreated.UTC(),
+		PrevHash:    e.PrevHash,
+		Hash:        e.Hash,
+	}
+
+	return dbE, nil
+}
+
+func toCoreEntry(dbE dbEntry) (audit.Entry, error) {
+	var changes []audit.Change
+	if err := json.Unmarshal([]byte(dbE.Changes), &changes); err != nil {
+		return audit.Entry{}, fmt.Errorf("unmarshal changes: seq[%d]: %w", dbE.Seq, err)
+	}
+
+	e := audit.Entry{
+		ID:  dbE.ID,
+		Seq: dbE.Seq,
+		Actor: audit.Actor{
+			UserID:   dbE.ActorID,
+			TraceID:  dbE.TraceID,
+			ClientIP: dbE.ClientIP,
+		},
+		Action:      dbE.Action,
+		EntityType:  dbE.EntityType,
+		EntityID:    dbE.EntityID,
+		Changes:     changes,
+		DateCreated: dbE.DateCreated.In(time.Local),
+		PrevHash:    dbE.PrevHash,
+		Hash:        dbE.Hash,
+	}
+
+	return e, nil
+}
+
+func toCoreEntrySlice(dbEntries []dbEntry) ([]audit.Entry, error) {
+	entries := make([]audit.Entry, len(dbEntries))
+	for i, dbE := range dbEntries {
+		var err error
+		entries[i], err = toCoreEntry(dbE)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
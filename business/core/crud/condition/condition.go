@@ -9,6 +9,7 @@ import (
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
 	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"time"
 
@@ -17,8 +18,9 @@ import (
 
 // Set of error variables for CRUD operations.
 var (
-	ErrNotFound     = errors.New("condition not found")
-	ErrUserDisabled = errors.New("user disabled")
+	ErrNotFound        = errors.New("condition not found")
+	ErrUserDisabled    = errors.New("user disabled")
+	ErrVersionConflict = errors.New("condition was changed by another request")
 )
 
 // Storer interface declares the behaviour this package needs to persist and
@@ -29,6 +31,7 @@ type Storer interface {
 	Update(ctx context.Context, cn Condition) error
 	Delete(ctx context.Context, cn Condition) error
 	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Condition, error)
+	QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, rows int) ([]Condition, error)
 	Count(ctx context.Context, filter QueryFilter) (int, error)
 	QueryByID(ctx context.Context, conditionID uuid.UUID) (Condition, error)
 	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Condition, error)
@@ -65,10 +68,15 @@ func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error
 		return nil, err
 	}
 
+	dlg, err := c.delegate.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	core := Core{
 		log:      c.log,
 		usrCore:  usrCore,
-		delegate: c.delegate,
+		delegate: dlg,
 		storer:   storer,
 	}
 
@@ -94,17 +102,24 @@ func (c *Core) Create(ctx context.Context, nr NewCondition) (Condition, error) {
 		UserID:      nr.UserID,
 		DateCreated: now,
 		DateUpdated: now,
+		Version:     1,
 	}
 
 	if err := c.storer.Create(ctx, hme); err != nil {
 		return Condition{}, fmt.Errorf("create: %w", err)
 	}
 
+	if err := c.delegate.Call(ctx, ActionCreatedData(hme)); err != nil {
+		return Condition{}, fmt.Errorf("failed to execute `%s` action: %w", ActionCreated, err)
+	}
+
 	return hme, nil
 }
 
 // Update modifies information about a condition.
 func (c *Core) Update(ctx context.Context, condition Condition, ur UpdateCondition) (Condition, error) {
+	before := condition
+
 	if ur.Name != nil {
 		condition.Name = *ur.Name
 	}
@@ -115,6 +130,12 @@ func (c *Core) Update(ctx context.Context, condition Condition, ur UpdateConditi
 		return Condition{}, fmt.Errorf("update: %w", err)
 	}
 
+	condition.Version++
+
+	if err := c.delegate.Call(ctx, ActionUpdatedData(before, condition)); err != nil {
+		return Condition{}, fmt.Errorf("failed to execute `%s` action: %w", ActionUpdated, err)
+	}
+
 	return condition, nil
 }
 
@@ -124,6 +145,10 @@ func (c *Core) Delete(ctx context.Context, cn Condition) error {
 		return fmt.Errorf("delete: %w", err)
 	}
 
+	if err := c.delegate.Call(ctx, ActionDeletedData(cn)); err != nil {
+		return fmt.Errorf("failed to execute `%s` action: %w", ActionDeleted, err)
+	}
+
 	return nil
 }
 
@@ -141,6 +166,21 @@ func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By,
 	return hmes, nil
 }
 
+// QueryByCursor retrieves a list of existing conditions positioned after the
+// cursor, or before it for a backward cursor, in list order.
+func (c *Core) QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, rows int) ([]Condition, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	cns, err := c.storer.QueryByCursor(ctx, filter, orderBy, cursor, rows)
+	if err != nil {
+		return nil, fmt.Errorf("querybycursor: %w", err)
+	}
+
+	return cns, nil
+}
+
 // Count returns the total number of conditions.
 func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
 	if err := filter.Validate(); err != nil {
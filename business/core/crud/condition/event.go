@@ -1 +1,149 @@
 package condition
+
+import (
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+
+	"github.com/go-json-experiment/json"
+	"github.com/google/uuid"
+)
+
+// Domain represents the name of this domain.
+const Domain = "condition"
+
+// Set of delegate actions.
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
+// Set of versions of the action parameters. A version is incremented when
+// the parameters change in a way existing listeners can't decode.
+const (
+	ActionCreatedVersion = 1
+	ActionUpdatedVersion = 1
+	ActionDeletedVersion = 1
+)
+
+func init() {
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionCreated, Version: ActionCreatedVersion, Parms: ActionCreatedParms{}})
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionUpdated, Version: ActionUpdatedVersion, Parms: ActionUpdatedParms{}})
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionDeleted, Version: ActionDeletedVersion, Parms: ActionDeletedParms{}})
+}
+
+// =============================================================================
+
+// ActionCreatedParms represents the parameters for the created action.
+type ActionCreatedParms struct {
+	ConditionID uuid.UUID
+	After       Condition
+}
+
+// String returns a string representation of the action parameters.
+func (ac *ActionCreatedParms) String() string {
+	return fmt.Sprintf("&ActionCreatedParms{ConditionID:%v, Version:%v}", ac.ConditionID, ac.After.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (ac *ActionCreatedParms) Marshal() ([]byte, error) {
+	return json.Marshal(ac)
+}
+
+// ActionCreatedData constructs the data for the created action.
+func ActionCreatedData(cn Condition) delegate.Data {
+	params := ActionCreatedParms{
+		ConditionID: cn.ID,
+		After:       cn,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionCreated,
+		Version:   ActionCreatedVersion,
+		RawParams: rawParams,
+	}
+}
+
+// =============================================================================
+
+// ActionUpdatedParms represents the parameters for the updated action.
+type ActionUpdatedParms struct {
+	ConditionID uuid.UUID
+	Before      Condition
+	After       Condition
+}
+
+// String returns a string representation of the action parameters.
+func (au *ActionUpdatedParms) String() string {
+	return fmt.Sprintf("&ActionUpdatedParms{ConditionID:%v, Version:%v}", au.ConditionID, au.After.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (au *ActionUpdatedParms) Marshal() ([]byte, error) {
+	return json.Marshal(au)
+}
+
+// ActionUpdatedData constructs the data for the updated action.
+func ActionUpdatedData(before Condition, after Condition) delegate.Data {
+	params := ActionUpdatedParms{
+		ConditionID: after.ID,
+		Before:      before,
+		After:       after,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionUpdated,
+		Version:   ActionUpdatedVersion,
+		RawParams: rawParams,
+	}
+}
+
+// =============================================================================
+
+// ActionDeletedParms represents the parameters for the deleted action.
+type ActionDeletedParms struct {
+	ConditionID uuid.UUID
+	Before      Condition
+}
+
+// String returns a string representation of the action parameters.
+func (ad *ActionDeletedParms) String() string {
+	return fmt.Sprintf("&ActionDeletedParms{ConditionID:%v, Version:%v}", ad.ConditionID, ad.Before.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (ad *ActionDeletedParms) Marshal() ([]byte, error) {
+	return json.Marshal(ad)
+}
+
+// ActionDeletedData constructs the data for the deleted action.
+func ActionDeletedData(cn Condition) delegate.Data {
+	params := ActionDeletedParms{
+		ConditionID: cn.ID,
+		Before:      cn,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionDeleted,
+		Version:   ActionDeletedVersion,
+		RawParams: rawParams,
+	}
+}
@@ -13,6 +13,7 @@ type Condition struct {
 	Name        string
 	DateCreated time.Time
 	DateUpdated time.Time
+	Version     int
 }
 
 // NewCondition is what we require from clients when adding a condition.
@@ -14,6 +14,7 @@ type dbCondition struct {
 	Name        string    `db:"name"`
 	DateCreated time.Time `db:"date_created"`
 	DateUpdated time.Time `db:"date_updated"`
+	Version     int       `db:"version"`
 }
 
 func toDBCondition(rl condition.Condition) dbCondition {
@@ -23,6 +24,7 @@ func toDBCondition(rl condition.Condition) dbCondition {
 		Name:        rl.Name,
 		DateCreated: rl.DateCreated.UTC(),
 		DateUpdated: rl.DateUpdated.UTC(),
+		Version:     rl.Version,
 	}
 
 	return rlDB
@@ -35,6 +37,7 @@ func toCoreCondition(dbCn dbCondition) (condition.Condition, error) {
 		Name:        dbCn.Name,
 		DateCreated: dbCn.DateCreated.In(time.Local),
 		DateUpdated: dbCn.DateUpdated.In(time.Local),
+		Version:     dbCn.Version,
 	}
 
 	return rl, nil
@@ -3,7 +3,9 @@ package conditiondb
 import (
 	"fmt"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/condition"
+	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 )
 
 var orderByFields = map[string]string{
@@ -12,11 +14,51 @@ var orderByFields = map[string]string{
 	condition.OrderByUserID: "user_id",
 }
 
+// orderByColumns maps the fields of the order to columns. The condition_id
+// is appended as a tiebreaker, unless the order includes it, so rows that
+// are equal in every field still have a stable order.
+func orderByColumns(orderBy order.By) ([]sqldb.OrderColumn, error) {
+	cols := make([]sqldb.OrderColumn, 0, len(orderBy.Fields)+1)
+
+	var hasID bool
+	for _, fld := range orderBy.Fields {
+		by, exists := orderByFields[fld.Name]
+		if !exists {
+			return nil, fmt.Errorf("field %q does not exist", fld.Name)
+		}
+
+		hasID = hasID || by == "condition_id"
+		cols = append(cols, sqldb.OrderColumn{Name: by, Descending: fld.Direction == order.DESC})
+	}
+
+	if !hasID {
+		cols = append(cols, sqldb.OrderColumn{Name: "condition_id"})
+	}
+
+	return cols, nil
+}
+
 func orderByClause(orderBy order.By) (string, error) {
-	by, exists := orderByFields[orderBy.Field]
-	if !exists {
-		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	cols, err := orderByColumns(orderBy)
+	if err != nil {
+		return "", err
+	}
+
+	return sqldb.OrderByClause(cols), nil
+}
+
+// keyset returns the keyset for cursor paging in the order.
+func keyset(orderBy order.By, cursor page.Cursor) (sqldb.Keyset, error) {
+	cols, err := orderByColumns(orderBy)
+	if err != nil {
+		return sqldb.Keyset{}, err
+	}
+
+	ks := sqldb.Keyset{
+		Columns:  cols,
+		Values:   cursor.Values,
+		Backward: cursor.Backward,
 	}
 
-	return " ORDER BY " + by + " " + orderBy.Direction, nil
+	return ks, nil
 }
@@ -10,7 +10,9 @@ import (
 	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
 	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"slices"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -50,9 +52,9 @@ func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (condition.S
 func (s *Store) Create(ctx context.Context, cn condition.Condition) error {
 	const q = `
     INSERT INTO conditions
-        (condition_id, user_id, name, date_created, date_updated)
+        (condition_id, user_id, name, date_created, date_updated, version)
     VALUES
-        (:condition_id, :user_id, :name, :date_created, :date_updated)`
+        (:condition_id, :user_id, :name, :date_created, :date_updated, :version)`
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBCondition(cn)); err != nil {
 		return fmt.Errorf("namedexeccontext: %w", err)
@@ -62,21 +64,32 @@ func (s *Store) Create(ctx context.Context, cn condition.Condition) error {
 }
 
 // Delete removes a condition from the database.
-func (s *Store) Delete(ctx context.Context, condition condition.Condition) error {
+func (s *Store) Delete(ctx context.Context, cn condition.Condition) error {
 	data := struct {
-		ID string `db:"condition_id"`
+		ID      string `db:"condition_id"`
+		Version int    `db:"version"`
 	}{
-		ID: condition.ID.String(),
+		ID:      cn.ID.String(),
+		Version: cn.Version,
 	}
 
 	const q = `
     DELETE FROM
 	    conditions
 	WHERE
-	  	condition_id = :condition_id`
+	  	condition_id = :condition_id AND version = :version
+	RETURNING
+	  	condition_id`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
-		return fmt.Errorf("namedexeccontext: %w", err)
+	var dest struct {
+		ID string `db:"condition_id"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", condition.ErrVersionConflict)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
 	}
 
 	return nil
@@ -89,12 +102,22 @@ func (s *Store) Update(ctx context.Context, rl condition.Condition) error {
         conditions
     SET
         "name"          = :name,
-        "date_updated"  = :date_updated
+        "date_updated"  = :date_updated,
+        "version"       = "version" + 1
     WHERE
-        condition_id = :condition_id`
+        condition_id = :condition_id AND version = :version
+    RETURNING
+        version`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBCondition(rl)); err != nil {
-		return fmt.Errorf("namedexeccontext: %w", err)
+	var dest struct {
+		Version int `db:"version"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, toDBCondition(rl), &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", condition.ErrVersionConflict)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
 	}
 
 	return nil
@@ -109,7 +132,7 @@ func (s *Store) Query(ctx context.Context, filter condition.QueryFilter, orderBy
 
 	const q = `
     SELECT
-	    condition_id, user_id, name, date_created, date_updated
+	    condition_id, user_id, name, date_created, date_updated, version
 	FROM
 	  	conditions`
 
@@ -137,6 +160,62 @@ func (s *Store) Query(ctx context.Context, filter condition.QueryFilter, orderBy
 	return conditions, nil
 }
 
+// QueryByCursor retrieves the conditions positioned after the cursor from the
+// database, or before it for a backward cursor, in list order.
+func (s *Store) QueryByCursor(ctx context.Context, filter condition.QueryFilter, orderBy order.By, cursor page.Cursor, rows int) ([]condition.Condition, error) {
+	data := map[string]interface{}{
+		"rows_per_page": rows,
+	}
+
+	const q = `
+    SELECT
+	    condition_id, user_id, name, date_created, date_updated, version
+	FROM
+	  	conditions`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf)
+
+	ks, err := keyset(orderBy, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	wc, err := ks.Where(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if wc != "" {
+		switch {
+		case buf.Len() > len(q):
+			buf.WriteString(" AND ")
+		default:
+			buf.WriteString(" WHERE ")
+		}
+		buf.WriteString(wc)
+	}
+
+	buf.WriteString(ks.OrderBy())
+	buf.WriteString(" FETCH FIRST :rows_per_page ROWS ONLY")
+
+	var dbConditions []dbCondition
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbConditions); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	if cursor.Backward {
+		slices.Reverse(dbConditions)
+	}
+
+	conditions, err := toCoreConditionsSlice(dbConditions)
+	if err != nil {
+		return nil, err
+	}
+
+	return conditions, nil
+}
+
 // Count returns the total number of conditions in the DB.
 func (s *Store) Count(ctx context.Context, filter condition.QueryFilter) (int, error) {
 	data := map[string]interface{}{}
@@ -170,7 +249,7 @@ func (s *Store) QueryByID(ctx context.Context, conditionID uuid.UUID) (condition
 
 	const q = `
     SELECT
-	  	condition_id, user_id, name, date_created, date_updated
+	  	condition_id, user_id, name, date_created, date_updated, version
     FROM
         conditions
     WHERE
@@ -197,7 +276,7 @@ func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]conditio
 
 	const q = `
 	SELECT
-	    condition_id, user_id, name, date_created, date_updated
+	    condition_id, user_id, name, date_created, date_updated, version
 	FROM
 		conditions
 	WHERE
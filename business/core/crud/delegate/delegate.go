@@ -4,9 +4,39 @@ package delegate
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Set of error variables for the outbox.
+var (
+	ErrNotFound   = errors.New("event not found")
+	ErrNotDurable = errors.New("delegate has no outbox")
 )
 
+// Storer interface declares the behaviour this package needs to persist the
+// events of the outbox and the dead letters.
+type Storer interface {
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+	Create(ctx context.Context, ev Event) error
+	Claim(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]Event, error)
+	Retry(ctx context.Context, ev Event) error
+	Delete(ctx context.Context, ev Event) error
+	DeadLetter(ctx context.Context, ev Event, now time.Time) error
+	QueryDeadLetters(ctx context.Context, filter QueryFilter, pageNumber int, rowsPerPage int) ([]DeadLetter, error)
+	CountDeadLetters(ctx context.Context, filter QueryFilter) (int, error)
+	QueryDeadLetterByID(ctx context.Context, eventID uuid.UUID) (DeadLetter, error)
+	Replay(ctx context.Context, eventID uuid.UUID, now time.Time) (Event, error)
+}
+
 // These types are just for documentation so we know what keys go
 // where in the map.
 type (
@@ -17,11 +47,13 @@ type (
 // Delegate manages the set of functions to be called by core
 // packages when an import is not possible.
 type Delegate struct {
-	log   *logger.Logger
-	funcs map[domain]map[action][]Func
+	log    *logger.Logger
+	funcs  map[domain]map[action][]Func
+	storer Storer
 }
 
-// New constructs a delegate for indirect api access.
+// New constructs a delegate for indirect api access. The functions are
+// called synchronously by Call.
 func New(log *logger.Logger) *Delegate {
 	return &Delegate{
 		log:   log,
@@ -29,6 +61,38 @@ func New(log *logger.Logger) *Delegate {
 	}
 }
 
+// NewDurable constructs a delegate that writes the calls to an outbox. The
+// functions are called later by a Dispatcher, with retries, so a failed or
+// slow function neither loses the call nor slows down the caller.
+func NewDurable(log *logger.Logger, storer Storer) *Delegate {
+	d := New(log)
+	d.storer = storer
+
+	return d
+}
+
+// ExecuteUnderTransaction constructs a new Delegate value that writes to
+// the outbox in the specified transaction, so a call is only delivered when
+// the change that made it is committed.
+func (d *Delegate) ExecuteUnderTransaction(tx transaction.Transaction) (*Delegate, error) {
+	if d == nil || d.storer == nil {
+		return d, nil
+	}
+
+	storer, err := d.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	dlg := Delegate{
+		log:    d.log,
+		funcs:  d.funcs,
+		storer: storer,
+	}
+
+	return &dlg, nil
+}
+
 // Register adds a function to be called for a specified domain and action.
 func (d *Delegate) Register(domainType string, actionType string, fn Func) {
 	aMap, ok := d.funcs[domain(domainType)]
@@ -43,22 +107,139 @@ func (d *Delegate) Register(domainType string, actionType string, fn Func) {
 }
 
 // Call executes all functions registered for the specified domain and
-// action. These functions are executed synchronously on the G making the call.
+// action. These functions are executed synchronously on the G making the
+// call and their errors are returned joined. A durable delegate writes the call to
+// the outbox instead and fails when it can't. A nil delegate, used by cores
+// constructed for queries only, ignores the call.
 func (d *Delegate) Call(ctx context.Context, data Data) error {
-	d.log.Info(ctx, "delegate call", "status", "started", "domain", data.Domain, "action", data.Action, "params", data.RawParams)
+	if d == nil {
+		return nil
+	}
+
+	if d.storer != nil {
+		return d.enqueue(ctx, data)
+	}
+
+	return d.execute(ctx, data)
+}
+
+// enqueue writes the call to the outbox when functions are registered for
+// it.
+func (d *Delegate) enqueue(ctx context.Context, data Data) error {
+	if len(d.lookup(data)) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	ev := Event{
+		ID:          uuid.New(),
+		Data:        data,
+		AvailableAt: now,
+		DateCreated: now,
+	}
+
+	if err := d.storer.Create(ctx, ev); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	d.log.Info(ctx, "delegate call", "status", "queued", "domain", data.Domain, "action", data.Action, "event_id", ev.ID)
+
+	return nil
+}
+
+// execute calls the functions registered for the data and returns their
+// errors joined.
+func (d *Delegate) execute(ctx context.Context, data Data) error {
+	ctx, span := web.AddSpan(ctx, "business.core.delegate.call",
+		attribute.String("domain", data.Domain),
+		attribute.String("action", data.Action),
+	)
+	defer span.End()
+
+	d.log.Info(ctx, "delegate call", "status", "started", "domain", data.Domain, "action", data.Action, "version", data.Version)
 	defer d.log.Info(ctx, "delegate call", "status", "completed")
 
-	if dMap, ok := d.funcs[domain(data.Domain)]; ok {
-		if funcs, ok := dMap[action(data.Action)]; ok {
-			for _, fn := range funcs {
-				d.log.Info(ctx, "delegate call", "status", "sending")
-
-				if err := fn(ctx, data); err != nil {
-					d.log.Error(ctx, "delegate call", "msg", err)
-				}
-			}
+	funcs := d.lookup(data)
+	span.SetAttributes(attribute.Int("funcs", len(funcs)))
+
+	var errs []error
+	for _, fn := range funcs {
+		d.log.Info(ctx, "delegate call", "status", "sending")
+
+		if err := fn(ctx, data); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			d.log.Error(ctx, "delegate call", "msg", err)
+			errs = append(errs, err)
 		}
 	}
 
+	return errors.Join(errs...)
+}
+
+// lookup returns the functions registered for the domain and action.
+func (d *Delegate) lookup(data Data) []Func {
+	if dMap, ok := d.funcs[domain(data.Domain)]; ok {
+		return dMap[action(data.Action)]
+	}
+
 	return nil
 }
+
+// =============================================================================
+
+// QueryDeadLetters retrieves a list of the events that failed every delivery
+// attempt.
+func (d *Delegate) QueryDeadLetters(ctx context.Context, filter QueryFilter, pageNumber int, rowsPerPage int) ([]DeadLetter, error) {
+	if d.storer == nil {
+		return nil, ErrNotDurable
+	}
+
+	dls, err := d.storer.QueryDeadLetters(ctx, filter, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return dls, nil
+}
+
+// CountDeadLetters returns the total number of dead letters.
+func (d *Delegate) CountDeadLetters(ctx context.Context, filter QueryFilter) (int, error) {
+	if d.storer == nil {
+		return 0, ErrNotDurable
+	}
+
+	return d.storer.CountDeadLetters(ctx, filter)
+}
+
+// QueryDeadLetterByID finds the dead letter by the specified event ID.
+func (d *Delegate) QueryDeadLetterByID(ctx context.Context, eventID uuid.UUID) (DeadLetter, error) {
+	if d.storer == nil {
+		return DeadLetter{}, ErrNotDurable
+	}
+
+	dl, err := d.storer.QueryDeadLetterByID(ctx, eventID)
+	if err != nil {
+		return DeadLetter{}, fmt.Errorf("query: eventID[%s]: %w", eventID, err)
+	}
+
+	return dl, nil
+}
+
+// Replay moves the dead letter back to the outbox to be delivered again
+// with a fresh number of attempts.
+func (d *Delegate) Replay(ctx context.Context, eventID uuid.UUID) (Event, error) {
+	if d.storer == nil {
+		return Event{}, ErrNotDurable
+	}
+
+	ev, err := d.storer.Replay(ctx, eventID, time.Now())
+	if err != nil {
+		return Event{}, fmt.Errorf("replay: eventID[%s]: %w", eventID, err)
+	}
+
+	d.log.Info(ctx, "delegate call", "status", "replayed", "domain", ev.Data.Domain, "action", ev.Data.Action, "event_id", ev.ID)
+
+	return ev, nil
+}
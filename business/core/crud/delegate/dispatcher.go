@@ -0,0 +1,173 @@
+package delegate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/worker"
+	"time"
+)
+
+// DispatcherConfig represents the settings for delivering the events of the
+// outbox. Every Interval up to BatchSize due events are claimed and hidden
+// from other dispatchers for Lease, which must be longer than the Timeout
+// the functions of an event have to complete. An event that fails is
+// retried after a backoff that doubles from MinBackoff up to MaxBackoff,
+// and is moved to the dead letters after MaxAttempts failures.
+type DispatcherConfig struct {
+	Interval    time.Duration
+	BatchSize   int
+	Lease       time.Duration
+	Timeout     time.Duration
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Dispatcher delivers the events of the outbox to the functions registered
+// with the delegate. Events are delivered at least once, so the functions
+// must be safe to run again for the same event.
+type Dispatcher struct {
+	log      *logger.Logger
+	delegate *Delegate
+	worker   *worker.Worker
+	cfg      DispatcherConfig
+}
+
+// NewDispatcher constructs a dispatcher for the durable delegate that runs
+// the deliveries on the worker.
+func NewDispatcher(log *logger.Logger, d *Delegate, w *worker.Worker, cfg DispatcherConfig) (*Dispatcher, error) {
+	if d.storer == nil {
+		return nil, ErrNotDurable
+	}
+
+	if cfg.Interval <= 0 || cfg.BatchSize <= 0 || cfg.Timeout <= 0 || cfg.MaxAttempts <= 0 {
+		return nil, errors.New("interval, batch size, timeout and max attempts must be greater than 0")
+	}
+
+	if cfg.Lease <= cfg.Timeout {
+		return nil, errors.New("lease must be longer than the timeout")
+	}
+
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		return nil, errors.New("max backoff must not be less than min backoff")
+	}
+
+	dp := Dispatcher{
+		log:      log,
+		delegate: d,
+		worker:   w,
+		cfg:      cfg,
+	}
+
+	return &dp, nil
+}
+
+// Run dispatches the due events every interval until the context is
+// canceled.
+func (dp *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(dp.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := dp.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			dp.log.Error(ctx, "delegate dispatch", "status", "dispatching", "msg", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch claims a batch of due events and starts their delivery on the
+// worker. It returns the number of deliveries started. Events that were
+// claimed but not started are delivered once their lease ends.
+func (dp *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	evs, err := dp.delegate.storer.Claim(ctx, now, now.Add(dp.cfg.Lease), dp.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claim: %w", err)
+	}
+
+	for i, ev := range evs {
+		// The timeout starts once the job runs so the time spent waiting for
+		// a free worker isn't taken from it. Waiting and the job both end
+		// with the lease at the latest, since the event is claimed again
+		// after it.
+		job := func(ctx context.Context) {
+			ctx, cancel := context.WithTimeout(ctx, dp.cfg.Timeout)
+			defer cancel()
+
+			dp.deliver(ctx, ev)
+		}
+
+		leaseCtx, cancel := context.WithDeadline(ctx, now.Add(dp.cfg.Lease))
+		_, err := dp.worker.Start(leaseCtx, job)
+		cancel()
+
+		if err != nil {
+			return i, fmt.Errorf("start: eventID[%s]: %w", ev.ID, err)
+		}
+	}
+
+	return len(evs), nil
+}
+
+// deliver calls the functions registered for the event and records the
+// outcome in the outbox.
+func (dp *Dispatcher) deliver(ctx context.Context, ev Event) {
+	err := dp.delegate.execute(ctx, ev.Data)
+
+	// The outcome is recorded even when the functions used up the time
+	// they had.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err == nil {
+		if err := dp.delegate.storer.Delete(ctx, ev); err != nil {
+			dp.log.Error(ctx, "delegate dispatch", "status", "deleting delivered event", "event_id", ev.ID, "msg", err)
+		}
+		return
+	}
+
+	ev.Attempts++
+	ev.LastError = err.Error()
+
+	if ev.Attempts >= dp.cfg.MaxAttempts {
+		dp.log.Error(ctx, "delegate dispatch", "status", "dead lettered", "event_id", ev.ID, "domain", ev.Data.Domain, "action", ev.Data.Action, "attempts", ev.Attempts, "msg", err)
+
+		if err := dp.delegate.storer.DeadLetter(ctx, ev, time.Now()); err != nil {
+			dp.log.Error(ctx, "delegate dispatch", "status", "dead lettering event", "event_id", ev.ID, "msg", err)
+		}
+		return
+	}
+
+	ev.AvailableAt = time.Now().Add(Backoff(ev.Attempts, dp.cfg.MinBackoff, dp.cfg.MaxBackoff))
+
+	dp.log.Warn(ctx, "delegate dispatch", "status", "retrying", "event_id", ev.ID, "attempts", ev.Attempts, "available_at", ev.AvailableAt, "msg", err)
+
+	if err := dp.delegate.storer.Retry(ctx, ev); err != nil {
+		dp.log.Error(ctx, "delegate dispatch", "status", "scheduling retry", "event_id", ev.ID, "msg", err)
+	}
+}
+
+// Backoff returns the time to wait before the next attempt after the
+// specified number of failed attempts. It doubles from min with every
+// attempt and is capped at max.
+func Backoff(attempts int, min time.Duration, max time.Duration) time.Duration {
+	backoff := min
+	for i := 1; i < attempts && backoff < max; i++ {
+		backoff *= 2
+	}
+
+	if backoff > max {
+		return max
+	}
+
+	return backoff
+}
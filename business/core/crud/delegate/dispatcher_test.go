@@ -0,0 +1,388 @@
+package delegate_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/worker"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps the outbox and dead letters in memory.
+type memoryStore struct {
+	mu          sync.Mutex
+	outbox      map[uuid.UUID]delegate.Event
+	deadLetters map[uuid.UUID]delegate.DeadLetter
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		outbox:      make(map[uuid.UUID]delegate.Event),
+		deadLetters: make(map[uuid.UUID]delegate.DeadLetter),
+	}
+}
+
+func (ms *memoryStore) ExecuteUnderTransaction(tx transaction.Transaction) (delegate.Storer, error) {
+	return ms, nil
+}
+
+func (ms *memoryStore) Create(ctx context.Context, ev delegate.Event) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.outbox[ev.ID] = ev
+	return nil
+}
+
+func (ms *memoryStore) Claim(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]delegate.Event, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var evs []delegate.Event
+	for id, ev := range ms.outbox {
+		if len(evs) == limit || ev.AvailableAt.After(now) {
+			continue
+		}
+
+		ev.AvailableAt = leaseUntil
+		ms.outbox[id] = ev
+		evs = append(evs, ev)
+	}
+
+	return evs, nil
+}
+
+func (ms *memoryStore) Retry(ctx context.Context, ev delegate.Event) error {
+	return ms.Create(ctx, ev)
+}
+
+func (ms *memoryStore) Delete(ctx context.Context, ev delegate.Event) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.outbox, ev.ID)
+	return nil
+}
+
+func (ms *memoryStore) DeadLetter(ctx context.Context, ev delegate.Event, now time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.outbox, ev.ID)
+	ms.deadLetters[ev.ID] = delegate.DeadLetter{Event: ev, DateFailed: now}
+	return nil
+}
+
+func (ms *memoryStore) QueryDeadLetters(ctx context.Context, filter delegate.QueryFilter, pageNumber int, rowsPerPage int) ([]delegate.DeadLetter, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var dls []delegate.DeadLetter
+	for _, dl := range ms.deadLetters {
+		dls = append(dls, dl)
+	}
+
+	return dls, nil
+}
+
+func (ms *memoryStore) CountDeadLetters(ctx context.Context, filter delegate.QueryFilter) (int, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return len(ms.deadLetters), nil
+}
+
+func (ms *memoryStore) QueryDeadLetterByID(ctx context.Context, eventID uuid.UUID) (delegate.DeadLetter, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	dl, exists := ms.deadLetters[eventID]
+	if !exists {
+		return delegate.DeadLetter{}, delegate.ErrNotFound
+	}
+
+	return dl, nil
+}
+
+func (ms *memoryStore) Replay(ctx context.Context, eventID uuid.UUID, now time.Time) (delegate.Event, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	dl, exists := ms.deadLetters[eventID]
+	if !exists {
+		return delegate.Event{}, delegate.ErrNotFound
+	}
+	delete(ms.deadLetters, eventID)
+
+	ev := dl.Event
+	ev.Attempts = 0
+	ev.LastError = ""
+	ev.AvailableAt = now
+	ms.outbox[ev.ID] = ev
+
+	return ev, nil
+}
+
+func (ms *memoryStore) sizes() (int, int) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return len(ms.outbox), len(ms.deadLetters)
+}
+
+// =============================================================================
+
+func Test_Dispatcher(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	store := newMemoryStore()
+	dlg := delegate.NewDurable(log, store)
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	failing := true
+
+	dlg.Register("user", "updated", func(ctx context.Context, data delegate.Data) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		calls[string(data.RawParams)]++
+		if failing && string(data.RawParams) == "fail" {
+			return errors.New("listener failed")
+		}
+		return nil
+	})
+
+	wrk, err := worker.New(2)
+	if err != nil {
+		t.Fatalf("Should be able to construct the worker: %s", err)
+	}
+
+	dispatcher, err := delegate.NewDispatcher(log, dlg, wrk, delegate.DispatcherConfig{
+		Interval:    time.Millisecond,
+		BatchSize:   10,
+		Lease:       time.Second,
+		Timeout:     100 * time.Millisecond,
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+		MaxBackoff:  2 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct the dispatcher: %s", err)
+	}
+
+	ctx := context.Background()
+
+	for _, params := range []string{"ok", "fail"} {
+		if err := dlg.Call(ctx, delegate.Data{Domain: "user", Action: "updated", RawParams: []byte(params)}); err != nil {
+			t.Fatalf("Should be able to call the delegate: %s", err)
+		}
+	}
+
+	if err := dlg.Call(ctx, delegate.Data{Domain: "user", Action: "deleted"}); err != nil {
+		t.Fatalf("Should be able to call the delegate: %s", err)
+	}
+
+	if outbox, _ := store.sizes(); outbox != 2 {
+		t.Fatalf("Should write the calls with functions to the outbox: got %d", outbox)
+	}
+
+	mu.Lock()
+	if len(calls) != 0 {
+		t.Fatalf("Should not call the functions on the calling G: got %v", calls)
+	}
+	mu.Unlock()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	go dispatcher.Run(runCtx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		outbox, deadLetters := store.sizes()
+		if outbox == 0 && deadLetters == 1 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Should deliver the events: outbox %d, dead letters %d", outbox, deadLetters)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	if err := wrk.Shutdown(ctx); err != nil {
+		t.Fatalf("Should be able to shut down the worker: %s", err)
+	}
+
+	mu.Lock()
+	if calls["ok"] != 1 || calls["fail"] != 3 {
+		t.Errorf("Should deliver once and retry up to the max attempts: got %v", calls)
+	}
+	failing = false
+	mu.Unlock()
+
+	dls, err := dlg.QueryDeadLetters(ctx, delegate.QueryFilter{}, 1, 10)
+	if err != nil || len(dls) != 1 {
+		t.Fatalf("Should be able to query the dead letter: %v %v", dls, err)
+	}
+
+	if dls[0].Attempts != 3 || dls[0].LastError != "listener failed" {
+		t.Errorf("Should record the attempts and last error: got %+v", dls[0])
+	}
+
+	ev, err := dlg.Replay(ctx, dls[0].ID)
+	if err != nil {
+		t.Fatalf("Should be able to replay the dead letter: %s", err)
+	}
+
+	if ev.Attempts != 0 {
+		t.Errorf("Should reset the attempts: got %d", ev.Attempts)
+	}
+
+	if _, err := dlg.Replay(ctx, dls[0].ID); !errors.Is(err, delegate.ErrNotFound) {
+		t.Errorf("Should not be able to replay an event twice: got %v", err)
+	}
+
+	wrk, err = worker.New(1)
+	if err != nil {
+		t.Fatalf("Should be able to construct the worker: %s", err)
+	}
+
+	dispatcher, err = delegate.NewDispatcher(log, dlg, wrk, delegate.DispatcherConfig{
+		Interval:    time.Millisecond,
+		BatchSize:   10,
+		Lease:       time.Second,
+		Timeout:     100 * time.Millisecond,
+		MaxAttempts: 3,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct the dispatcher: %s", err)
+	}
+
+	if n, err := dispatcher.Dispatch(ctx); err != nil || n != 1 {
+		t.Fatalf("Should dispatch the replayed event: got %d %v", n, err)
+	}
+
+	if err := wrk.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Should be able to shut down the worker: %s", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if calls["fail"] != 4 {
+		t.Errorf("Should deliver the replayed event: got %v", calls)
+	}
+}
+
+func Test_DispatcherTimeout(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	store := newMemoryStore()
+	dlg := delegate.NewDurable(log, store)
+
+	// Every call takes most of the timeout, so a call that waited for the
+	// previous one would run out of time if the wait counted against it.
+	dlg.Register("user", "updated", func(ctx context.Context, data delegate.Data) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(60 * time.Millisecond):
+			return nil
+		}
+	})
+
+	wrk, err := worker.New(1)
+	if err != nil {
+		t.Fatalf("Should be able to construct the worker: %s", err)
+	}
+
+	dispatcher, err := delegate.NewDispatcher(log, dlg, wrk, delegate.DispatcherConfig{
+		Interval:    time.Millisecond,
+		BatchSize:   10,
+		Lease:       time.Second,
+		Timeout:     100 * time.Millisecond,
+		MaxAttempts: 3,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct the dispatcher: %s", err)
+	}
+
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := dlg.Call(ctx, delegate.Data{Domain: "user", Action: "updated"}); err != nil {
+			t.Fatalf("Should be able to call the delegate: %s", err)
+		}
+	}
+
+	if n, err := dispatcher.Dispatch(ctx); err != nil || n != 3 {
+		t.Fatalf("Should dispatch the events: got %d %v", n, err)
+	}
+
+	// Shutting down cancels the running jobs, so the last one is waited for.
+	deadline := time.Now().Add(time.Second)
+	for wrk.Running() > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := wrk.Shutdown(ctx); err != nil {
+		t.Fatalf("Should be able to shut down the worker: %s", err)
+	}
+
+	if outbox, _ := store.sizes(); outbox != 0 {
+		t.Errorf("Should deliver every event within its own timeout: %d left in the outbox", outbox)
+	}
+}
+
+func Test_Call(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	dlg := delegate.New(log)
+
+	errFailed := errors.New("listener failed")
+	dlg.Register("user", "updated", func(ctx context.Context, data delegate.Data) error {
+		return errFailed
+	})
+	dlg.Register("user", "updated", func(ctx context.Context, data delegate.Data) error {
+		return nil
+	})
+
+	ctx := context.Background()
+
+	if err := dlg.Call(ctx, delegate.Data{Domain: "user", Action: "updated"}); !errors.Is(err, errFailed) {
+		t.Errorf("Should return the errors of the functions: got %v", err)
+	}
+
+	if err := dlg.Call(ctx, delegate.Data{Domain: "user", Action: "deleted"}); err != nil {
+		t.Errorf("Should not fail without functions: got %v", err)
+	}
+}
+
+func Test_Backoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		exp      time.Duration
+	}{
+		{attempts: 1, exp: time.Second},
+		{attempts: 2, exp: 2 * time.Second},
+		{attempts: 4, exp: 8 * time.Second},
+		{attempts: 10, exp: time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := delegate.Backoff(tt.attempts, time.Second, time.Minute); got != tt.exp {
+			t.Errorf("Should get %s after %d attempts: got %s", tt.exp, tt.attempts, got)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package delegate
+
+// QueryFilter holds the available fields dead letters can be filtered on.
+// We are using pointer semantics because the With API mutates the value.
+type QueryFilter struct {
+	Domain *string
+	Action *string
+}
+
+// WithDomain sets the Domain field of the QueryFilter value.
+func (qf *QueryFilter) WithDomain(domain string) {
+	qf.Domain = &domain
+}
+
+// WithAction sets the Action field of the QueryFilter value.
+func (qf *QueryFilter) WithAction(action string) {
+	qf.Action = &action
+}
@@ -3,22 +3,46 @@ package delegate
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // Func represents a function that is registered and called by the system.
 type Func func(context.Context, Data) error
 
-// Data represents an event between core domains.
+// Data represents an event between core domains. Version is the version
+// of the schema the RawParams are encoded with.
 type Data struct {
 	Domain    string
 	Action    string
+	Version   int
 	RawParams []byte
 }
 
 // String implements the Stringer interface.
 func (d Data) String() string {
 	return fmt.Sprintf(
-		"Event{Domain:%#v, Action:%#v, RawParams:%#v}",
-		d.Domain, d.Action, string(d.RawParams),
+		"Event{Domain:%#v, Action:%#v, Version:%d, RawParams:%#v}",
+		d.Domain, d.Action, d.Version, string(d.RawParams),
 	)
 }
+
+// Event represents data written to the outbox to be delivered to the
+// registered functions by the dispatcher. Attempts counts the failed
+// deliveries and LastError holds the error of the last of them. The event
+// isn't delivered before AvailableAt.
+type Event struct {
+	ID          uuid.UUID
+	Data        Data
+	Attempts    int
+	LastError   string
+	AvailableAt time.Time
+	DateCreated time.Time
+}
+
+// DeadLetter represents an event that failed every delivery attempt.
+type DeadLetter struct {
+	Event
+	DateFailed time.Time
+}
@@ -0,0 +1,69 @@
+package delegate
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Schema describes the parameters carried by the data of a domain and
+// action. Parms holds a value of the typed parameters the RawParams are
+// encoded from, and Version is incremented whenever they change in a way
+// existing listeners can't read.
+type Schema struct {
+	Domain  string
+	Action  string
+	Version int
+	Parms   any
+}
+
+var registry = struct {
+	mu      sync.RWMutex
+	schemas map[string]Schema
+}{
+	schemas: make(map[string]Schema),
+}
+
+// RegisterSchema adds the schema of a domain and action to the registry so
+// listeners and consumers can discover the data they receive. Registering a
+// domain and action twice is a programming error and panics.
+func RegisterSchema(s Schema) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	key := s.Domain + "." + s.Action
+	if _, exists := registry.schemas[key]; exists {
+		panic(fmt.Sprintf("delegate: schema %s registered twice", key))
+	}
+
+	registry.schemas[key] = s
+}
+
+// LookupSchema returns the schema registered for the domain and action.
+func LookupSchema(domain string, action string) (Schema, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	s, exists := registry.schemas[domain+"."+action]
+	return s, exists
+}
+
+// Schemas returns the registered schemas ordered by domain and action.
+func Schemas() []Schema {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	schemas := make([]Schema, 0, len(registry.schemas))
+	for _, s := range registry.schemas {
+		schemas = append(schemas, s)
+	}
+
+	sort.Slice(schemas, func(i, j int) bool {
+		if schemas[i].Domain != schemas[j].Domain {
+			return schemas[i].Domain < schemas[j].Domain
+		}
+		return schemas[i].Action < schemas[j].Action
+	})
+
+	return schemas
+}
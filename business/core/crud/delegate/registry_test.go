@@ -0,0 +1,44 @@
+package delegate_test
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"testing"
+)
+
+func Test_Registry(t *testing.T) {
+	type parms struct {
+		ID string
+	}
+
+	delegate.RegisterSchema(delegate.Schema{Domain: "registrytest", Action: "updated", Version: 2, Parms: parms{}})
+	delegate.RegisterSchema(delegate.Schema{Domain: "registrytest", Action: "created", Version: 1, Parms: parms{}})
+
+	s, exists := delegate.LookupSchema("registrytest", "updated")
+	if !exists {
+		t.Fatalf("Should find the registered schema")
+	}
+	if s.Version != 2 {
+		t.Errorf("Should get the registered version: got %d", s.Version)
+	}
+
+	if _, exists := delegate.LookupSchema("registrytest", "deleted"); exists {
+		t.Errorf("Should not find a schema that was never registered")
+	}
+
+	var actions []string
+	for _, s := range delegate.Schemas() {
+		if s.Domain == "registrytest" {
+			actions = append(actions, s.Action)
+		}
+	}
+	if len(actions) != 2 || actions[0] != "created" || actions[1] != "updated" {
+		t.Errorf("Should list the schemas ordered by action: got %v", actions)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Should panic when a schema is registered twice")
+		}
+	}()
+	delegate.RegisterSchema(delegate.Schema{Domain: "registrytest", Action: "created", Version: 1, Parms: parms{}})
+}
@@ -0,0 +1,85 @@
+package outboxdb
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type dbEvent struct {
+	ID          uuid.UUID `db:"event_id"`
+	Domain      string    `db:"domain"`
+	Action      string    `db:"action"`
+	Version     int       `db:"version"`
+	Params      []byte    `db:"params"`
+	Attempts    int       `db:"attempts"`
+	LastError   string    `db:"last_error"`
+	AvailableAt time.Time `db:"available_at"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+func toDBEvent(ev delegate.Event) dbEvent {
+	params := ev.Data.RawParams
+	if params == nil {
+		params = []byte{}
+	}
+
+	return dbEvent{
+		ID:          ev.ID,
+		Domain:      ev.Data.Domain,
+		Action:      ev.Data.Action,
+		Version:     ev.Data.Version,
+		Params:      params,
+		Attempts:    ev.Attempts,
+		LastError:   ev.LastError,
+		AvailableAt: ev.AvailableAt.UTC(),
+		DateCreated: ev.DateCreated.UTC(),
+	}
+}
+
+func toCoreEvent(dbEv dbEvent) delegate.Event {
+	return delegate.Event{
+		ID: dbEv.ID,
+		Data: delegate.Data{
+			Domain:    dbEv.Domain,
+			Action:    dbEv.Action,
+			Version:   dbEv.Version,
+			RawParams: dbEv.Params,
+		},
+		Attempts:    dbEv.Attempts,
+		LastError:   dbEv.LastError,
+		AvailableAt: dbEv.AvailableAt.In(time.Local),
+		DateCreated: dbEv.DateCreated.In(time.Local),
+	}
+}
+
+func toCoreEventSlice(dbEvs []dbEvent) []delegate.Event {
+	evs := make([]delegate.Event, len(dbEvs))
+	for i, dbEv := range dbEvs {
+		evs[i] = toCoreEvent(dbEv)
+	}
+
+	return evs
+}
+
+type dbDeadLetter struct {
+	dbEvent
+	DateFailed time.Time `db:"date_failed"`
+}
+
+func toCoreDeadLetter(dbDL dbDeadLetter) delegate.DeadLetter {
+	return delegate.DeadLetter{
+		Event:      toCoreEvent(dbDL.dbEvent),
+		DateFailed: dbDL.DateFailed.In(time.Local),
+	}
+}
+
+func toCoreDeadLetterSlice(dbDLs []dbDeadLetter) []delegate.DeadLetter {
+	dls := make([]delegate.DeadLetter, len(dbDLs))
+	for i, dbDL := range dbDLs {
+		dls[i] = toCoreDeadLetter(dbDL)
+	}
+
+	return dls
+}
@@ -0,0 +1,318 @@
+// Package outboxdb contains the database backed storage of the delegate
+// outbox and dead letters.
+package outboxdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for outbox database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (delegate.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new event into the outbox.
+func (s *Store) Create(ctx context.Context, ev delegate.Event) error {
+	const q = `
+	INSERT INTO delegate_outbox
+		(event_id, domain, action, version, params, attempts, last_error, available_at, date_created)
+	VALUES
+		(:event_id, :domain, :action, :version, :params, :attempts, :last_error, :available_at, :date_created)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBEvent(ev)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Claim returns up to limit events that are due in the order they became
+// available, and makes them unavailable until the lease ends so no other
+// dispatcher claims them meanwhile. Rows claimed by a concurrent claim are
+// skipped instead of waited for.
+func (s *Store) Claim(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]delegate.Event, error) {
+	data := struct {
+		Now        time.Time `db:"now"`
+		LeaseUntil time.Time `db:"lease_until"`
+		Limit      int       `db:"limit"`
+	}{
+		Now:        now.UTC(),
+		LeaseUntil: leaseUntil.UTC(),
+		Limit:      limit,
+	}
+
+	const q = `
+	UPDATE
+		delegate_outbox
+	SET
+		available_at = :lease_until
+	WHERE
+		event_id IN (
+			SELECT
+				event_id
+			FROM
+				delegate_outbox
+			WHERE
+				available_at <= :now
+			ORDER BY
+				available_at
+			LIMIT :limit
+			FOR UPDATE SKIP LOCKED
+		)
+	RETURNING
+		event_id, domain, action, version, params, attempts, last_error, available_at, date_created`
+
+	var dbEvs []dbEvent
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbEvs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreEventSlice(dbEvs), nil
+}
+
+// Retry records the failed attempt of the event and when it's due again.
+func (s *Store) Retry(ctx context.Context, ev delegate.Event) error {
+	const q = `
+	UPDATE
+		delegate_outbox
+	SET
+		attempts = :attempts,
+		last_error = :last_error,
+		available_at = :available_at
+	WHERE
+		event_id = :event_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBEvent(ev)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a delivered event from the outbox.
+func (s *Store) Delete(ctx context.Context, ev delegate.Event) error {
+	data := struct {
+		ID uuid.UUID `db:"event_id"`
+	}{
+		ID: ev.ID,
+	}
+
+	const q = `
+	DELETE FROM
+		delegate_outbox
+	WHERE
+		event_id = :event_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeadLetter moves the event from the outbox to the dead letters.
+func (s *Store) DeadLetter(ctx context.Context, ev delegate.Event, now time.Time) error {
+	data := struct {
+		dbEvent
+		DateFailed time.Time `db:"date_failed"`
+	}{
+		dbEvent:    toDBEvent(ev),
+		DateFailed: now.UTC(),
+	}
+
+	const q = `
+	WITH moved AS (
+		DELETE FROM
+			delegate_outbox
+		WHERE
+			event_id = :event_id
+		RETURNING
+			event_id, domain, action, version, params, date_created
+	)
+	INSERT INTO delegate_dead_letters
+		(event_id, domain, action, version, params, attempts, last_error, available_at, date_created, date_failed)
+	SELECT
+		event_id, domain, action, version, params, :attempts, :last_error, :available_at, date_created, :date_failed
+	FROM
+		moved`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryDeadLetters retrieves a list of dead letters from the database, the
+// most recent failures first.
+func (s *Store) QueryDeadLetters(ctx context.Context, filter delegate.QueryFilter, pageNumber int, rowsPerPage int) ([]delegate.DeadLetter, error) {
+	data := map[string]interface{}{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		event_id, domain, action, version, params, attempts, last_error, available_at, date_created, date_failed
+	FROM
+		delegate_dead_letters`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf)
+
+	buf.WriteString(" ORDER BY date_failed DESC, event_id")
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbDLs []dbDeadLetter
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbDLs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreDeadLetterSlice(dbDLs), nil
+}
+
+// CountDeadLetters returns the total number of dead letters in the DB.
+func (s *Store) CountDeadLetters(ctx context.Context, filter delegate.QueryFilter) (int, error) {
+	data := map[string]interface{}{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		delegate_dead_letters`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryDeadLetterByID gets the specified dead letter from the database.
+func (s *Store) QueryDeadLetterByID(ctx context.Context, eventID uuid.UUID) (delegate.DeadLetter, error) {
+	data := struct {
+		ID uuid.UUID `db:"event_id"`
+	}{
+		ID: eventID,
+	}
+
+	const q = `
+	SELECT
+		event_id, domain, action, version, params, attempts, last_error, available_at, date_created, date_failed
+	FROM
+		delegate_dead_letters
+	WHERE
+		event_id = :event_id`
+
+	var dbDL dbDeadLetter
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbDL); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return delegate.DeadLetter{}, fmt.Errorf("namedquerystruct: %w", delegate.ErrNotFound)
+		}
+		return delegate.DeadLetter{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreDeadLetter(dbDL), nil
+}
+
+// Replay moves the dead letter back to the outbox, due now and with no
+// failed attempts.
+func (s *Store) Replay(ctx context.Context, eventID uuid.UUID, now time.Time) (delegate.Event, error) {
+	data := struct {
+		ID  uuid.UUID `db:"event_id"`
+		Now time.Time `db:"now"`
+	}{
+		ID:  eventID,
+		Now: now.UTC(),
+	}
+
+	const q = `
+	WITH moved AS (
+		DELETE FROM
+			delegate_dead_letters
+		WHERE
+			event_id = :event_id
+		RETURNING
+			event_id, domain, action, version, params, date_created
+	)
+	INSERT INTO delegate_outbox
+		(event_id, domain, action, version, params, attempts, last_error, available_at, date_created)
+	SELECT
+		event_id, domain, action, version, params, 0, '', :now, date_created
+	FROM
+		moved
+	RETURNING
+		event_id, domain, action, version, params, attempts, last_error, available_at, date_created`
+
+	var dbEv dbEvent
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbEv); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return delegate.Event{}, fmt.Errorf("namedquerystruct: %w", delegate.ErrNotFound)
+		}
+		return delegate.Event{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreEvent(dbEv), nil
+}
+
+func (s *Store) applyFilter(filter delegate.QueryFilter, data map[string]interface{}, buf *bytes.Buffer) {
+	var wc []string
+
+	if filter.Domain != nil {
+		data["domain"] = *filter.Domain
+		wc = append(wc, "domain = :domain")
+	}
+
+	if filter.Action != nil {
+		data["action"] = *filter.Action
+		wc = append(wc, "action = :action")
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
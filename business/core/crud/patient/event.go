@@ -7,8 +7,193 @@ import (
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
 
 	"github.com/go-json-experiment/json"
+	"github.com/google/uuid"
 )
 
+// Domain represents the name of this domain.
+const Domain = "patient"
+
+// Set of delegate actions.
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+	ActionHealed  = "healed"
+)
+
+// Set of versions of the action parameters. A version is incremented when
+// the parameters change in a way existing listeners can't decode.
+const (
+	ActionCreatedVersion = 1
+	ActionUpdatedVersion = 1
+	ActionDeletedVersion = 1
+	ActionHealedVersion  = 1
+)
+
+func init() {
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionCreated, Version: ActionCreatedVersion, Parms: ActionCreatedParms{}})
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionUpdated, Version: ActionUpdatedVersion, Parms: ActionUpdatedParms{}})
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionDeleted, Version: ActionDeletedVersion, Parms: ActionDeletedParms{}})
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionHealed, Version: ActionHealedVersion, Parms: ActionHealedParms{}})
+}
+
+// =============================================================================
+
+// ActionCreatedParms represents the parameters for the created action.
+type ActionCreatedParms struct {
+	PatientID uuid.UUID
+	After     Patient
+}
+
+// String returns a string representation of the action parameters.
+func (ac *ActionCreatedParms) String() string {
+	return fmt.Sprintf("&ActionCreatedParms{PatientID:%v, Version:%v}", ac.PatientID, ac.After.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (ac *ActionCreatedParms) Marshal() ([]byte, error) {
+	return json.Marshal(ac)
+}
+
+// ActionCreatedData constructs the data for the created action.
+func ActionCreatedData(pn Patient) delegate.Data {
+	params := ActionCreatedParms{
+		PatientID: pn.ID,
+		After:     pn,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionCreated,
+		Version:   ActionCreatedVersion,
+		RawParams: rawParams,
+	}
+}
+
+// =============================================================================
+
+// ActionUpdatedParms represents the parameters for the updated action.
+type ActionUpdatedParms struct {
+	PatientID uuid.UUID
+	Before    Patient
+	After     Patient
+}
+
+// String returns a string representation of the action parameters.
+func (au *ActionUpdatedParms) String() string {
+	return fmt.Sprintf("&ActionUpdatedParms{PatientID:%v, Version:%v}", au.PatientID, au.After.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (au *ActionUpdatedParms) Marshal() ([]byte, error) {
+	return json.Marshal(au)
+}
+
+// ActionUpdatedData constructs the data for the updated action.
+func ActionUpdatedData(before Patient, after Patient) delegate.Data {
+	params := ActionUpdatedParms{
+		PatientID: after.ID,
+		Before:    before,
+		After:     after,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionUpdated,
+		Version:   ActionUpdatedVersion,
+		RawParams: rawParams,
+	}
+}
+
+// =============================================================================
+
+// ActionDeletedParms represents the parameters for the deleted action.
+type ActionDeletedParms struct {
+	PatientID uuid.UUID
+	Before    Patient
+}
+
+// String returns a string representation of the action parameters.
+func (ad *ActionDeletedParms) String() string {
+	return fmt.Sprintf("&ActionDeletedParms{PatientID:%v, Version:%v}", ad.PatientID, ad.Before.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (ad *ActionDeletedParms) Marshal() ([]byte, error) {
+	return json.Marshal(ad)
+}
+
+// ActionDeletedData constructs the data for the deleted action.
+func ActionDeletedData(pn Patient) delegate.Data {
+	params := ActionDeletedParms{
+		PatientID: pn.ID,
+		Before:    pn,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionDeleted,
+		Version:   ActionDeletedVersion,
+		RawParams: rawParams,
+	}
+}
+
+// =============================================================================
+
+// ActionHealedParms represents the parameters for the healed action, which
+// follows the updated action when an update marks a patient healed.
+type ActionHealedParms struct {
+	PatientID uuid.UUID
+	Patient   Patient
+}
+
+// String returns a string representation of the action parameters.
+func (ah *ActionHealedParms) String() string {
+	return fmt.Sprintf("&ActionHealedParms{PatientID:%v, Version:%v}", ah.PatientID, ah.Patient.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (ah *ActionHealedParms) Marshal() ([]byte, error) {
+	return json.Marshal(ah)
+}
+
+// ActionHealedData constructs the data for the healed action.
+func ActionHealedData(pn Patient) delegate.Data {
+	params := ActionHealedParms{
+		PatientID: pn.ID,
+		Patient:   pn,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionHealed,
+		Version:   ActionHealedVersion,
+		RawParams: rawParams,
+	}
+}
+
+// =============================================================================
+
 // registerDelegateFunctions will register action functions with the delegate
 // system. If the core was constructed for query only, there won't be a
 // delegate provided.
@@ -20,13 +205,44 @@ func (c *Core) registerDelegateFunctions() {
 
 // actionUserUpdated is executed by the user domain indirectly when a user is updated.
 func (c *Core) actionUserUpdated(ctx context.Context, data delegate.Data) error {
-	var params user.ActionUpdatedParms
-	err := json.Unmarshal(data.RawParams, &params)
-	if err != nil {
-		return fmt.Errorf("expected an encoded %T: %w", params, err)
+	var userID uuid.UUID
+	var enabled bool
+
+	switch data.Version {
+	case 1:
+		// Events written to the outbox before version 2 only carry the
+		// fields of the update.
+		var params struct {
+			UserID  uuid.UUID
+			Enabled *bool
+		}
+		if err := json.Unmarshal(data.RawParams, &params); err != nil {
+			return fmt.Errorf("expected encoded version 1 parameters: %w", err)
+		}
+
+		if params.Enabled == nil {
+			return nil
+		}
+
+		userID, enabled = params.UserID, *params.Enabled
+
+	case user.ActionUpdatedVersion:
+		var params user.ActionUpdatedParms
+		if err := json.Unmarshal(data.RawParams, &params); err != nil {
+			return fmt.Errorf("expected an encoded %T: %w", params, err)
+		}
+
+		if params.Before.Enabled == params.After.Enabled {
+			return nil
+		}
+
+		userID, enabled = params.UserID, params.After.Enabled
+
+	default:
+		return fmt.Errorf("unsupported version %d of the %s.%s parameters", data.Version, data.Domain, data.Action)
 	}
 
-	c.log.Info(ctx, "action-userupdate", "user_id", params.UserID, "enabled", params.Enabled)
+	c.log.Info(ctx, "action-userupdate", "user_id", userID, "enabled", enabled)
 
 	// Now we can see if this user has been disabled. If they have been, we will
 	// want to disable to mark all these patients as deleted. Right now we don't
@@ -12,6 +12,7 @@ import (
 type QueryFilter struct {
 	ID         *uuid.UUID
 	UserID     *uuid.UUID
+	RegionID   *uuid.UUID
 	Name       *string `validate:"omitempty,min=3"`
 	Age        *int
 	Condition  *string
@@ -35,7 +36,13 @@ func (qf *QueryFilter) WithPatientID(patientID uuid.UUID) {
 
 // WithUserID sets the User ID field of the QueryFilter value.
 func (qf *QueryFilter) WithUserID(userID uuid.UUID) {
-	qf.ID = &userID
+	qf.UserID = &userID
+}
+
+// WithRegionID sets the RegionID field of the QueryFilter value. A patient
+// belongs to the region of the user responsible for them.
+func (qf *QueryFilter) WithRegionID(regionID uuid.UUID) {
+	qf.RegionID = &regionID
 }
 
 // WithName sets the Name field of the QueryFilter value.
@@ -10,19 +10,20 @@ import (
 type Patient struct {
 	ID          uuid.UUID
 	UserID      uuid.UUID
-	Name        string
+	Name        string `log:"redact"`
 	Age         int
 	VideoLinks  []string
 	Condition   string
 	Healed      bool
 	DateCreated time.Time
 	DateUpdated time.Time
+	Version     int
 }
 
 // NewPatient is what we require from clients when adding a Patient.
 type NewPatient struct {
 	UserID     uuid.UUID
-	Name       string
+	Name       string `log:"redact"`
 	Age        int
 	VideoLinks []string
 	Condition  string
@@ -36,7 +37,7 @@ type NewPatient struct {
 // explicitly blank. Normally we do not want to use pointers to basic types but
 // we make exceptions around marshalling/unmarshalling.
 type UpdatePatient struct {
-	Name       *string
+	Name       *string `log:"redact"`
 	Age        *int
 	VideoLinks []string
 	Condition  *string
@@ -1,6 +1,9 @@
 package patient
 
-import "github.com/fadhilijuma/gateone-service/business/web/v1/order"
+import (
+	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"strconv"
+)
 
 // DefaultOrderBy represents the default way we sort.
 var DefaultOrderBy = order.NewBy(OrderByPatientID, order.ASC)
@@ -14,3 +17,35 @@ const (
 	OrderByCondition = "condition"
 	OrderByHealed    = "healed"
 )
+
+// CursorValues returns the values of the order fields of the patient
+// followed by its ID, unless the order includes it, in the text form the
+// cursor paging queries compare them in.
+func CursorValues(pn Patient, orderBy order.By) []string {
+	values := make([]string, 0, len(orderBy.Fields)+1)
+
+	var hasID bool
+	for _, fld := range orderBy.Fields {
+		switch fld.Name {
+		case OrderByPatientID:
+			values = append(values, pn.ID.String())
+			hasID = true
+		case OrderByUserID:
+			values = append(values, pn.UserID.String())
+		case OrderByName:
+			values = append(values, pn.Name)
+		case OrderByAge:
+			values = append(values, strconv.Itoa(pn.Age))
+		case OrderByCondition:
+			values = append(values, pn.Condition)
+		case OrderByHealed:
+			values = append(values, strconv.FormatBool(pn.Healed))
+		}
+	}
+
+	if !hasID {
+		values = append(values, pn.ID.String())
+	}
+
+	return values
+}
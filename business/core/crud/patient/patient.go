@@ -11,6 +11,7 @@ import (
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
 	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"time"
 
@@ -19,9 +20,10 @@ import (
 
 // Set of error variables for CRUD operations.
 var (
-	ErrNotFound     = errors.New("patient not found")
-	ErrUserDisabled = errors.New("user disabled")
-	ErrInvalidCost  = errors.New("cost not valid")
+	ErrNotFound        = errors.New("patient not found")
+	ErrUserDisabled    = errors.New("user disabled")
+	ErrInvalidCost     = errors.New("cost not valid")
+	ErrVersionConflict = errors.New("patient was changed by another request")
 )
 
 // Storer interface declares the behavior this package needs to persists and
@@ -32,6 +34,7 @@ type Storer interface {
 	Update(ctx context.Context, pn Patient) error
 	Delete(ctx context.Context, pn Patient) error
 	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Patient, error)
+	QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, rows int) ([]Patient, error)
 	Count(ctx context.Context, filter QueryFilter) (int, error)
 	QueryByID(ctx context.Context, pnID uuid.UUID) (Patient, error)
 	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Patient, error)
@@ -72,10 +75,15 @@ func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error
 		return nil, err
 	}
 
+	dlg, err := c.delegate.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	core := Core{
 		log:      c.log,
 		usrCore:  usrCore,
-		delegate: c.delegate,
+		delegate: dlg,
 		storer:   storer,
 	}
 
@@ -105,17 +113,24 @@ func (c *Core) Create(ctx context.Context, np NewPatient) (Patient, error) {
 		UserID:      np.UserID,
 		DateCreated: now,
 		DateUpdated: now,
+		Version:     1,
 	}
 
 	if err := c.storer.Create(ctx, prd); err != nil {
 		return Patient{}, fmt.Errorf("create: %w", err)
 	}
 
+	if err := c.delegate.Call(ctx, ActionCreatedData(prd)); err != nil {
+		return Patient{}, fmt.Errorf("failed to execute `%s` action: %w", ActionCreated, err)
+	}
+
 	return prd, nil
 }
 
 // Update modifies information about a patient.
 func (c *Core) Update(ctx context.Context, pn Patient, up UpdatePatient) (Patient, error) {
+	before := pn
+
 	if up.Name != nil {
 		pn.Name = *up.Name
 	}
@@ -140,6 +155,18 @@ func (c *Core) Update(ctx context.Context, pn Patient, up UpdatePatient) (Patien
 		return Patient{}, fmt.Errorf("update: %w", err)
 	}
 
+	pn.Version++
+
+	if err := c.delegate.Call(ctx, ActionUpdatedData(before, pn)); err != nil {
+		return Patient{}, fmt.Errorf("failed to execute `%s` action: %w", ActionUpdated, err)
+	}
+
+	if !before.Healed && pn.Healed {
+		if err := c.delegate.Call(ctx, ActionHealedData(pn)); err != nil {
+			return Patient{}, fmt.Errorf("failed to execute `%s` action: %w", ActionHealed, err)
+		}
+	}
+
 	return pn, nil
 }
 
@@ -149,6 +176,10 @@ func (c *Core) Delete(ctx context.Context, prd Patient) error {
 		return fmt.Errorf("delete: %w", err)
 	}
 
+	if err := c.delegate.Call(ctx, ActionDeletedData(prd)); err != nil {
+		return fmt.Errorf("failed to execute `%s` action: %w", ActionDeleted, err)
+	}
+
 	return nil
 }
 
@@ -166,6 +197,21 @@ func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By,
 	return prds, nil
 }
 
+// QueryByCursor retrieves a list of existing patients positioned after the
+// cursor, or before it for a backward cursor, in list order.
+func (c *Core) QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, rows int) ([]Patient, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	prds, err := c.storer.QueryByCursor(ctx, filter, orderBy, cursor, rows)
+	if err != nil {
+		return nil, fmt.Errorf("querybycursor: %w", err)
+	}
+
+	return prds, nil
+}
+
 // Count returns the total number of patients.
 func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
 	if err := filter.Validate(); err != nil {
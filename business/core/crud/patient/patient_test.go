@@ -9,6 +9,8 @@ import (
 	"github.com/fadhilijuma/gateone-service/business/data/dbtest"
 	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
 	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 	"github.com/fadhilijuma/gateone-service/foundation/docker"
 	"net/mail"
 	"os"
@@ -299,6 +301,52 @@ func paging(t *testing.T) {
 		t.Logf("patient2: %v", prd3[1].ID)
 		t.Fatalf("Should have different patient")
 	}
+
+	orderBy := order.NewBy(patient.OrderByCondition, order.ASC).Then(patient.OrderByName, order.DESC)
+
+	prd4, err := api.Patient.Query(ctx, patient.QueryFilter{}, orderBy, 1, 2)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve patients ordered by %s : %s", orderBy, err)
+	}
+
+	if len(prd4) != 2 || prd4[0].Condition > prd4[1].Condition ||
+		prd4[0].Condition == prd4[1].Condition && prd4[0].Name < prd4[1].Name {
+		t.Logf("got: %v", prd4)
+		t.Fatalf("Should have patients ordered by %s", orderBy)
+	}
+
+	// -------------------------------------------------------------------------
+
+	orderBy = order.NewBy(patient.OrderByName, order.ASC)
+
+	first, err := api.Patient.QueryByCursor(ctx, patient.QueryFilter{}, orderBy, page.Cursor{}, 1)
+	if err != nil || len(first) != 1 {
+		t.Fatalf("Should be able to retrieve the first patient by cursor : %v : %s", first, err)
+	}
+
+	cur := page.Cursor{Values: patient.CursorValues(first[0], orderBy)}
+	second, err := api.Patient.QueryByCursor(ctx, patient.QueryFilter{}, orderBy, cur, 1)
+	if err != nil || len(second) != 1 {
+		t.Fatalf("Should be able to retrieve the next patient by cursor : %v : %s", second, err)
+	}
+
+	if second[0].ID == first[0].ID || second[0].Name < first[0].Name {
+		t.Logf("patient1: %v", first[0])
+		t.Logf("patient2: %v", second[0])
+		t.Fatalf("Should get the patient after the cursor")
+	}
+
+	cur = page.Cursor{Values: patient.CursorValues(second[0], orderBy), Backward: true}
+	back, err := api.Patient.QueryByCursor(ctx, patient.QueryFilter{}, orderBy, cur, 1)
+	if err != nil || len(back) != 1 {
+		t.Fatalf("Should be able to retrieve the previous patient by cursor : %v : %s", back, err)
+	}
+
+	if back[0].ID != first[0].ID {
+		t.Logf("got: %v", back[0].ID)
+		t.Logf("exp: %v", first[0].ID)
+		t.Fatalf("Should get the patient before the cursor")
+	}
 }
 
 func tran(t *testing.T) {
@@ -15,6 +15,16 @@ func (s *Store) applyFilter(filter patient.QueryFilter, data map[string]interfac
 		wc = append(wc, "patient_id = :patient_id")
 	}
 
+	if filter.UserID != nil {
+		data["user_id"] = *filter.UserID
+		wc = append(wc, "user_id = :user_id")
+	}
+
+	if filter.RegionID != nil {
+		data["region_id"] = *filter.RegionID
+		wc = append(wc, "user_id IN (SELECT user_id FROM users WHERE region_id = :region_id)")
+	}
+
 	if filter.Name != nil {
 		data["name"] = fmt.Sprintf("%%%s%%", *filter.Name)
 		wc = append(wc, "name LIKE :name")
@@ -10,13 +10,14 @@ import (
 type dbPatient struct {
 	ID          uuid.UUID `db:"patient_id"`
 	UserID      uuid.UUID `db:"user_id"`
-	Name        string    `db:"name"`
+	Name        string    `db:"name" log:"redact"`
 	Age         int       `db:"age"`
 	Condition   string    `db:"condition"`
 	Healed      bool      `db:"healed"`
 	VideoLinks  []string  `db:"video_links"`
 	DateCreated time.Time `db:"date_created"`
 	DateUpdated time.Time `db:"date_updated"`
+	Version     int       `db:"version"`
 }
 
 func toDBPatient(pn patient.Patient) dbPatient {
@@ -30,6 +31,7 @@ func toDBPatient(pn patient.Patient) dbPatient {
 		VideoLinks:  pn.VideoLinks,
 		DateCreated: pn.DateCreated.UTC(),
 		DateUpdated: pn.DateUpdated.UTC(),
+		Version:     pn.Version,
 	}
 
 	return prdDB
@@ -46,6 +48,7 @@ func toCorePatient(dbPn dbPatient) patient.Patient {
 		VideoLinks:  dbPn.VideoLinks,
 		DateCreated: dbPn.DateCreated.In(time.Local),
 		DateUpdated: dbPn.DateUpdated.In(time.Local),
+		Version:     dbPn.Version,
 	}
 
 	return prd
@@ -10,7 +10,9 @@ import (
 	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
 	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"slices"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -51,9 +53,9 @@ func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (patient.Sto
 func (s *Store) Create(ctx context.Context, prd patient.Patient) error {
 	const q = `
 	INSERT INTO patients
-		(patient_id, user_id, name, age, condition, healed,video_links, date_created, date_updated)
+		(patient_id, user_id, name, age, condition, healed,video_links, date_created, date_updated, version)
 	VALUES
-		(:patient_id, :user_id, :name, :age, :condition, :healed,:video_links, :date_created, :date_updated)`
+		(:patient_id, :user_id, :name, :age, :condition, :healed,:video_links, :date_created, :date_updated, :version)`
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBPatient(prd)); err != nil {
 		return fmt.Errorf("namedexeccontext: %w", err)
@@ -74,12 +76,22 @@ func (s *Store) Update(ctx context.Context, prd patient.Patient) error {
 		"condition" = :condition,
 		"healed" = :healed,
 		"video_links" = :video_links,
-		"date_updated" = :date_updated
+		"date_updated" = :date_updated,
+		"version" = "version" + 1
 	WHERE
-		patient_id = :patient_id`
+		patient_id = :patient_id AND version = :version
+	RETURNING
+		version`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBPatient(prd)); err != nil {
-		return fmt.Errorf("namedexeccontext: %w", err)
+	var dest struct {
+		Version int `db:"version"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, toDBPatient(prd), &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", patient.ErrVersionConflict)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
 	}
 
 	return nil
@@ -88,19 +100,30 @@ func (s *Store) Update(ctx context.Context, prd patient.Patient) error {
 // Delete removes the patient identified by a given ID.
 func (s *Store) Delete(ctx context.Context, prd patient.Patient) error {
 	data := struct {
-		ID string `db:"patient_id"`
+		ID      string `db:"patient_id"`
+		Version int    `db:"version"`
 	}{
-		ID: prd.ID.String(),
+		ID:      prd.ID.String(),
+		Version: prd.Version,
 	}
 
 	const q = `
 	DELETE FROM
 		patients
 	WHERE
-		patient_id = :patient_id`
+		patient_id = :patient_id AND version = :version
+	RETURNING
+		patient_id`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
-		return fmt.Errorf("namedexeccontext: %w", err)
+	var dest struct {
+		ID string `db:"patient_id"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", patient.ErrVersionConflict)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
 	}
 
 	return nil
@@ -115,7 +138,7 @@ func (s *Store) Query(ctx context.Context, filter patient.QueryFilter, orderBy o
 
 	const q = `
 	SELECT
-	    patient_id, user_id, name, age, condition, healed, video_links, date_created, date_updated
+	    patient_id, user_id, name, age, condition, healed, video_links, date_created, date_updated, version
 	FROM
 		patients`
 
@@ -138,6 +161,57 @@ func (s *Store) Query(ctx context.Context, filter patient.QueryFilter, orderBy o
 	return toCorePatients(dbPrds), nil
 }
 
+// QueryByCursor retrieves the patients positioned after the cursor from the
+// database, or before it for a backward cursor, in list order.
+func (s *Store) QueryByCursor(ctx context.Context, filter patient.QueryFilter, orderBy order.By, cursor page.Cursor, rows int) ([]patient.Patient, error) {
+	data := map[string]interface{}{
+		"rows_per_page": rows,
+	}
+
+	const q = `
+	SELECT
+	    patient_id, user_id, name, age, condition, healed, video_links, date_created, date_updated, version
+	FROM
+		patients`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf)
+
+	ks, err := keyset(orderBy, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	wc, err := ks.Where(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if wc != "" {
+		switch {
+		case buf.Len() > len(q):
+			buf.WriteString(" AND ")
+		default:
+			buf.WriteString(" WHERE ")
+		}
+		buf.WriteString(wc)
+	}
+
+	buf.WriteString(ks.OrderBy())
+	buf.WriteString(" FETCH FIRST :rows_per_page ROWS ONLY")
+
+	var dbPrds []dbPatient
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbPrds); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	if cursor.Backward {
+		slices.Reverse(dbPrds)
+	}
+
+	return toCorePatients(dbPrds), nil
+}
+
 // Count returns the total number of Patients in the DB.
 func (s *Store) Count(ctx context.Context, filter patient.QueryFilter) (int, error) {
 	data := map[string]interface{}{}
@@ -172,7 +246,7 @@ func (s *Store) QueryByID(ctx context.Context, patientID uuid.UUID) (patient.Pat
 
 	const q = `
 	SELECT
-	    patient_id, user_id, name, age, condition, healed, video_links, date_created, date_updated
+	    patient_id, user_id, name, age, condition, healed, video_links, date_created, date_updated, version
 	FROM
 		patients
 	WHERE
@@ -199,7 +273,7 @@ func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]patient.
 
 	const q = `
 	SELECT
-	    patient_id, user_id, name, age, condition, healed, video_links, date_created, date_updated
+	    patient_id, user_id, name, age, condition, healed, video_links, date_created, date_updated, version
 	FROM
 		patients
 	WHERE
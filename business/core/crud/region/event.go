@@ -1 +1,149 @@
 package region
+
+import (
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+
+	"github.com/go-json-experiment/json"
+	"github.com/google/uuid"
+)
+
+// Domain represents the name of this domain.
+const Domain = "region"
+
+// Set of delegate actions.
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
+// Set of versions of the action parameters. A version is incremented when
+// the parameters change in a way existing listeners can't decode.
+const (
+	ActionCreatedVersion = 1
+	ActionUpdatedVersion = 1
+	ActionDeletedVersion = 1
+)
+
+func init() {
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionCreated, Version: ActionCreatedVersion, Parms: ActionCreatedParms{}})
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionUpdated, Version: ActionUpdatedVersion, Parms: ActionUpdatedParms{}})
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionDeleted, Version: ActionDeletedVersion, Parms: ActionDeletedParms{}})
+}
+
+// =============================================================================
+
+// ActionCreatedParms represents the parameters for the created action.
+type ActionCreatedParms struct {
+	RegionID uuid.UUID
+	After    Region
+}
+
+// String returns a string representation of the action parameters.
+func (ac *ActionCreatedParms) String() string {
+	return fmt.Sprintf("&ActionCreatedParms{RegionID:%v, Version:%v}", ac.RegionID, ac.After.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (ac *ActionCreatedParms) Marshal() ([]byte, error) {
+	return json.Marshal(ac)
+}
+
+// ActionCreatedData constructs the data for the created action.
+func ActionCreatedData(rn Region) delegate.Data {
+	params := ActionCreatedParms{
+		RegionID: rn.ID,
+		After:    rn,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionCreated,
+		Version:   ActionCreatedVersion,
+		RawParams: rawParams,
+	}
+}
+
+// =============================================================================
+
+// ActionUpdatedParms represents the parameters for the updated action.
+type ActionUpdatedParms struct {
+	RegionID uuid.UUID
+	Before   Region
+	After    Region
+}
+
+// String returns a string representation of the action parameters.
+func (au *ActionUpdatedParms) String() string {
+	return fmt.Sprintf("&ActionUpdatedParms{RegionID:%v, Version:%v}", au.RegionID, au.After.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (au *ActionUpdatedParms) Marshal() ([]byte, error) {
+	return json.Marshal(au)
+}
+
+// ActionUpdatedData constructs the data for the updated action.
+func ActionUpdatedData(before Region, after Region) delegate.Data {
+	params := ActionUpdatedParms{
+		RegionID: after.ID,
+		Before:   before,
+		After:    after,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionUpdated,
+		Version:   ActionUpdatedVersion,
+		RawParams: rawParams,
+	}
+}
+
+// =============================================================================
+
+// ActionDeletedParms represents the parameters for the deleted action.
+type ActionDeletedParms struct {
+	RegionID uuid.UUID
+	Before   Region
+}
+
+// String returns a string representation of the action parameters.
+func (ad *ActionDeletedParms) String() string {
+	return fmt.Sprintf("&ActionDeletedParms{RegionID:%v, Version:%v}", ad.RegionID, ad.Before.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (ad *ActionDeletedParms) Marshal() ([]byte, error) {
+	return json.Marshal(ad)
+}
+
+// ActionDeletedData constructs the data for the deleted action.
+func ActionDeletedData(rn Region) delegate.Data {
+	params := ActionDeletedParms{
+		RegionID: rn.ID,
+		Before:   rn,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionDeleted,
+		Version:   ActionDeletedVersion,
+		RawParams: rawParams,
+	}
+}
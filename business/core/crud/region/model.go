@@ -13,6 +13,7 @@ type Region struct {
 	Name        string
 	DateCreated time.Time
 	DateUpdated time.Time
+	Version     int
 }
 
 // NewRegion is what we require from clients when adding a Region.
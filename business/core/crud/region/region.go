@@ -9,6 +9,7 @@ import (
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
 	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"time"
 
@@ -17,8 +18,9 @@ import (
 
 // Set of error variables for CRUD operations.
 var (
-	ErrNotFound     = errors.New("region not found")
-	ErrUserDisabled = errors.New("user disabled")
+	ErrNotFound        = errors.New("region not found")
+	ErrUserDisabled    = errors.New("user disabled")
+	ErrVersionConflict = errors.New("region was changed by another request")
 )
 
 // Storer interface declares the behaviour this package needs to persist and
@@ -29,6 +31,7 @@ type Storer interface {
 	Update(ctx context.Context, rn Region) error
 	Delete(ctx context.Context, rn Region) error
 	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Region, error)
+	QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, rows int) ([]Region, error)
 	Count(ctx context.Context, filter QueryFilter) (int, error)
 	QueryByID(ctx context.Context, RegionID uuid.UUID) (Region, error)
 	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Region, error)
@@ -65,10 +68,15 @@ func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error
 		return nil, err
 	}
 
+	dlg, err := c.delegate.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	core := Core{
 		log:      c.log,
 		usrCore:  usrCore,
-		delegate: c.delegate,
+		delegate: dlg,
 		storer:   storer,
 	}
 
@@ -94,17 +102,24 @@ func (c *Core) Create(ctx context.Context, nr NewRegion) (Region, error) {
 		UserID:      nr.UserID,
 		DateCreated: now,
 		DateUpdated: now,
+		Version:     1,
 	}
 
 	if err := c.storer.Create(ctx, hme); err != nil {
 		return Region{}, fmt.Errorf("create: %w", err)
 	}
 
+	if err := c.delegate.Call(ctx, ActionCreatedData(hme)); err != nil {
+		return Region{}, fmt.Errorf("failed to execute `%s` action: %w", ActionCreated, err)
+	}
+
 	return hme, nil
 }
 
 // Update modifies information about a Region.
 func (c *Core) Update(ctx context.Context, rn Region, ur UpdateRegion) (Region, error) {
+	before := rn
+
 	if ur.Name != nil {
 		rn.Name = *ur.Name
 	}
@@ -115,6 +130,12 @@ func (c *Core) Update(ctx context.Context, rn Region, ur UpdateRegion) (Region,
 		return Region{}, fmt.Errorf("update: %w", err)
 	}
 
+	rn.Version++
+
+	if err := c.delegate.Call(ctx, ActionUpdatedData(before, rn)); err != nil {
+		return Region{}, fmt.Errorf("failed to execute `%s` action: %w", ActionUpdated, err)
+	}
+
 	return rn, nil
 }
 
@@ -124,6 +145,10 @@ func (c *Core) Delete(ctx context.Context, cn Region) error {
 		return fmt.Errorf("delete: %w", err)
 	}
 
+	if err := c.delegate.Call(ctx, ActionDeletedData(cn)); err != nil {
+		return fmt.Errorf("failed to execute `%s` action: %w", ActionDeleted, err)
+	}
+
 	return nil
 }
 
@@ -141,6 +166,21 @@ func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By,
 	return hmes, nil
 }
 
+// QueryByCursor retrieves a list of existing regions positioned after the
+// cursor, or before it for a backward cursor, in list order.
+func (c *Core) QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, rows int) ([]Region, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	rgns, err := c.storer.QueryByCursor(ctx, filter, orderBy, cursor, rows)
+	if err != nil {
+		return nil, fmt.Errorf("querybycursor: %w", err)
+	}
+
+	return rgns, nil
+}
+
 // Count returns the total number of Regions.
 func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
 	if err := filter.Validate(); err != nil {
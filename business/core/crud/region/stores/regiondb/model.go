@@ -14,6 +14,7 @@ type dbRegion struct {
 	Name        string    `db:"name"`
 	DateCreated time.Time `db:"date_created"`
 	DateUpdated time.Time `db:"date_updated"`
+	Version     int       `db:"version"`
 }
 
 func toDBRegion(rl region.Region) dbRegion {
@@ -23,6 +24,7 @@ func toDBRegion(rl region.Region) dbRegion {
 		Name:        rl.Name,
 		DateCreated: rl.DateCreated.UTC(),
 		DateUpdated: rl.DateUpdated.UTC(),
+		Version:     rl.Version,
 	}
 
 	return rlDB
@@ -35,6 +37,7 @@ func toCoreRegion(dbCn dbRegion) (region.Region, error) {
 		Name:        dbCn.Name,
 		DateCreated: dbCn.DateCreated.In(time.Local),
 		DateUpdated: dbCn.DateUpdated.In(time.Local),
+		Version:     dbCn.Version,
 	}
 
 	return rl, nil
@@ -10,7 +10,9 @@ import (
 	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
 	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"slices"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -50,9 +52,9 @@ func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (region.Stor
 func (s *Store) Create(ctx context.Context, rn region.Region) error {
 	const q = `
     INSERT INTO regions
-        (region_id, user_id, name, date_created, date_updated)
+        (region_id, user_id, name, date_created, date_updated, version)
     VALUES
-        (:region_id, :user_id, :name, :date_created, :date_updated)`
+        (:region_id, :user_id, :name, :date_created, :date_updated, :version)`
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBRegion(rn)); err != nil {
 		return fmt.Errorf("namedexeccontext: %w", err)
@@ -64,19 +66,30 @@ func (s *Store) Create(ctx context.Context, rn region.Region) error {
 // Delete removes a Region from the database.
 func (s *Store) Delete(ctx context.Context, rn region.Region) error {
 	data := struct {
-		ID string `db:"region_id"`
+		ID      string `db:"region_id"`
+		Version int    `db:"version"`
 	}{
-		ID: rn.ID.String(),
+		ID:      rn.ID.String(),
+		Version: rn.Version,
 	}
 
 	const q = `
     DELETE FROM
 	    regions
 	WHERE
-	  	region_id = :region_id`
+	  	region_id = :region_id AND version = :version
+	RETURNING
+	  	region_id`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
-		return fmt.Errorf("namedexeccontext: %w", err)
+	var dest struct {
+		ID string `db:"region_id"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", region.ErrVersionConflict)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
 	}
 
 	return nil
@@ -89,12 +102,22 @@ func (s *Store) Update(ctx context.Context, rn region.Region) error {
         regions
     SET
         "name"          = :name,
-        "date_updated"  = :date_updated
+        "date_updated"  = :date_updated,
+        "version"       = "version" + 1
     WHERE
-        region_id = :Region_id`
+        region_id = :region_id AND version = :version
+    RETURNING
+        version`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBRegion(rn)); err != nil {
-		return fmt.Errorf("namedexeccontext: %w", err)
+	var dest struct {
+		Version int `db:"version"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, toDBRegion(rn), &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", region.ErrVersionConflict)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
 	}
 
 	return nil
@@ -109,7 +132,7 @@ func (s *Store) Query(ctx context.Context, filter region.QueryFilter, orderBy or
 
 	const q = `
     SELECT
-	    region_id, user_id, name, date_created, date_updated
+	    region_id, user_id, name, date_created, date_updated, version
 	FROM
 	  	regions`
 
@@ -137,6 +160,62 @@ func (s *Store) Query(ctx context.Context, filter region.QueryFilter, orderBy or
 	return Regions, nil
 }
 
+// QueryByCursor retrieves the Regions positioned after the cursor from the
+// database, or before it for a backward cursor, in list order.
+func (s *Store) QueryByCursor(ctx context.Context, filter region.QueryFilter, orderBy order.By, cursor page.Cursor, rows int) ([]region.Region, error) {
+	data := map[string]interface{}{
+		"rows_per_page": rows,
+	}
+
+	const q = `
+    SELECT
+	    region_id, user_id, name, date_created, date_updated, version
+	FROM
+	  	regions`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf)
+
+	ks, err := keyset(orderBy, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	wc, err := ks.Where(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if wc != "" {
+		switch {
+		case buf.Len() > len(q):
+			buf.WriteString(" AND ")
+		default:
+			buf.WriteString(" WHERE ")
+		}
+		buf.WriteString(wc)
+	}
+
+	buf.WriteString(ks.OrderBy())
+	buf.WriteString(" FETCH FIRST :rows_per_page ROWS ONLY")
+
+	var dbRegions []dbRegion
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbRegions); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	if cursor.Backward {
+		slices.Reverse(dbRegions)
+	}
+
+	Regions, err := toCoreRegionsSlice(dbRegions)
+	if err != nil {
+		return nil, err
+	}
+
+	return Regions, nil
+}
+
 // Count returns the total number of Regions in the DB.
 func (s *Store) Count(ctx context.Context, filter region.QueryFilter) (int, error) {
 	data := map[string]interface{}{}
@@ -170,7 +249,7 @@ func (s *Store) QueryByID(ctx context.Context, RegionID uuid.UUID) (region.Regio
 
 	const q = `
     SELECT
-	  	region_id, user_id, name, date_created, date_updated
+	  	region_id, user_id, name, date_created, date_updated, version
     FROM
         regions
     WHERE
@@ -197,7 +276,7 @@ func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]region.R
 
 	const q = `
 	SELECT
-	    region_id, user_id, name, date_created, date_updated
+	    region_id, user_id, name, date_created, date_updated, version
 	FROM
 		regions
 	WHERE
@@ -1 +1,149 @@
 package role
+
+import (
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+
+	"github.com/go-json-experiment/json"
+	"github.com/google/uuid"
+)
+
+// Domain represents the name of this domain.
+const Domain = "role"
+
+// Set of delegate actions.
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
+// Set of versions of the action parameters. A version is incremented when
+// the parameters change in a way existing listeners can't decode.
+const (
+	ActionCreatedVersion = 1
+	ActionUpdatedVersion = 1
+	ActionDeletedVersion = 1
+)
+
+func init() {
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionCreated, Version: ActionCreatedVersion, Parms: ActionCreatedParms{}})
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionUpdated, Version: ActionUpdatedVersion, Parms: ActionUpdatedParms{}})
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionDeleted, Version: ActionDeletedVersion, Parms: ActionDeletedParms{}})
+}
+
+// =============================================================================
+
+// ActionCreatedParms represents the parameters for the created action.
+type ActionCreatedParms struct {
+	RoleID uuid.UUID
+	After  Role
+}
+
+// String returns a string representation of the action parameters.
+func (ac *ActionCreatedParms) String() string {
+	return fmt.Sprintf("&ActionCreatedParms{RoleID:%v, Version:%v}", ac.RoleID, ac.After.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (ac *ActionCreatedParms) Marshal() ([]byte, error) {
+	return json.Marshal(ac)
+}
+
+// ActionCreatedData constructs the data for the created action.
+func ActionCreatedData(rl Role) delegate.Data {
+	params := ActionCreatedParms{
+		RoleID: rl.ID,
+		After:  rl,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionCreated,
+		Version:   ActionCreatedVersion,
+		RawParams: rawParams,
+	}
+}
+
+// =============================================================================
+
+// ActionUpdatedParms represents the parameters for the updated action.
+type ActionUpdatedParms struct {
+	RoleID uuid.UUID
+	Before Role
+	After  Role
+}
+
+// String returns a string representation of the action parameters.
+func (au *ActionUpdatedParms) String() string {
+	return fmt.Sprintf("&ActionUpdatedParms{RoleID:%v, Version:%v}", au.RoleID, au.After.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (au *ActionUpdatedParms) Marshal() ([]byte, error) {
+	return json.Marshal(au)
+}
+
+// ActionUpdatedData constructs the data for the updated action.
+func ActionUpdatedData(before Role, after Role) delegate.Data {
+	params := ActionUpdatedParms{
+		RoleID: after.ID,
+		Before: before,
+		After:  after,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionUpdated,
+		Version:   ActionUpdatedVersion,
+		RawParams: rawParams,
+	}
+}
+
+// =============================================================================
+
+// ActionDeletedParms represents the parameters for the deleted action.
+type ActionDeletedParms struct {
+	RoleID uuid.UUID
+	Before Role
+}
+
+// String returns a string representation of the action parameters.
+func (ad *ActionDeletedParms) String() string {
+	return fmt.Sprintf("&ActionDeletedParms{RoleID:%v, Version:%v}", ad.RoleID, ad.Before.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (ad *ActionDeletedParms) Marshal() ([]byte, error) {
+	return json.Marshal(ad)
+}
+
+// ActionDeletedData constructs the data for the deleted action.
+func ActionDeletedData(rl Role) delegate.Data {
+	params := ActionDeletedParms{
+		RoleID: rl.ID,
+		Before: rl,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionDeleted,
+		Version:   ActionDeletedVersion,
+		RawParams: rawParams,
+	}
+}
@@ -13,6 +13,7 @@ type Role struct {
 	Name        string
 	DateCreated time.Time
 	DateUpdated time.Time
+	Version     int
 }
 
 // NewRole is what we require from clients when adding a Role.
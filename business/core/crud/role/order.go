@@ -11,3 +11,29 @@ const (
 	OrderByName   = "name"
 	OrderByUserID = "user_id"
 )
+
+// CursorValues returns the values of the order fields of the role
+// followed by its ID, unless the order includes it, in the text form the
+// cursor paging queries compare them in.
+func CursorValues(rl Role, orderBy order.By) []string {
+	values := make([]string, 0, len(orderBy.Fields)+1)
+
+	var hasID bool
+	for _, fld := range orderBy.Fields {
+		switch fld.Name {
+		case OrderByID:
+			values = append(values, rl.ID.String())
+			hasID = true
+		case OrderByName:
+			values = append(values, rl.Name)
+		case OrderByUserID:
+			values = append(values, rl.UserID.String())
+		}
+	}
+
+	if !hasID {
+		values = append(values, rl.ID.String())
+	}
+
+	return values
+}
@@ -14,6 +14,7 @@ type dbRole struct {
 	Name        string    `db:"name"`
 	DateCreated time.Time `db:"date_created"`
 	DateUpdated time.Time `db:"date_updated"`
+	Version     int       `db:"version"`
 }
 
 func toDBRole(rl role.Role) dbRole {
@@ -23,6 +24,7 @@ func toDBRole(rl role.Role) dbRole {
 		Name:        rl.Name,
 		DateCreated: rl.DateCreated.UTC(),
 		DateUpdated: rl.DateUpdated.UTC(),
+		Version:     rl.Version,
 	}
 
 	return rlDB
@@ -35,6 +37,7 @@ func toCoreRole(dbRl dbRole) (role.Role, error) {
 		Name:        dbRl.Name,
 		DateCreated: dbRl.DateCreated.In(time.Local),
 		DateUpdated: dbRl.DateUpdated.In(time.Local),
+		Version:     dbRl.Version,
 	}
 
 	return rl, nil
@@ -10,7 +10,9 @@ import (
 	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
 	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"slices"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -50,9 +52,9 @@ func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (role.Storer
 func (s *Store) Create(ctx context.Context, rl role.Role) error {
 	const q = `
     INSERT INTO roles
-        (role_id, user_id, name, date_created, date_updated)
+        (role_id, user_id, name, date_created, date_updated, version)
     VALUES
-        (:role_id, :user_id, :name, :date_created, :date_updated)`
+        (:role_id, :user_id, :name, :date_created, :date_updated, :version)`
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBRole(rl)); err != nil {
 		return fmt.Errorf("namedexeccontext: %w", err)
@@ -62,21 +64,32 @@ func (s *Store) Create(ctx context.Context, rl role.Role) error {
 }
 
 // Delete removes a role from the database.
-func (s *Store) Delete(ctx context.Context, role role.Role) error {
+func (s *Store) Delete(ctx context.Context, rl role.Role) error {
 	data := struct {
-		ID string `db:"role_id"`
+		ID      string `db:"role_id"`
+		Version int    `db:"version"`
 	}{
-		ID: role.ID.String(),
+		ID:      rl.ID.String(),
+		Version: rl.Version,
 	}
 
 	const q = `
     DELETE FROM
 	    roles
 	WHERE
-	  	role_id = :role_id`
+	  	role_id = :role_id AND version = :version
+	RETURNING
+	  	role_id`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
-		return fmt.Errorf("namedexeccontext: %w", err)
+	var dest struct {
+		ID string `db:"role_id"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", role.ErrVersionConflict)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
 	}
 
 	return nil
@@ -89,12 +102,22 @@ func (s *Store) Update(ctx context.Context, rl role.Role) error {
         roles
     SET
         "name"          = :name,
-        "date_updated"  = :date_updated
+        "date_updated"  = :date_updated,
+        "version"       = "version" + 1
     WHERE
-        role_id = :role_id`
+        role_id = :role_id AND version = :version
+    RETURNING
+        version`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBRole(rl)); err != nil {
-		return fmt.Errorf("namedexeccontext: %w", err)
+	var dest struct {
+		Version int `db:"version"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, toDBRole(rl), &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", role.ErrVersionConflict)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
 	}
 
 	return nil
@@ -109,7 +132,7 @@ func (s *Store) Query(ctx context.Context, filter role.QueryFilter, orderBy orde
 
 	const q = `
     SELECT
-	    role_id, user_id, name, date_created, date_updated
+	    role_id, user_id, name, date_created, date_updated, version
 	FROM
 	  	roles`
 
@@ -137,6 +160,62 @@ func (s *Store) Query(ctx context.Context, filter role.QueryFilter, orderBy orde
 	return roles, nil
 }
 
+// QueryByCursor retrieves the roles positioned after the cursor from the
+// database, or before it for a backward cursor, in list order.
+func (s *Store) QueryByCursor(ctx context.Context, filter role.QueryFilter, orderBy order.By, cursor page.Cursor, rows int) ([]role.Role, error) {
+	data := map[string]interface{}{
+		"rows_per_page": rows,
+	}
+
+	const q = `
+    SELECT
+	    role_id, user_id, name, date_created, date_updated, version
+	FROM
+	  	roles`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf)
+
+	ks, err := keyset(orderBy, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	wc, err := ks.Where(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if wc != "" {
+		switch {
+		case buf.Len() > len(q):
+			buf.WriteString(" AND ")
+		default:
+			buf.WriteString(" WHERE ")
+		}
+		buf.WriteString(wc)
+	}
+
+	buf.WriteString(ks.OrderBy())
+	buf.WriteString(" FETCH FIRST :rows_per_page ROWS ONLY")
+
+	var dbRoles []dbRole
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbRoles); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	if cursor.Backward {
+		slices.Reverse(dbRoles)
+	}
+
+	roles, err := toCoreRolesSlice(dbRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
 // Count returns the total number of roles in the DB.
 func (s *Store) Count(ctx context.Context, filter role.QueryFilter) (int, error) {
 	data := map[string]interface{}{}
@@ -170,7 +249,7 @@ func (s *Store) QueryByID(ctx context.Context, roleID uuid.UUID) (role.Role, err
 
 	const q = `
     SELECT
-	  	role_id, user_id, name, date_created, date_updated
+	  	role_id, user_id, name, date_created, date_updated, version
     FROM
         roles
     WHERE
@@ -197,7 +276,7 @@ func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]role.Rol
 
 	const q = `
 	SELECT
-	    role_id, user_id, name, date_created, date_updated
+	    role_id, user_id, name, date_created, date_updated, version
 	FROM
 		roles
 	WHERE
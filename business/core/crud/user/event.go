@@ -13,18 +13,77 @@ const Domain = "user"
 
 // Set of delegate actions.
 const (
+	ActionCreated = "created"
 	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
 )
 
+// Set of versions of the action parameters. A version is incremented when
+// the parameters change in a way existing listeners can't decode. Version 1
+// of the updated action carried the UpdateUser fields instead of the user
+// before and after the update.
+const (
+	ActionCreatedVersion = 1
+	ActionUpdatedVersion = 2
+	ActionDeletedVersion = 1
+)
+
+func init() {
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionCreated, Version: ActionCreatedVersion, Parms: ActionCreatedParms{}})
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionUpdated, Version: ActionUpdatedVersion, Parms: ActionUpdatedParms{}})
+	delegate.RegisterSchema(delegate.Schema{Domain: Domain, Action: ActionDeleted, Version: ActionDeletedVersion, Parms: ActionDeletedParms{}})
+}
+
+// =============================================================================
+
+// ActionCreatedParms represents the parameters for the created action.
+type ActionCreatedParms struct {
+	UserID uuid.UUID
+	After  User
+}
+
+// String returns a string representation of the action parameters.
+func (ac *ActionCreatedParms) String() string {
+	return fmt.Sprintf("&ActionCreatedParms{UserID:%v, Version:%v}", ac.UserID, ac.After.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (ac *ActionCreatedParms) Marshal() ([]byte, error) {
+	return json.Marshal(ac)
+}
+
+// ActionCreatedData constructs the data for the created action.
+func ActionCreatedData(usr User) delegate.Data {
+	params := ActionCreatedParms{
+		UserID: usr.ID,
+		After:  usr,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionCreated,
+		Version:   ActionCreatedVersion,
+		RawParams: rawParams,
+	}
+}
+
+// =============================================================================
+
 // ActionUpdatedParms represents the parameters for the updated action.
 type ActionUpdatedParms struct {
 	UserID uuid.UUID
-	UpdateUser
+	Before User
+	After  User
 }
 
 // String returns a string representation of the action parameters.
 func (au *ActionUpdatedParms) String() string {
-	return fmt.Sprintf("&EventParamsUpdated{UserID:%v, Enabled:%v}", au.UserID, au.Enabled)
+	return fmt.Sprintf("&ActionUpdatedParms{UserID:%v, Version:%v}", au.UserID, au.After.Version)
 }
 
 // Marshal returns the event parameters encoded as JSON.
@@ -33,12 +92,11 @@ func (au *ActionUpdatedParms) Marshal() ([]byte, error) {
 }
 
 // ActionUpdatedData constructs the data for the updated action.
-func ActionUpdatedData(uu UpdateUser, userID uuid.UUID) delegate.Data {
+func ActionUpdatedData(before User, after User) delegate.Data {
 	params := ActionUpdatedParms{
-		UserID: userID,
-		UpdateUser: UpdateUser{
-			Enabled: uu.Enabled,
-		},
+		UserID: after.ID,
+		Before: before,
+		After:  after,
 	}
 
 	rawParams, err := params.Marshal()
@@ -49,6 +107,45 @@ func ActionUpdatedData(uu UpdateUser, userID uuid.UUID) delegate.Data {
 	return delegate.Data{
 		Domain:    Domain,
 		Action:    ActionUpdated,
+		Version:   ActionUpdatedVersion,
+		RawParams: rawParams,
+	}
+}
+
+// =============================================================================
+
+// ActionDeletedParms represents the parameters for the deleted action.
+type ActionDeletedParms struct {
+	UserID uuid.UUID
+	Before User
+}
+
+// String returns a string representation of the action parameters.
+func (ad *ActionDeletedParms) String() string {
+	return fmt.Sprintf("&ActionDeletedParms{UserID:%v, Version:%v}", ad.UserID, ad.Before.Version)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (ad *ActionDeletedParms) Marshal() ([]byte, error) {
+	return json.Marshal(ad)
+}
+
+// ActionDeletedData constructs the data for the deleted action.
+func ActionDeletedData(usr User) delegate.Data {
+	params := ActionDeletedParms{
+		UserID: usr.ID,
+		Before: usr,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionDeleted,
+		Version:   ActionDeletedVersion,
 		RawParams: rawParams,
 	}
 }
@@ -15,6 +15,7 @@ type QueryFilter struct {
 	ID               *uuid.UUID
 	Name             *string `validate:"omitempty,min=3"`
 	Email            *mail.Address
+	RegionID         *uuid.UUID
 	StartCreatedDate *time.Time
 	EndCreatedDate   *time.Time
 }
@@ -43,6 +44,11 @@ func (qf *QueryFilter) WithEmail(email mail.Address) {
 	qf.Email = &email
 }
 
+// WithRegionID sets the RegionID field of the QueryFilter value.
+func (qf *QueryFilter) WithRegionID(regionID uuid.UUID) {
+	qf.RegionID = &regionID
+}
+
 // WithStartDateCreated sets the StartCreatedDate field of the QueryFilter value.
 func (qf *QueryFilter) WithStartDateCreated(startDate time.Time) {
 	d := startDate.UTC()
@@ -10,34 +10,37 @@ import (
 // User represents information about an individual user.
 type User struct {
 	ID           uuid.UUID
-	Name         string
-	Email        mail.Address
+	Name         string       `log:"redact"`
+	Email        mail.Address `log:"redact"`
 	Roles        []Role
 	RegionID     uuid.UUID
-	PasswordHash []byte
+	PasswordHash []byte `log:"redact" json:"-"`
 	Department   string
 	Enabled      bool
 	DateCreated  time.Time
 	DateUpdated  time.Time
+	Version      int
 }
 
 // NewUser contains information needed to create a new user.
 type NewUser struct {
-	Name            string
-	Email           mail.Address
+	Name            string       `log:"redact"`
+	Email           mail.Address `log:"redact"`
 	Roles           []Role
+	RegionID        uuid.UUID
 	Department      string
-	Password        string
-	PasswordConfirm string
+	Password        string `log:"redact"`
+	PasswordConfirm string `log:"redact"`
 }
 
 // UpdateUser contains information needed to update a user.
 type UpdateUser struct {
-	Name            *string
-	Email           *mail.Address
+	Name            *string       `log:"redact"`
+	Email           *mail.Address `log:"redact"`
 	Roles           []Role
+	RegionID        *uuid.UUID
 	Department      *string
-	Password        *string
-	PasswordConfirm *string
+	Password        *string `log:"redact"`
+	PasswordConfirm *string `log:"redact"`
 	Enabled         *bool
 }
@@ -1,6 +1,10 @@
 package user
 
-import "github.com/fadhilijuma/gateone-service/business/web/v1/order"
+import (
+	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"strconv"
+	"strings"
+)
 
 // DefaultOrderBy represents the default way we sort.
 var DefaultOrderBy = order.NewBy(OrderByID, order.ASC)
@@ -13,3 +17,43 @@ const (
 	OrderByRoles   = "roles"
 	OrderByEnabled = "enabled"
 )
+
+// CursorValues returns the values of the order fields of the user
+// followed by its ID, unless the order includes it, in the text form the
+// cursor paging queries compare them in.
+func CursorValues(usr User, orderBy order.By) []string {
+	values := make([]string, 0, len(orderBy.Fields)+1)
+
+	var hasID bool
+	for _, fld := range orderBy.Fields {
+		switch fld.Name {
+		case OrderByID:
+			values = append(values, usr.ID.String())
+			hasID = true
+		case OrderByName:
+			values = append(values, usr.Name)
+		case OrderByEmail:
+			values = append(values, usr.Email.Address)
+		case OrderByRoles:
+			values = append(values, rolesValue(usr.Roles))
+		case OrderByEnabled:
+			values = append(values, strconv.FormatBool(usr.Enabled))
+		}
+	}
+
+	if !hasID {
+		values = append(values, usr.ID.String())
+	}
+
+	return values
+}
+
+// rolesValue returns the roles in the text form of an array.
+func rolesValue(roles []Role) string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = strconv.Quote(role.Name())
+	}
+
+	return "{" + strings.Join(names, ",") + "}"
+}
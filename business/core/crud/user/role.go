@@ -4,14 +4,16 @@ import "fmt"
 
 // Set of possible roles for a user.
 var (
-	RoleAdmin = Role{"ADMIN"}
-	RoleUser  = Role{"USER"}
+	RoleAdmin      = Role{"ADMIN"}
+	RoleSupervisor = Role{"SUPERVISOR"}
+	RoleUser       = Role{"USER"}
 )
 
 // Set of known roles.
 var roles = map[string]Role{
-	RoleAdmin.name: RoleAdmin,
-	RoleUser.name:  RoleUser,
+	RoleAdmin.name:      RoleAdmin,
+	RoleSupervisor.name: RoleSupervisor,
+	RoleUser.name:       RoleUser,
 }
 
 // Role represents a role in the system.
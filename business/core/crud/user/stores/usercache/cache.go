@@ -0,0 +1,163 @@
+package usercache
+
+import (
+	"container/list"
+	"expvar"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
+	"sync"
+	"time"
+)
+
+// Set of default values for the cache.
+const (
+	defaultCapacity = 10_000
+	defaultTTL      = 5 * time.Minute
+)
+
+// stats holds the counters published for every cache in the process. The
+// expvar package registers values as singletons so a single map is shared.
+var stats = expvar.NewMap("usercache")
+
+// Config represents the settings for constructing a Cache. Now is the clock
+// the values expire by, time.Now when it isn't provided.
+type Config struct {
+	Capacity int
+	TTL      time.Duration
+	Now      func() time.Time
+}
+
+// entry represents a single value held by the cache.
+type entry struct {
+	key     string
+	usr     user.User
+	expires time.Time
+}
+
+// Cache is a size bounded, least recently used cache of users where every
+// value expires after the configured time to live. The same user is stored
+// under both its id and email address. A Cache is safe for concurrent use
+// and is intended to be shared by every Store in the process.
+type Cache struct {
+	capacity int
+	ttl      time.Duration
+	mu       sync.Mutex
+	ll       *list.List
+	items    map[string]*list.Element
+	now      func() time.Time
+}
+
+// NewCache constructs a cache for use. Zero values in the config are replaced
+// with the package defaults.
+func NewCache(cfg Config) *Cache {
+	if cfg.Capacity <= 0 {
+		cfg.Capacity = defaultCapacity
+	}
+
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultTTL
+	}
+
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	return &Cache{
+		capacity: cfg.Capacity,
+		ttl:      cfg.TTL,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		now:      cfg.Now,
+	}
+}
+
+// Len returns the number of keys currently held by the cache.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+// Purge removes every value from the cache.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	clear(c.items)
+}
+
+// get performs a search in the cache for the specified key. Expired values
+// are removed and reported as a miss.
+func (c *Cache) get(key string) (user.User, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.items[key]
+	if !exists {
+		stats.Add("misses", 1)
+		return user.User{}, false
+	}
+
+	ent := elem.Value.(*entry)
+	if c.now().After(ent.expires) {
+		c.removeElement(elem)
+		stats.Add("expirations", 1)
+		stats.Add("misses", 1)
+		return user.User{}, false
+	}
+
+	c.ll.MoveToFront(elem)
+	stats.Add("hits", 1)
+
+	return ent.usr, true
+}
+
+// set writes the user into the cache under its id and email address.
+func (c *Cache) set(usr user.User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(c.ttl)
+
+	c.add(usr.ID.String(), usr, expires)
+	c.add(usr.Email.Address, usr, expires)
+}
+
+// remove deletes the specified keys from the cache.
+func (c *Cache) remove(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, exists := c.items[key]; exists {
+			c.removeElement(elem)
+		}
+	}
+}
+
+// add stores the value under the key and evicts the least recently used
+// values when the cache is over capacity. The caller must hold the lock.
+func (c *Cache) add(key string, usr user.User, expires time.Time) {
+	if elem, exists := c.items[key]; exists {
+		ent := elem.Value.(*entry)
+		ent.usr = usr
+		ent.expires = expires
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&entry{key: key, usr: usr, expires: expires})
+
+	for c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+		stats.Add("evictions", 1)
+	}
+}
+
+// removeElement unlinks the element from the cache. The caller must hold
+// the lock.
+func (c *Cache) removeElement(elem *list.Element) {
+	c.ll.Remove(elem)
+	delete(c.items, elem.Value.(*entry).key)
+}
@@ -0,0 +1,111 @@
+package usercache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+// Channel is the PostgreSQL notification channel the users table trigger
+// publishes to whenever a user is updated or deleted.
+const Channel = "user_changed"
+
+// notification represents the payload sent by the users table trigger. It
+// carries the values from before the change so every key the user could be
+// cached under is known.
+type notification struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// Listen subscribes to the user change notifications published by the
+// database and evicts the affected users from the cache. This keeps the
+// caches of every instance of the service consistent with each other. The
+// call blocks until the context is cancelled, reconnecting on failure. Since
+// notifications may be missed while disconnected, the cache is purged every
+// time the subscription is re-established.
+func Listen(ctx context.Context, log *logger.Logger, db *sqlx.DB, cache *Cache) error {
+	const maxBackoff = 30 * time.Second
+	backoff := time.Second
+
+	for {
+		err := listen(ctx, log, db, cache)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		log.Error(ctx, "usercache", "status", "listen failed", "channel", Channel, "msg", err, "retry", backoff.String())
+		stats.Add("listen_errors", 1)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// listen holds a dedicated connection and processes notifications until an
+// error occurs or the context is cancelled.
+func listen(ctx context.Context, log *logger.Logger, db *sqlx.DB, cache *Cache) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("conn: %w", err)
+	}
+	defer conn.Close()
+
+	f := func(driverConn any) error {
+		sc, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("driver connection(%T) not of type *stdlib.Conn", driverConn)
+		}
+		pgxConn := sc.Conn()
+
+		if _, err := pgxConn.Exec(ctx, "LISTEN "+Channel); err != nil {
+			return fmt.Errorf("listen: %w", err)
+		}
+
+		cache.Purge()
+		log.Info(ctx, "usercache", "status", "listening", "channel", Channel)
+
+		for {
+			n, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				return fmt.Errorf("wait for notification: %w", err)
+			}
+
+			if err := cache.Invalidate([]byte(n.Payload)); err != nil {
+				log.Error(ctx, "usercache", "status", "invalid notification", "payload", n.Payload, "msg", err)
+			}
+		}
+	}
+
+	err = conn.Raw(f)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+
+	return err
+}
+
+// Invalidate evicts the user described by the payload of a user change
+// notification from the cache.
+func (c *Cache) Invalidate(payload []byte) error {
+	var n notification
+	if err := json.Unmarshal(payload, &n); err != nil {
+		return err
+	}
+
+	c.remove(n.UserID, n.Email)
+	stats.Add("invalidations", 1)
+
+	return nil
+}
@@ -6,34 +6,54 @@ import (
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
 	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"net/mail"
-	"sync"
 
 	"github.com/google/uuid"
 )
 
-// Store manages the set of APIs for user data and caching.
+// Store manages the set of APIs for user data and caching. A Store inside a
+// transaction reads straight from the database so the version it sees is
+// the one the transaction changes.
 type Store struct {
 	log    *logger.Logger
 	storer user.Storer
-	cache  map[string]user.User
-	mu     sync.RWMutex
+	cache  *Cache
+	inTx   bool
 }
 
-// NewStore constructs the api for data and caching access.
-func NewStore(log *logger.Logger, storer user.Storer) *Store {
+// NewStore constructs the api for data and caching access. The cache is
+// shared with every other Store constructed with it. If no cache is provided
+// a private cache with the default settings is used.
+func NewStore(log *logger.Logger, storer user.Storer, cache *Cache) *Store {
+	if cache == nil {
+		cache = NewCache(Config{})
+	}
+
 	return &Store{
 		log:    log,
 		storer: storer,
-		cache:  map[string]user.User{},
+		cache:  cache,
 	}
 }
 
 // ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
 // value with a sqlx DB value that is currently inside a transaction.
 func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (user.Storer, error) {
-	return s.storer.ExecuteUnderTransaction(tx)
+	storer, err := s.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log:    s.log,
+		storer: storer,
+		cache:  s.cache,
+		inTx:   true,
+	}
+
+	return &store, nil
 }
 
 // Create inserts a new user into the database.
@@ -53,7 +73,14 @@ func (s *Store) Update(ctx context.Context, usr user.User) error {
 		return err
 	}
 
-	s.writeCache(usr)
+	// The entry isn't replaced since the database moved the version on, the
+	// next read caches the user again. The email address may have changed
+	// so the value cached under the previous address is dropped as well.
+	if cachedUsr, ok := s.cache.get(usr.ID.String()); ok {
+		s.deleteCache(cachedUsr)
+	}
+
+	s.deleteCache(usr)
 
 	return nil
 }
@@ -74,6 +101,12 @@ func (s *Store) Query(ctx context.Context, filter user.QueryFilter, orderBy orde
 	return s.storer.Query(ctx, filter, orderBy, pageNumber, rowsPerPage)
 }
 
+// QueryByCursor retrieves a list of existing users from the database
+// positioned by the cursor.
+func (s *Store) QueryByCursor(ctx context.Context, filter user.QueryFilter, orderBy order.By, cursor page.Cursor, rows int) ([]user.User, error) {
+	return s.storer.QueryByCursor(ctx, filter, orderBy, cursor, rows)
+}
+
 // Count returns the total number of cards in the DB.
 func (s *Store) Count(ctx context.Context, filter user.QueryFilter) (int, error) {
 	return s.storer.Count(ctx, filter)
@@ -124,32 +157,26 @@ func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (user.User
 }
 
 // readCache performs a safe search in the cache for the specified key.
+// Nothing is found inside a transaction.
 func (s *Store) readCache(key string) (user.User, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	usr, exists := s.cache[key]
-	if !exists {
+	if s.inTx {
 		return user.User{}, false
 	}
 
-	return usr, true
+	return s.cache.get(key)
 }
 
 // writeCache performs a safe write to the cache for the specified user.
+// Nothing is written inside a transaction since it may be rolled back.
 func (s *Store) writeCache(usr user.User) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	if s.inTx {
+		return
+	}
 
-	s.cache[usr.ID.String()] = usr
-	s.cache[usr.Email.Address] = usr
+	s.cache.set(usr)
 }
 
 // deleteCache performs a safe removal from the cache for the specified user.
 func (s *Store) deleteCache(usr user.User) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	delete(s.cache, usr.ID.String())
-	delete(s.cache, usr.Email.Address)
+	s.cache.remove(usr.ID.String(), usr.Email.Address)
 }
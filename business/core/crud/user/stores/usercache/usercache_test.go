@@ -0,0 +1,268 @@
+package usercache_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/user/stores/usercache"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"io"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// countingStore serves the users it holds and counts the queries that
+// reached it, so a query answered by the cache isn't counted.
+type countingStore struct {
+	user.Storer
+	users   map[uuid.UUID]user.User
+	queries int
+}
+
+func (cs *countingStore) QueryByID(ctx context.Context, userID uuid.UUID) (user.User, error) {
+	cs.queries++
+
+	usr, exists := cs.users[userID]
+	if !exists {
+		return user.User{}, user.ErrNotFound
+	}
+
+	return usr, nil
+}
+
+func (cs *countingStore) QueryByEmail(ctx context.Context, email mail.Address) (user.User, error) {
+	cs.queries++
+
+	for _, usr := range cs.users {
+		if usr.Email.Address == email.Address {
+			return usr, nil
+		}
+	}
+
+	return user.User{}, user.ErrNotFound
+}
+
+func (cs *countingStore) ExecuteUnderTransaction(tx transaction.Transaction) (user.Storer, error) {
+	return cs, nil
+}
+
+func (cs *countingStore) Update(ctx context.Context, usr user.User) error {
+	usr.Version++
+	cs.users[usr.ID] = usr
+
+	return nil
+}
+
+// fakeClock is a clock that only moves when it's advanced.
+type fakeClock struct {
+	now time.Time
+}
+
+func (fc *fakeClock) Now() time.Time {
+	return fc.now
+}
+
+func (fc *fakeClock) Advance(d time.Duration) {
+	fc.now = fc.now.Add(d)
+}
+
+func newUsers(n int) []user.User {
+	usrs := make([]user.User, n)
+	for i := range usrs {
+		usrs[i] = user.User{
+			ID:    uuid.New(),
+			Email: mail.Address{Address: fmt.Sprintf("user%d@example.com", i)},
+		}
+	}
+
+	return usrs
+}
+
+// =============================================================================
+
+func Test_Cache(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	clock := fakeClock{now: time.Now()}
+
+	// Every user is cached under two keys, its id and its email address.
+	tests := []struct {
+		name     string
+		cfg      usercache.Config
+		run      func(ctx context.Context, s *usercache.Store, cache *usercache.Cache, usrs []user.User) error
+		user     int
+		byEmail  bool
+		queries  int
+		cacheLen int
+	}{
+		{
+			name: "hit",
+			cfg:  usercache.Config{Capacity: 4, TTL: time.Hour},
+			run: func(ctx context.Context, s *usercache.Store, cache *usercache.Cache, usrs []user.User) error {
+				_, err := s.QueryByID(ctx, usrs[0].ID)
+				return err
+			},
+			user:     0,
+			byEmail:  true,
+			queries:  1,
+			cacheLen: 2,
+		},
+		{
+			name: "evicts least recently used",
+			cfg:  usercache.Config{Capacity: 4, TTL: time.Hour},
+			run: func(ctx context.Context, s *usercache.Store, cache *usercache.Cache, usrs []user.User) error {
+				for _, i := range []int{0, 1, 0, 2} {
+					if _, err := s.QueryByID(ctx, usrs[i].ID); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			user:     1,
+			queries:  4,
+			cacheLen: 4,
+		},
+		{
+			name: "keeps recently used",
+			cfg:  usercache.Config{Capacity: 4, TTL: time.Hour},
+			run: func(ctx context.Context, s *usercache.Store, cache *usercache.Cache, usrs []user.User) error {
+				for _, i := range []int{0, 1, 0, 2} {
+					if _, err := s.QueryByID(ctx, usrs[i].ID); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			user:     0,
+			queries:  3,
+			cacheLen: 4,
+		},
+		{
+			name: "expires",
+			cfg:  usercache.Config{Capacity: 4, TTL: time.Minute, Now: clock.Now},
+			run: func(ctx context.Context, s *usercache.Store, cache *usercache.Cache, usrs []user.User) error {
+				_, err := s.QueryByID(ctx, usrs[0].ID)
+				clock.Advance(time.Minute + time.Second)
+				return err
+			},
+			user:     0,
+			queries:  2,
+			cacheLen: 2,
+		},
+		{
+			name: "invalidates on update",
+			cfg:  usercache.Config{Capacity: 4, TTL: time.Hour},
+			run: func(ctx context.Context, s *usercache.Store, cache *usercache.Cache, usrs []user.User) error {
+				if _, err := s.QueryByID(ctx, usrs[0].ID); err != nil {
+					return err
+				}
+
+				return s.Update(ctx, usrs[0])
+			},
+			user:     0,
+			queries:  2,
+			cacheLen: 2,
+		},
+		{
+			name: "invalidates on notification",
+			cfg:  usercache.Config{Capacity: 4, TTL: time.Hour},
+			run: func(ctx context.Context, s *usercache.Store, cache *usercache.Cache, usrs []user.User) error {
+				if _, err := s.QueryByID(ctx, usrs[0].ID); err != nil {
+					return err
+				}
+
+				payload := fmt.Sprintf(`{"user_id":%q,"email":%q}`, usrs[0].ID, usrs[0].Email.Address)
+				return cache.Invalidate([]byte(payload))
+			},
+			user:     0,
+			byEmail:  true,
+			queries:  2,
+			cacheLen: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			usrs := newUsers(3)
+			storer := countingStore{users: make(map[uuid.UUID]user.User)}
+			for _, usr := range usrs {
+				storer.users[usr.ID] = usr
+			}
+
+			cache := usercache.NewCache(tt.cfg)
+			s := usercache.NewStore(log, &storer, cache)
+
+			if err := tt.run(ctx, s, cache, usrs); err != nil {
+				t.Fatalf("Should be able to run the queries: %s", err)
+			}
+
+			var err error
+			switch tt.byEmail {
+			case true:
+				_, err = s.QueryByEmail(ctx, usrs[tt.user].Email)
+			default:
+				_, err = s.QueryByID(ctx, usrs[tt.user].ID)
+			}
+			if err != nil {
+				t.Fatalf("Should be able to query the user: %s", err)
+			}
+
+			if storer.queries != tt.queries {
+				t.Errorf("Should query the store %d times: got %d", tt.queries, storer.queries)
+			}
+
+			if cache.Len() != tt.cacheLen {
+				t.Errorf("Should hold %d keys: got %d", tt.cacheLen, cache.Len())
+			}
+		})
+	}
+
+	t.Run("transaction", func(t *testing.T) {
+		ctx := context.Background()
+
+		usrs := newUsers(1)
+		storer := countingStore{users: map[uuid.UUID]user.User{usrs[0].ID: usrs[0]}}
+
+		cache := usercache.NewCache(usercache.Config{Capacity: 4, TTL: time.Hour})
+		s := usercache.NewStore(log, &storer, cache)
+
+		if _, err := s.QueryByID(ctx, usrs[0].ID); err != nil {
+			t.Fatalf("Should be able to query the user: %s", err)
+		}
+
+		txStore, err := s.ExecuteUnderTransaction(nil)
+		if err != nil {
+			t.Fatalf("Should be able to execute under a transaction: %s", err)
+		}
+
+		if err := txStore.Update(ctx, usrs[0]); err != nil {
+			t.Fatalf("Should be able to update the user: %s", err)
+		}
+
+		usr, err := txStore.QueryByID(ctx, usrs[0].ID)
+		if err != nil {
+			t.Fatalf("Should be able to query the user: %s", err)
+		}
+
+		if usr.Version != usrs[0].Version+1 || storer.queries != 2 {
+			t.Errorf("Should read the updated version from the store: got version %d and %d queries", usr.Version, storer.queries)
+		}
+
+		if cache.Len() != 0 {
+			t.Errorf("Should not cache inside a transaction: got %d keys", cache.Len())
+		}
+	})
+
+	t.Run("invalid notification", func(t *testing.T) {
+		cache := usercache.NewCache(usercache.Config{})
+		if err := cache.Invalidate([]byte("not json")); err == nil {
+			t.Errorf("Should not accept an invalid notification")
+		}
+	})
+}
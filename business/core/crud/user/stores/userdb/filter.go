@@ -25,6 +25,11 @@ func applyFilter(filter user.QueryFilter, data map[string]interface{}, buf *byte
 		wc = append(wc, "email = :email")
 	}
 
+	if filter.RegionID != nil {
+		data["region_id"] = *filter.RegionID
+		wc = append(wc, "region_id = :region_id")
+	}
+
 	if filter.StartCreatedDate != nil {
 		data["start_date_created"] = *filter.StartCreatedDate
 		wc = append(wc, "date_created >= :start_date_created")
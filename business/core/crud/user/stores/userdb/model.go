@@ -12,14 +12,16 @@ import (
 
 type dbUser struct {
 	ID           uuid.UUID      `db:"user_id"`
-	Name         string         `db:"name"`
-	Email        string         `db:"email"`
+	Name         string         `db:"name" log:"redact"`
+	Email        string         `db:"email" log:"redact"`
 	Roles        dbarray.String `db:"roles"`
-	PasswordHash []byte         `db:"password_hash"`
+	RegionID     uuid.NullUUID  `db:"region_id"`
+	PasswordHash []byte         `db:"password_hash" log:"redact"`
 	Department   sql.NullString `db:"department"`
 	Enabled      bool           `db:"enabled"`
 	DateCreated  time.Time      `db:"date_created"`
 	DateUpdated  time.Time      `db:"date_updated"`
+	Version      int            `db:"version"`
 }
 
 func toDBUser(usr user.User) dbUser {
@@ -28,11 +30,17 @@ func toDBUser(usr user.User) dbUser {
 		roles[i] = role.Name()
 	}
 
+	regionID := uuid.NullUUID{
+		UUID:  usr.RegionID,
+		Valid: usr.RegionID != uuid.Nil,
+	}
+
 	return dbUser{
 		ID:           usr.ID,
 		Name:         usr.Name,
 		Email:        usr.Email.Address,
 		Roles:        roles,
+		RegionID:     regionID,
 		PasswordHash: usr.PasswordHash,
 		Department: sql.NullString{
 			String: usr.Department,
@@ -41,6 +49,7 @@ func toDBUser(usr user.User) dbUser {
 		Enabled:     usr.Enabled,
 		DateCreated: usr.DateCreated.UTC(),
 		DateUpdated: usr.DateUpdated.UTC(),
+		Version:     usr.Version,
 	}
 }
 
@@ -63,11 +72,13 @@ func toCoreUser(dbUsr dbUser) (user.User, error) {
 		Name:         dbUsr.Name,
 		Email:        addr,
 		Roles:        roles,
+		RegionID:     dbUsr.RegionID.UUID,
 		PasswordHash: dbUsr.PasswordHash,
 		Enabled:      dbUsr.Enabled,
 		Department:   dbUsr.Department.String,
 		DateCreated:  dbUsr.DateCreated.In(time.Local),
 		DateUpdated:  dbUsr.DateUpdated.In(time.Local),
+		Version:      dbUsr.Version,
 	}
 
 	return usr, nil
@@ -11,8 +11,10 @@ import (
 	"github.com/fadhilijuma/gateone-service/business/data/sqldb/dbarray"
 	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"net/mail"
+	"slices"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -52,9 +54,9 @@ func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (user.Storer
 func (s *Store) Create(ctx context.Context, usr user.User) error {
 	const q = `
 	INSERT INTO users
-		(user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated)
+		(user_id, region_id, name, email, password_hash, roles, enabled, department, date_created, date_updated, version)
 	VALUES
-		(:user_id, :name, :email, :password_hash, :roles, :enabled, :department, :date_created, :date_updated)`
+		(:user_id, :region_id, :name, :email, :password_hash, :roles, :enabled, :department, :date_created, :date_updated, :version)`
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
 		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
@@ -75,17 +77,28 @@ func (s *Store) Update(ctx context.Context, usr user.User) error {
 		"name" = :name,
 		"email" = :email,
 		"roles" = :roles,
+		"region_id" = :region_id,
 		"password_hash" = :password_hash,
 		"department" = :department,
-		"date_updated" = :date_updated
+		"date_updated" = :date_updated,
+		"version" = "version" + 1
 	WHERE
-		user_id = :user_id`
+		user_id = :user_id AND version = :version
+	RETURNING
+		version`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
+	var dest struct {
+		Version int `db:"version"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, toDBUser(usr), &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", user.ErrVersionConflict)
+		}
 		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
 			return user.ErrUniqueEmail
 		}
-		return fmt.Errorf("namedexeccontext: %w", err)
+		return fmt.Errorf("namedquerystruct: %w", err)
 	}
 
 	return nil
@@ -94,19 +107,30 @@ func (s *Store) Update(ctx context.Context, usr user.User) error {
 // Delete removes a user from the database.
 func (s *Store) Delete(ctx context.Context, usr user.User) error {
 	data := struct {
-		ID string `db:"user_id"`
+		ID      string `db:"user_id"`
+		Version int    `db:"version"`
 	}{
-		ID: usr.ID.String(),
+		ID:      usr.ID.String(),
+		Version: usr.Version,
 	}
 
 	const q = `
 	DELETE FROM
 		users
 	WHERE
-		user_id = :user_id`
+		user_id = :user_id AND version = :version
+	RETURNING
+		user_id`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
-		return fmt.Errorf("namedexeccontext: %w", err)
+	var dest struct {
+		ID string `db:"user_id"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", user.ErrVersionConflict)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
 	}
 
 	return nil
@@ -121,7 +145,7 @@ func (s *Store) Query(ctx context.Context, filter user.QueryFilter, orderBy orde
 
 	const q = `
 	SELECT
-		user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated
+		user_id, region_id, name, email, password_hash, roles, enabled, department, date_created, date_updated, version
 	FROM
 		users`
 
@@ -144,6 +168,57 @@ func (s *Store) Query(ctx context.Context, filter user.QueryFilter, orderBy orde
 	return toCoreUserSlice(dbUsrs)
 }
 
+// QueryByCursor retrieves the users positioned after the cursor from the
+// database, or before it for a backward cursor, in list order.
+func (s *Store) QueryByCursor(ctx context.Context, filter user.QueryFilter, orderBy order.By, cursor page.Cursor, rows int) ([]user.User, error) {
+	data := map[string]interface{}{
+		"rows_per_page": rows,
+	}
+
+	const q = `
+	SELECT
+		user_id, region_id, name, email, password_hash, roles, enabled, department, date_created, date_updated, version
+	FROM
+		users`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+
+	ks, err := keyset(orderBy, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	wc, err := ks.Where(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if wc != "" {
+		switch {
+		case buf.Len() > len(q):
+			buf.WriteString(" AND ")
+		default:
+			buf.WriteString(" WHERE ")
+		}
+		buf.WriteString(wc)
+	}
+
+	buf.WriteString(ks.OrderBy())
+	buf.WriteString(" FETCH FIRST :rows_per_page ROWS ONLY")
+
+	var dbUsrs []dbUser
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbUsrs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	if cursor.Backward {
+		slices.Reverse(dbUsrs)
+	}
+
+	return toCoreUserSlice(dbUsrs)
+}
+
 // Count returns the total number of users in the DB.
 func (s *Store) Count(ctx context.Context, filter user.QueryFilter) (int, error) {
 	data := map[string]interface{}{}
@@ -177,7 +252,7 @@ func (s *Store) QueryByID(ctx context.Context, userID uuid.UUID) (user.User, err
 
 	const q = `
 	SELECT
-        user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated
+        user_id, region_id, name, email, password_hash, roles, enabled, department, date_created, date_updated, version
 	FROM
 		users
 	WHERE 
@@ -209,7 +284,7 @@ func (s *Store) QueryByIDs(ctx context.Context, userIDs []uuid.UUID) ([]user.Use
 
 	const q = `
 	SELECT
-        user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated
+        user_id, region_id, name, email, password_hash, roles, enabled, department, date_created, date_updated, version
 	FROM
 		users
 	WHERE
@@ -236,7 +311,7 @@ func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (user.User
 
 	const q = `
 	SELECT
-        user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated
+        user_id, region_id, name, email, password_hash, roles, enabled, department, date_created, date_updated, version
 	FROM
 		users
 	WHERE
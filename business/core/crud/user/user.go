@@ -10,6 +10,7 @@ import (
 	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
 	"github.com/fadhilijuma/gateone-service/business/data/transaction"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"net/mail"
 	"time"
@@ -23,6 +24,7 @@ var (
 	ErrNotFound              = errors.New("user not found")
 	ErrUniqueEmail           = errors.New("email is not unique")
 	ErrAuthenticationFailure = errors.New("authentication failed")
+	ErrVersionConflict       = errors.New("user was changed by another request")
 )
 
 // Storer interface declares the behavior this package needs to perists and
@@ -33,6 +35,7 @@ type Storer interface {
 	Update(ctx context.Context, usr User) error
 	Delete(ctx context.Context, usr User) error
 	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]User, error)
+	QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, rows int) ([]User, error)
 	Count(ctx context.Context, filter QueryFilter) (int, error)
 	QueryByID(ctx context.Context, userID uuid.UUID) (User, error)
 	QueryByIDs(ctx context.Context, userID []uuid.UUID) ([]User, error)
@@ -63,9 +66,14 @@ func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error
 		return nil, err
 	}
 
+	dlg, err := c.delegate.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	core := Core{
 		log:      c.log,
-		delegate: c.delegate,
+		delegate: dlg,
 		storer:   trS,
 	}
 
@@ -87,21 +95,29 @@ func (c *Core) Create(ctx context.Context, nu NewUser) (User, error) {
 		Email:        nu.Email,
 		PasswordHash: hash,
 		Roles:        nu.Roles,
+		RegionID:     nu.RegionID,
 		Department:   nu.Department,
 		Enabled:      true,
 		DateCreated:  now,
 		DateUpdated:  now,
+		Version:      1,
 	}
 
 	if err := c.storer.Create(ctx, usr); err != nil {
 		return User{}, fmt.Errorf("create: %w", err)
 	}
 
+	if err := c.delegate.Call(ctx, ActionCreatedData(usr)); err != nil {
+		return User{}, fmt.Errorf("failed to execute `%s` action: %w", ActionCreated, err)
+	}
+
 	return usr, nil
 }
 
 // Update modifies information about a user.
 func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error) {
+	before := usr
+
 	if uu.Name != nil {
 		usr.Name = *uu.Name
 	}
@@ -122,6 +138,10 @@ func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error
 		usr.PasswordHash = pw
 	}
 
+	if uu.RegionID != nil {
+		usr.RegionID = *uu.RegionID
+	}
+
 	if uu.Department != nil {
 		usr.Department = *uu.Department
 	}
@@ -135,9 +155,11 @@ func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error
 		return User{}, fmt.Errorf("update: %w", err)
 	}
 
+	usr.Version++
+
 	// Other domains may need to know when a user is updated so business
 	// logic can be applied. This represents a delegate call to other domains.
-	if err := c.delegate.Call(ctx, ActionUpdatedData(uu, usr.ID)); err != nil {
+	if err := c.delegate.Call(ctx, ActionUpdatedData(before, usr)); err != nil {
 		return User{}, fmt.Errorf("failed to execute `%s` action: %w", ActionUpdated, err)
 	}
 
@@ -150,6 +172,10 @@ func (c *Core) Delete(ctx context.Context, usr User) error {
 		return fmt.Errorf("delete: %w", err)
 	}
 
+	if err := c.delegate.Call(ctx, ActionDeletedData(usr)); err != nil {
+		return fmt.Errorf("failed to execute `%s` action: %w", ActionDeleted, err)
+	}
+
 	return nil
 }
 
@@ -167,6 +193,21 @@ func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By,
 	return users, nil
 }
 
+// QueryByCursor retrieves a list of existing users positioned after the
+// cursor, or before it for a backward cursor, in list order.
+func (c *Core) QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, rows int) ([]User, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	users, err := c.storer.QueryByCursor(ctx, filter, orderBy, cursor, rows)
+	if err != nil {
+		return nil, fmt.Errorf("querybycursor: %w", err)
+	}
+
+	return users, nil
+}
+
 // Count returns the total number of users.
 func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
 	if err := filter.Validate(); err != nil {
@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned when a delivery would connect to an address
+// that isn't public, such as a loopback, link-local or private address.
+var ErrBlockedAddress = errors.New("blocked address")
+
+// sharedAddressSpace is the carrier-grade NAT range, which isn't reachable
+// from the internet either.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// NewClient constructs the client the deliveries are sent with. Partners
+// choose the URLs, so the client only connects to public addresses. They are
+// checked once the host is resolved so a name can't point it back into the
+// network of the service. Redirects aren't followed, a redirect is an
+// unexpected status, and proxies from the environment aren't used since the
+// check would only see the proxy.
+func NewClient() *http.Client {
+	dialer := net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   controlPublic,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	client := http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return &client
+}
+
+// controlPublic refuses connections to an address that isn't public. It runs
+// after the address is resolved and before the connection is made.
+func controlPublic(network string, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !publicAddr(addr) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+
+	return nil
+}
+
+// publicAddr reports whether the address is reachable from the internet.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
@@ -0,0 +1,211 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/worker"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DelivererConfig represents the settings for delivering the events to the
+// subscriptions. Every Interval up to BatchSize due deliveries are claimed
+// and hidden from other deliverers for Lease, which must be longer than the
+// Timeout a receiver has to respond. A delivery that fails is retried after
+// a backoff that doubles from MinBackoff up to MaxBackoff, and is marked
+// failed after MaxAttempts failures. A subscription is disabled once
+// DisableAfter attempts to deliver to it failed in a row.
+type DelivererConfig struct {
+	Interval     time.Duration
+	BatchSize    int
+	Lease        time.Duration
+	Timeout      time.Duration
+	MaxAttempts  int
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	DisableAfter int
+}
+
+// Deliverer sends the queued deliveries to the subscriptions. Events are
+// delivered at least once, so receivers must ignore an event ID they
+// already processed.
+type Deliverer struct {
+	log    *logger.Logger
+	core   *Core
+	worker *worker.Worker
+	client *http.Client
+	cfg    DelivererConfig
+}
+
+// NewDeliverer constructs a deliverer that runs the deliveries on the
+// worker and sends them with the client.
+func NewDeliverer(log *logger.Logger, core *Core, w *worker.Worker, client *http.Client, cfg DelivererConfig) (*Deliverer, error) {
+	if cfg.Interval <= 0 || cfg.BatchSize <= 0 || cfg.Timeout <= 0 || cfg.MaxAttempts <= 0 || cfg.DisableAfter <= 0 {
+		return nil, errors.New("interval, batch size, timeout, max attempts and disable after must be greater than 0")
+	}
+
+	if cfg.Lease <= cfg.Timeout {
+		return nil, errors.New("lease must be longer than the timeout")
+	}
+
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		return nil, errors.New("max backoff must not be less than min backoff")
+	}
+
+	dr := Deliverer{
+		log:    log,
+		core:   core,
+		worker: w,
+		client: client,
+		cfg:    cfg,
+	}
+
+	return &dr, nil
+}
+
+// Run sends the due deliveries every interval until the context is
+// canceled.
+func (dr *Deliverer) Run(ctx context.Context) {
+	ticker := time.NewTicker(dr.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := dr.Deliver(ctx); err != nil && ctx.Err() == nil {
+			dr.log.Error(ctx, "webhook delivery", "status", "delivering", "msg", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Deliver claims a batch of due deliveries and starts sending them on the
+// worker. It returns the number of deliveries started. Deliveries that were
+// claimed but not started are sent once their lease ends.
+func (dr *Deliverer) Deliver(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	dls, err := dr.core.storer.ClaimDeliveries(ctx, now, now.Add(dr.cfg.Lease), dr.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claimdeliveries: %w", err)
+	}
+
+	for i, dl := range dls {
+		// The timeout starts once the job runs so the time spent waiting
+		// for a free worker isn't taken from the receiver. Waiting and the
+		// job both end with the lease at the latest, since the delivery is
+		// claimed again after it.
+		job := func(ctx context.Context) {
+			ctx, cancel := context.WithTimeout(ctx, dr.cfg.Timeout)
+			defer cancel()
+
+			dr.deliver(ctx, dl)
+		}
+
+		leaseCtx, cancel := context.WithDeadline(ctx, now.Add(dr.cfg.Lease))
+		_, err := dr.worker.Start(leaseCtx, job)
+		cancel()
+
+		if err != nil {
+			return i, fmt.Errorf("start: deliveryID[%s]: %w", dl.ID, err)
+		}
+	}
+
+	return len(dls), nil
+}
+
+// deliver sends the delivery to its subscription and records the outcome
+// in the delivery log.
+func (dr *Deliverer) deliver(ctx context.Context, dl Delivery) {
+	sub, err := dr.core.storer.QueryByID(ctx, dl.SubscriptionID)
+	if err != nil {
+		dr.log.Error(ctx, "webhook delivery", "status", "querying subscription", "delivery_id", dl.ID, "msg", err)
+		return
+	}
+
+	statusCode, err := dr.send(ctx, sub, dl)
+
+	// The outcome is recorded even when the receiver used up the time it
+	// had.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	dl.Attempts++
+	dl.StatusCode = statusCode
+	dl.DateUpdated = now
+
+	switch {
+	case err == nil:
+		dl.Status = StatusDelivered
+		dl.LastError = ""
+
+	case dl.Attempts >= dr.cfg.MaxAttempts:
+		dl.Status = StatusFailed
+		dl.LastError = err.Error()
+
+		dr.log.Error(ctx, "webhook delivery", "status", "failed", "delivery_id", dl.ID, "subscription_id", sub.ID, "event_type", dl.EventType, "attempts", dl.Attempts, "msg", err)
+
+	default:
+		dl.LastError = err.Error()
+		dl.NextAttemptAt = now.Add(delegate.Backoff(dl.Attempts, dr.cfg.MinBackoff, dr.cfg.MaxBackoff))
+
+		dr.log.Warn(ctx, "webhook delivery", "status", "retrying", "delivery_id", dl.ID, "subscription_id", sub.ID, "attempts", dl.Attempts, "next_attempt_at", dl.NextAttemptAt, "msg", err)
+	}
+
+	if err := dr.core.storer.UpdateDelivery(ctx, dl); err != nil {
+		dr.log.Error(ctx, "webhook delivery", "status", "updating delivery", "delivery_id", dl.ID, "msg", err)
+	}
+
+	sub, err = dr.core.storer.RecordAttempt(ctx, sub.ID, dl.Status == StatusDelivered, dr.cfg.DisableAfter, now)
+	if err != nil {
+		dr.log.Error(ctx, "webhook delivery", "status", "recording attempt", "subscription_id", dl.SubscriptionID, "msg", err)
+		return
+	}
+
+	if !sub.Enabled && dl.Status != StatusDelivered && sub.Failures == dr.cfg.DisableAfter {
+		dr.log.Warn(ctx, "webhook delivery", "status", "subscription disabled", "subscription_id", sub.ID, "failures", sub.Failures)
+	}
+}
+
+// send posts the payload of the delivery signed with the secret of the
+// subscription. Only a 2xx response is a successful delivery.
+func (dr *Deliverer) send(ctx context.Context, sub Subscription, dl Delivery) (int, error) {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(dl.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, dl.EventID.String())
+	req.Header.Set(HeaderEvent, dl.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, dl.Payload))
+
+	resp, err := dr.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("send: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Draining the body lets the connection be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/google/uuid"
+)
+
+// eventNamespace is the namespace the IDs of the events are derived in.
+var eventNamespace = uuid.MustParse("6f1d3c2e-8f4b-4d57-9a43-3b0c8e2a7d51")
+
+// envelope represents the body of a delivery. Data holds the parameters of
+// the action in the version of its schema.
+type envelope struct {
+	ID        uuid.UUID      `json:"id"`
+	Type      string         `json:"type"`
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Data      jsontext.Value `json:"data"`
+}
+
+// registerDelegateFunctions will register the function queueing the
+// deliveries for every registered schema with the delegate system. If the
+// core was constructed for query only, there won't be a delegate provided.
+func (c *Core) registerDelegateFunctions(d *delegate.Delegate) {
+	if d == nil {
+		return
+	}
+
+	for _, s := range delegate.Schemas() {
+		d.Register(s.Domain, s.Action, c.actionEvent)
+	}
+}
+
+// actionEvent is executed by the other domains indirectly for every event.
+// It queues a delivery of the event to every enabled subscription for its
+// type.
+func (c *Core) actionEvent(ctx context.Context, data delegate.Data) error {
+	ev, err := newEvent(data, time.Now())
+	if err != nil {
+		return fmt.Errorf("event: %w", err)
+	}
+
+	if err := c.storer.CreateDeliveries(ctx, ev, time.Now()); err != nil {
+		return fmt.Errorf("createdeliveries: eventID[%s]: %w", ev.ID, err)
+	}
+
+	return nil
+}
+
+// newEvent constructs the event for the data. The delegate delivers the data
+// at least once, so the ID of the event is derived from the data to queue a
+// single delivery per subscription when the same data is delivered again.
+func newEvent(data delegate.Data, now time.Time) (Event, error) {
+	eventType := EventType(data.Domain, data.Action)
+
+	id := uuid.NewSHA1(eventNamespace, fmt.Appendf(nil, "%s.%d.%s", eventType, data.Version, data.RawParams))
+
+	env := envelope{
+		ID:        id,
+		Type:      eventType,
+		Version:   data.Version,
+		CreatedAt: now.UTC(),
+		Data:      jsontext.Value(data.RawParams),
+	}
+
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return Event{}, err
+	}
+
+	ev := Event{
+		ID:      id,
+		Type:    eventType,
+		Payload: payload,
+	}
+
+	return ev, nil
+}
@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of statuses of a delivery.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Subscription represents a partner system that is sent the events of the
+// specified types. Failures counts the delivery attempts that failed in a
+// row, the subscription is disabled when there are too many.
+type Subscription struct {
+	ID          uuid.UUID
+	URL         string
+	EventTypes  []string
+	Secret      string `json:"-" log:"redact"`
+	Enabled     bool
+	Failures    int
+	DateCreated time.Time
+	DateUpdated time.Time
+	Version     int
+}
+
+// NewSubscription contains information needed to create a new subscription.
+type NewSubscription struct {
+	URL        string
+	EventTypes []string
+	Secret     string `log:"redact"`
+}
+
+// UpdateSubscription contains information needed to update a subscription.
+type UpdateSubscription struct {
+	URL        *string
+	EventTypes []string
+	Secret     *string `log:"redact"`
+	Enabled    *bool
+}
+
+// Event represents an event to be delivered to the subscriptions for its
+// type. Payload is the body every delivery of the event sends.
+type Event struct {
+	ID      uuid.UUID
+	Type    string
+	Payload []byte
+}
+
+// Delivery represents the delivery of an event to a subscription and the
+// outcome of its last attempt.
+type Delivery struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	EventID        uuid.UUID
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	StatusCode     int
+	LastError      string
+	NextAttemptAt  time.Time
+	DateCreated    time.Time
+	DateUpdated    time.Time
+}
@@ -0,0 +1,116 @@
+package webhookdb
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/core/crud/webhook"
+	"github.com/fadhilijuma/gateone-service/business/data/sqldb/dbarray"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type dbSubscription struct {
+	ID          uuid.UUID      `db:"subscription_id"`
+	URL         string         `db:"url"`
+	EventTypes  dbarray.String `db:"event_types"`
+	Secret      string         `db:"secret" log:"redact"`
+	Enabled     bool           `db:"enabled"`
+	Failures    int            `db:"failures"`
+	DateCreated time.Time      `db:"date_created"`
+	DateUpdated time.Time      `db:"date_updated"`
+	Version     int            `db:"version"`
+}
+
+func toDBSubscription(sub webhook.Subscription) dbSubscription {
+	return dbSubscription{
+		ID:          sub.ID,
+		URL:         sub.URL,
+		EventTypes:  sub.EventTypes,
+		Secret:      sub.Secret,
+		Enabled:     sub.Enabled,
+		Failures:    sub.Failures,
+		DateCreated: sub.DateCreated.UTC(),
+		DateUpdated: sub.DateUpdated.UTC(),
+		Version:     sub.Version,
+	}
+}
+
+func toCoreSubscription(dbSub dbSubscription) webhook.Subscription {
+	return webhook.Subscription{
+		ID:          dbSub.ID,
+		URL:         dbSub.URL,
+		EventTypes:  dbSub.EventTypes,
+		Secret:      dbSub.Secret,
+		Enabled:     dbSub.Enabled,
+		Failures:    dbSub.Failures,
+		DateCreated: dbSub.DateCreated.In(time.Local),
+		DateUpdated: dbSub.DateUpdated.In(time.Local),
+		Version:     dbSub.Version,
+	}
+}
+
+func toCoreSubscriptionSlice(dbSubs []dbSubscription) []webhook.Subscription {
+	subs := make([]webhook.Subscription, len(dbSubs))
+	for i, dbSub := range dbSubs {
+		subs[i] = toCoreSubscription(dbSub)
+	}
+
+	return subs
+}
+
+type dbDelivery struct {
+	ID             uuid.UUID `db:"delivery_id"`
+	SubscriptionID uuid.UUID `db:"subscription_id"`
+	EventID        uuid.UUID `db:"event_id"`
+	EventType      string    `db:"event_type"`
+	Payload        []byte    `db:"payload"`
+	Status         string    `db:"status"`
+	Attempts       int       `db:"attempts"`
+	StatusCode     int       `db:"status_code"`
+	LastError      string    `db:"last_error"`
+	NextAttemptAt  time.Time `db:"next_attempt_at"`
+	DateCreated    time.Time `db:"date_created"`
+	DateUpdated    time.Time `db:"date_updated"`
+}
+
+func toDBDelivery(dl webhook.Delivery) dbDelivery {
+	return dbDelivery{
+		ID:             dl.ID,
+		SubscriptionID: dl.SubscriptionID,
+		EventID:        dl.EventID,
+		EventType:      dl.EventType,
+		Payload:        dl.Payload,
+		Status:         dl.Status,
+		Attempts:       dl.Attempts,
+		StatusCode:     dl.StatusCode,
+		LastError:      dl.LastError,
+		NextAttemptAt:  dl.NextAttemptAt.UTC(),
+		DateCreated:    dl.DateCreated.UTC(),
+		DateUpdated:    dl.DateUpdated.UTC(),
+	}
+}
+
+func toCoreDelivery(dbDL dbDelivery) webhook.Delivery {
+	return webhook.Delivery{
+		ID:             dbDL.ID,
+		SubscriptionID: dbDL.SubscriptionID,
+		EventID:        dbDL.EventID,
+		EventType:      dbDL.EventType,
+		Payload:        dbDL.Payload,
+		Status:         dbDL.Status,
+		Attempts:       dbDL.Attempts,
+		StatusCode:     dbDL.StatusCode,
+		LastError:      dbDL.LastError,
+		NextAttemptAt:  dbDL.NextAttemptAt.In(time.Local),
+		DateCreated:    dbDL.DateCreated.In(time.Local),
+		DateUpdated:    dbDL.DateUpdated.In(time.Local),
+	}
+}
+
+func toCoreDeliverySlice(dbDLs []dbDelivery) []webhook.Delivery {
+	dls := make([]webhook.Delivery, len(dbDLs))
+	for i, dbDL := range dbDLs {
+		dls[i] = toCoreDelivery(dbDL)
+	}
+
+	return dls
+}
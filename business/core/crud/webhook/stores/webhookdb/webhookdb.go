@@ -0,0 +1,402 @@
+// Package webhookdb contains the database backed storage of the webhook
+// subscriptions and their deliveries.
+package webhookdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/webhook"
+	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for webhook database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (webhook.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new subscription into the database.
+func (s *Store) Create(ctx context.Context, sub webhook.Subscription) error {
+	const q = `
+	INSERT INTO webhook_subscriptions
+		(subscription_id, url, event_types, secret, enabled, failures, date_created, date_updated, version)
+	VALUES
+		(:subscription_id, :url, :event_types, :secret, :enabled, :failures, :date_created, :date_updated, :version)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBSubscription(sub)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a subscription document in the database.
+func (s *Store) Update(ctx context.Context, sub webhook.Subscription) error {
+	const q = `
+	UPDATE
+		webhook_subscriptions
+	SET
+		"url" = :url,
+		"event_types" = :event_types,
+		"secret" = :secret,
+		"enabled" = :enabled,
+		"failures" = :failures,
+		"date_updated" = :date_updated,
+		"version" = "version" + 1
+	WHERE
+		subscription_id = :subscription_id AND version = :version
+	RETURNING
+		version`
+
+	var dest struct {
+		Version int `db:"version"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, toDBSubscription(sub), &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", webhook.ErrVersionConflict)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a subscription and its deliveries from the database.
+func (s *Store) Delete(ctx context.Context, sub webhook.Subscription) error {
+	data := struct {
+		ID      uuid.UUID `db:"subscription_id"`
+		Version int       `db:"version"`
+	}{
+		ID:      sub.ID,
+		Version: sub.Version,
+	}
+
+	const q = `
+	DELETE FROM
+		webhook_subscriptions
+	WHERE
+		subscription_id = :subscription_id AND version = :version
+	RETURNING
+		subscription_id`
+
+	var dest struct {
+		ID uuid.UUID `db:"subscription_id"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", webhook.ErrVersionConflict)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing subscriptions from the database.
+func (s *Store) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]webhook.Subscription, error) {
+	data := map[string]interface{}{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		subscription_id, url, event_types, secret, enabled, failures, date_created, date_updated, version
+	FROM
+		webhook_subscriptions
+	ORDER BY
+		date_created, subscription_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var dbSubs []dbSubscription
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbSubs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreSubscriptionSlice(dbSubs), nil
+}
+
+// Count returns the total number of subscriptions in the DB.
+func (s *Store) Count(ctx context.Context) (int, error) {
+	data := map[string]interface{}{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		webhook_subscriptions`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryByID gets the specified subscription from the database.
+func (s *Store) QueryByID(ctx context.Context, subID uuid.UUID) (webhook.Subscription, error) {
+	data := struct {
+		ID uuid.UUID `db:"subscription_id"`
+	}{
+		ID: subID,
+	}
+
+	const q = `
+	SELECT
+		subscription_id, url, event_types, secret, enabled, failures, date_created, date_updated, version
+	FROM
+		webhook_subscriptions
+	WHERE
+		subscription_id = :subscription_id`
+
+	var dbSub dbSubscription
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbSub); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return webhook.Subscription{}, fmt.Errorf("namedquerystruct: %w", webhook.ErrNotFound)
+		}
+		return webhook.Subscription{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreSubscription(dbSub), nil
+}
+
+// RecordAttempt clears the failures of the subscription after a successful
+// attempt, or counts the failed attempt and disables the subscription once
+// disableAfter attempts failed in a row. It's a single statement so
+// concurrent attempts don't lose a count. The version moves on too so an
+// update made from a copy read before the attempt conflicts instead of
+// overwriting the failures.
+func (s *Store) RecordAttempt(ctx context.Context, subID uuid.UUID, succeeded bool, disableAfter int, now time.Time) (webhook.Subscription, error) {
+	data := struct {
+		ID           uuid.UUID `db:"subscription_id"`
+		Succeeded    bool      `db:"succeeded"`
+		DisableAfter int       `db:"disable_after"`
+		Now          time.Time `db:"now"`
+	}{
+		ID:           subID,
+		Succeeded:    succeeded,
+		DisableAfter: disableAfter,
+		Now:          now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		webhook_subscriptions
+	SET
+		failures = CASE WHEN :succeeded THEN 0 ELSE failures + 1 END,
+		enabled = enabled AND (:succeeded OR failures + 1 < :disable_after),
+		date_updated = :now,
+		version = version + 1
+	WHERE
+		subscription_id = :subscription_id
+	RETURNING
+		subscription_id, url, event_types, secret, enabled, failures, date_created, date_updated, version`
+
+	var dbSub dbSubscription
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbSub); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return webhook.Subscription{}, fmt.Errorf("namedquerystruct: %w", webhook.ErrNotFound)
+		}
+		return webhook.Subscription{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreSubscription(dbSub), nil
+}
+
+// =============================================================================
+
+// CreateDeliveries queues a delivery of the event to every enabled
+// subscription for its type. A subscription already queued the event isn't
+// queued it again.
+func (s *Store) CreateDeliveries(ctx context.Context, ev webhook.Event, now time.Time) error {
+	data := struct {
+		EventID   uuid.UUID `db:"event_id"`
+		EventType string    `db:"event_type"`
+		Payload   []byte    `db:"payload"`
+		Status    string    `db:"status"`
+		Now       time.Time `db:"now"`
+	}{
+		EventID:   ev.ID,
+		EventType: ev.Type,
+		Payload:   ev.Payload,
+		Status:    webhook.StatusPending,
+		Now:       now.UTC(),
+	}
+
+	const q = `
+	INSERT INTO webhook_deliveries
+		(delivery_id, subscription_id, event_id, event_type, payload, status, attempts, status_code, last_error, next_attempt_at, date_created, date_updated)
+	SELECT
+		gen_random_uuid(), subscription_id, :event_id, :event_type, :payload, :status, 0, 0, '', :now, :now, :now
+	FROM
+		webhook_subscriptions
+	WHERE
+		enabled AND :event_type = ANY(event_types)
+	ON CONFLICT (subscription_id, event_id) DO NOTHING`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDeliveries returns up to limit pending deliveries to enabled
+// subscriptions that are due, in the order they became due, and makes them
+// unavailable until the lease ends so no other deliverer claims them
+// meanwhile. Rows claimed by a concurrent claim are skipped instead of
+// waited for.
+func (s *Store) ClaimDeliveries(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]webhook.Delivery, error) {
+	data := struct {
+		Status     string    `db:"status"`
+		Now        time.Time `db:"now"`
+		LeaseUntil time.Time `db:"lease_until"`
+		Limit      int       `db:"limit"`
+	}{
+		Status:     webhook.StatusPending,
+		Now:        now.UTC(),
+		LeaseUntil: leaseUntil.UTC(),
+		Limit:      limit,
+	}
+
+	const q = `
+	UPDATE
+		webhook_deliveries
+	SET
+		next_attempt_at = :lease_until
+	WHERE
+		delivery_id IN (
+			SELECT
+				d.delivery_id
+			FROM
+				webhook_deliveries AS d
+			JOIN
+				webhook_subscriptions AS s ON s.subscription_id = d.subscription_id
+			WHERE
+				d.status = :status AND d.next_attempt_at <= :now AND s.enabled
+			ORDER BY
+				d.next_attempt_at
+			LIMIT :limit
+			FOR UPDATE OF d SKIP LOCKED
+		)
+	RETURNING
+		delivery_id, subscription_id, event_id, event_type, payload, status, attempts, status_code, last_error, next_attempt_at, date_created, date_updated`
+
+	var dbDLs []dbDelivery
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbDLs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreDeliverySlice(dbDLs), nil
+}
+
+// UpdateDelivery records the outcome of the last attempt of the delivery.
+func (s *Store) UpdateDelivery(ctx context.Context, dl webhook.Delivery) error {
+	const q = `
+	UPDATE
+		webhook_deliveries
+	SET
+		status = :status,
+		attempts = :attempts,
+		status_code = :status_code,
+		last_error = :last_error,
+		next_attempt_at = :next_attempt_at,
+		date_updated = :date_updated
+	WHERE
+		delivery_id = :delivery_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBDelivery(dl)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryDeliveries retrieves the deliveries to the subscription from the
+// database, the most recent first.
+func (s *Store) QueryDeliveries(ctx context.Context, subID uuid.UUID, pageNumber int, rowsPerPage int) ([]webhook.Delivery, error) {
+	data := map[string]interface{}{
+		"subscription_id": subID,
+		"offset":          (pageNumber - 1) * rowsPerPage,
+		"rows_per_page":   rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		delivery_id, subscription_id, event_id, event_type, payload, status, attempts, status_code, last_error, next_attempt_at, date_created, date_updated
+	FROM
+		webhook_deliveries
+	WHERE
+		subscription_id = :subscription_id
+	ORDER BY
+		date_created DESC, delivery_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var dbDLs []dbDelivery
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbDLs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreDeliverySlice(dbDLs), nil
+}
+
+// CountDeliveries returns the total number of deliveries to the
+// subscription in the DB.
+func (s *Store) CountDeliveries(ctx context.Context, subID uuid.UUID) (int, error) {
+	data := map[string]interface{}{
+		"subscription_id": subID,
+	}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		webhook_deliveries
+	WHERE
+		subscription_id = :subscription_id`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
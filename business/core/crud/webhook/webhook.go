@@ -0,0 +1,249 @@
+// Package webhook provides the subscriptions partner systems use to be sent
+// the domain events, and the delivery of the events to them.
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain represents the name of this domain, which the audit log records
+// changes to subscriptions under.
+const Domain = "webhook"
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound         = errors.New("subscription not found")
+	ErrUnknownEventType = errors.New("unknown event type")
+	ErrInvalidURL       = errors.New("invalid url")
+	ErrVersionConflict  = errors.New("subscription was changed by another request")
+)
+
+// Storer interface declares the behavior this package needs to persists and
+// retrieve data.
+type Storer interface {
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+	Create(ctx context.Context, sub Subscription) error
+	Update(ctx context.Context, sub Subscription) error
+	Delete(ctx context.Context, sub Subscription) error
+	Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Subscription, error)
+	Count(ctx context.Context) (int, error)
+	QueryByID(ctx context.Context, subID uuid.UUID) (Subscription, error)
+	RecordAttempt(ctx context.Context, subID uuid.UUID, succeeded bool, disableAfter int, now time.Time) (Subscription, error)
+	CreateDeliveries(ctx context.Context, ev Event, now time.Time) error
+	ClaimDeliveries(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]Delivery, error)
+	UpdateDelivery(ctx context.Context, dl Delivery) error
+	QueryDeliveries(ctx context.Context, subID uuid.UUID, pageNumber int, rowsPerPage int) ([]Delivery, error)
+	CountDeliveries(ctx context.Context, subID uuid.UUID) (int, error)
+}
+
+// Core manages the set of APIs for webhook access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+}
+
+// NewCore constructs a webhook core API for use. When a delegate is
+// provided, the events of every registered schema are queued for delivery
+// to the subscriptions for them. Only one core per delegate may be
+// constructed with it, cores for managing subscriptions are constructed
+// without one.
+func NewCore(log *logger.Logger, delegate *delegate.Delegate, storer Storer) *Core {
+	c := Core{
+		log:    log,
+		storer: storer,
+	}
+
+	c.registerDelegateFunctions(delegate)
+
+	return &c
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	storer, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	core := Core{
+		log:    c.log,
+		storer: storer,
+	}
+
+	return &core, nil
+}
+
+// Create adds a new subscription to the system.
+func (c *Core) Create(ctx context.Context, ns NewSubscription) (Subscription, error) {
+	if err := checkURL(ns.URL); err != nil {
+		return Subscription{}, err
+	}
+
+	if err := checkEventTypes(ns.EventTypes); err != nil {
+		return Subscription{}, err
+	}
+
+	now := time.Now()
+
+	sub := Subscription{
+		ID:          uuid.New(),
+		URL:         ns.URL,
+		EventTypes:  ns.EventTypes,
+		Secret:      ns.Secret,
+		Enabled:     true,
+		DateCreated: now,
+		DateUpdated: now,
+		Version:     1,
+	}
+
+	if err := c.storer.Create(ctx, sub); err != nil {
+		return Subscription{}, fmt.Errorf("create: %w", err)
+	}
+
+	return sub, nil
+}
+
+// Update modifies information about a subscription. Enabling a subscription
+// clears its failures.
+func (c *Core) Update(ctx context.Context, sub Subscription, us UpdateSubscription) (Subscription, error) {
+	if us.URL != nil {
+		if err := checkURL(*us.URL); err != nil {
+			return Subscription{}, err
+		}
+		sub.URL = *us.URL
+	}
+
+	if us.EventTypes != nil {
+		if err := checkEventTypes(us.EventTypes); err != nil {
+			return Subscription{}, err
+		}
+		sub.EventTypes = us.EventTypes
+	}
+
+	if us.Secret != nil {
+		sub.Secret = *us.Secret
+	}
+
+	if us.Enabled != nil {
+		if *us.Enabled && !sub.Enabled {
+			sub.Failures = 0
+		}
+		sub.Enabled = *us.Enabled
+	}
+
+	sub.DateUpdated = time.Now()
+
+	if err := c.storer.Update(ctx, sub); err != nil {
+		return Subscription{}, fmt.Errorf("update: %w", err)
+	}
+
+	sub.Version++
+
+	return sub, nil
+}
+
+// Delete removes the specified subscription and its deliveries.
+func (c *Core) Delete(ctx context.Context, sub Subscription) error {
+	if err := c.storer.Delete(ctx, sub); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing subscriptions.
+func (c *Core) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Subscription, error) {
+	subs, err := c.storer.Query(ctx, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return subs, nil
+}
+
+// Count returns the total number of subscriptions.
+func (c *Core) Count(ctx context.Context) (int, error) {
+	return c.storer.Count(ctx)
+}
+
+// QueryByID finds the subscription by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, subID uuid.UUID) (Subscription, error) {
+	sub, err := c.storer.QueryByID(ctx, subID)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("query: subscriptionID[%s]: %w", subID, err)
+	}
+
+	return sub, nil
+}
+
+// QueryDeliveries retrieves the log of deliveries to the subscription, the
+// most recent first.
+func (c *Core) QueryDeliveries(ctx context.Context, subID uuid.UUID, pageNumber int, rowsPerPage int) ([]Delivery, error) {
+	dls, err := c.storer.QueryDeliveries(ctx, subID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: subscriptionID[%s]: %w", subID, err)
+	}
+
+	return dls, nil
+}
+
+// CountDeliveries returns the total number of deliveries to the
+// subscription.
+func (c *Core) CountDeliveries(ctx context.Context, subID uuid.UUID) (int, error) {
+	return c.storer.CountDeliveries(ctx, subID)
+}
+
+// =============================================================================
+
+// EventType returns the type subscriptions use for the events of a domain
+// and action, such as patient.created.
+func EventType(domain string, action string) string {
+	return domain + "." + action
+}
+
+// checkURL makes sure deliveries are only posted over http or https to a
+// host.
+func checkURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme must be http or https", ErrInvalidURL)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("%w: no host", ErrInvalidURL)
+	}
+
+	return nil
+}
+
+// checkEventTypes makes sure every event type is one of the registered
+// schemas.
+func checkEventTypes(eventTypes []string) error {
+	if len(eventTypes) == 0 {
+		return fmt.Errorf("%w: no event types", ErrUnknownEventType)
+	}
+
+	for _, et := range eventTypes {
+		domain, action, _ := strings.Cut(et, ".")
+		if _, exists := delegate.LookupSchema(domain, action); !exists {
+			return fmt.Errorf("%w: %q", ErrUnknownEventType, et)
+		}
+	}
+
+	return nil
+}
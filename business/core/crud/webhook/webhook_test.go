@@ -0,0 +1,568 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/patient"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/webhook"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/worker"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps the subscriptions and deliveries in memory.
+type memoryStore struct {
+	mu         sync.Mutex
+	subs       map[uuid.UUID]webhook.Subscription
+	deliveries []webhook.Delivery
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		subs: make(map[uuid.UUID]webhook.Subscription),
+	}
+}
+
+func (ms *memoryStore) ExecuteUnderTransaction(tx transaction.Transaction) (webhook.Storer, error) {
+	return ms, nil
+}
+
+func (ms *memoryStore) Create(ctx context.Context, sub webhook.Subscription) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.subs[sub.ID] = sub
+	return nil
+}
+
+func (ms *memoryStore) Update(ctx context.Context, sub webhook.Subscription) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.subs[sub.ID].Version != sub.Version {
+		return webhook.ErrVersionConflict
+	}
+
+	sub.Version++
+	ms.subs[sub.ID] = sub
+	return nil
+}
+
+func (ms *memoryStore) Delete(ctx context.Context, sub webhook.Subscription) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.subs[sub.ID].Version != sub.Version {
+		return webhook.ErrVersionConflict
+	}
+
+	delete(ms.subs, sub.ID)
+	return nil
+}
+
+func (ms *memoryStore) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]webhook.Subscription, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var subs []webhook.Subscription
+	for _, sub := range ms.subs {
+		subs = append(subs, sub)
+	}
+
+	return subs, nil
+}
+
+func (ms *memoryStore) Count(ctx context.Context) (int, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return len(ms.subs), nil
+}
+
+func (ms *memoryStore) QueryByID(ctx context.Context, subID uuid.UUID) (webhook.Subscription, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	sub, exists := ms.subs[subID]
+	if !exists {
+		return webhook.Subscription{}, webhook.ErrNotFound
+	}
+
+	return sub, nil
+}
+
+func (ms *memoryStore) RecordAttempt(ctx context.Context, subID uuid.UUID, succeeded bool, disableAfter int, now time.Time) (webhook.Subscription, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	sub, exists := ms.subs[subID]
+	if !exists {
+		return webhook.Subscription{}, webhook.ErrNotFound
+	}
+
+	switch {
+	case succeeded:
+		sub.Failures = 0
+	default:
+		sub.Failures++
+		if sub.Failures >= disableAfter {
+			sub.Enabled = false
+		}
+	}
+	sub.Version++
+	ms.subs[subID] = sub
+
+	return sub, nil
+}
+
+func (ms *memoryStore) CreateDeliveries(ctx context.Context, ev webhook.Event, now time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, sub := range ms.subs {
+		if !sub.Enabled || !slices.Contains(sub.EventTypes, ev.Type) {
+			continue
+		}
+
+		queued := slices.ContainsFunc(ms.deliveries, func(dl webhook.Delivery) bool {
+			return dl.SubscriptionID == sub.ID && dl.EventID == ev.ID
+		})
+		if queued {
+			continue
+		}
+
+		ms.deliveries = append(ms.deliveries, webhook.Delivery{
+			ID:             uuid.New(),
+			SubscriptionID: sub.ID,
+			EventID:        ev.ID,
+			EventType:      ev.Type,
+			Payload:        ev.Payload,
+			Status:         webhook.StatusPending,
+			NextAttemptAt:  now,
+			DateCreated:    now,
+			DateUpdated:    now,
+		})
+	}
+
+	return nil
+}
+
+func (ms *memoryStore) ClaimDeliveries(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]webhook.Delivery, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var dls []webhook.Delivery
+	for i, dl := range ms.deliveries {
+		if len(dls) == limit || dl.Status != webhook.StatusPending || dl.NextAttemptAt.After(now) || !ms.subs[dl.SubscriptionID].Enabled {
+			continue
+		}
+
+		ms.deliveries[i].NextAttemptAt = leaseUntil
+		dls = append(dls, ms.deliveries[i])
+	}
+
+	return dls, nil
+}
+
+func (ms *memoryStore) UpdateDelivery(ctx context.Context, dl webhook.Delivery) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for i := range ms.deliveries {
+		if ms.deliveries[i].ID == dl.ID {
+			ms.deliveries[i] = dl
+		}
+	}
+
+	return nil
+}
+
+func (ms *memoryStore) QueryDeliveries(ctx context.Context, subID uuid.UUID, pageNumber int, rowsPerPage int) ([]webhook.Delivery, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var dls []webhook.Delivery
+	for _, dl := range ms.deliveries {
+		if dl.SubscriptionID == subID {
+			dls = append(dls, dl)
+		}
+	}
+
+	return dls, nil
+}
+
+func (ms *memoryStore) CountDeliveries(ctx context.Context, subID uuid.UUID) (int, error) {
+	dls, err := ms.QueryDeliveries(ctx, subID, 1, 0)
+	return len(dls), err
+}
+
+// =============================================================================
+
+// receiver records the deliveries it's sent and responds with the status.
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	secret   string
+	received []http.Header
+	errs     []error
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rc.errs = append(rc.errs, err)
+	}
+
+	if err := webhook.Verify(rc.secret, r.Header, body, time.Minute, time.Now()); err != nil {
+		rc.errs = append(rc.errs, err)
+	}
+
+	rc.received = append(rc.received, r.Header)
+	w.WriteHeader(rc.status)
+}
+
+func (rc *receiver) deliveries() ([]http.Header, []error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return rc.received, rc.errs
+}
+
+func newDeliverer(t *testing.T, core *webhook.Core, client *http.Client) (*webhook.Deliverer, *worker.Worker) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	wrk, err := worker.New(2)
+	if err != nil {
+		t.Fatalf("Should be able to construct the worker: %s", err)
+	}
+
+	dr, err := webhook.NewDeliverer(log, core, wrk, client, webhook.DelivererConfig{
+		Interval:     time.Millisecond,
+		BatchSize:    10,
+		Lease:        time.Second,
+		Timeout:      time.Second / 2,
+		MaxAttempts:  2,
+		DisableAfter: 3,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct the deliverer: %s", err)
+	}
+
+	return dr, wrk
+}
+
+// deliver sends the due deliveries and waits for them to complete.
+func deliver(t *testing.T, dr *webhook.Deliverer, wrk *worker.Worker) int {
+	n, err := dr.Deliver(context.Background())
+	if err != nil {
+		t.Fatalf("Should be able to deliver: %s", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for wrk.Running() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Should complete the deliveries: got %d running", wrk.Running())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	return n
+}
+
+func Test_Deliver(t *testing.T) {
+	ctx := context.Background()
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	rc := receiver{status: http.StatusNoContent, secret: "0123456789abcdef"}
+	srv := httptest.NewServer(&rc)
+	defer srv.Close()
+
+	store := newMemoryStore()
+	dlg := delegate.New(log)
+	core := webhook.NewCore(log, dlg, store)
+
+	if _, err := core.Create(ctx, webhook.NewSubscription{URL: srv.URL, EventTypes: []string{"patient.nope"}, Secret: rc.secret}); err == nil {
+		t.Fatalf("Should not be able to subscribe to an unknown event type")
+	}
+
+	sub, err := core.Create(ctx, webhook.NewSubscription{
+		URL:        srv.URL,
+		EventTypes: []string{webhook.EventType(patient.Domain, patient.ActionCreated), webhook.EventType(patient.Domain, patient.ActionHealed)},
+		Secret:     rc.secret,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create a subscription: %s", err)
+	}
+
+	pn := patient.Patient{ID: uuid.New(), Name: "Patient", Version: 1}
+
+	// The delegate delivers at least once, the same data twice is one event.
+	for range 2 {
+		if err := dlg.Call(ctx, patient.ActionCreatedData(pn)); err != nil {
+			t.Fatalf("Should be able to call the delegate: %s", err)
+		}
+	}
+
+	if err := dlg.Call(ctx, patient.ActionDeletedData(pn)); err != nil {
+		t.Fatalf("Should be able to call the delegate: %s", err)
+	}
+
+	dr, wrk := newDeliverer(t, core, &http.Client{})
+
+	if n := deliver(t, dr, wrk); n != 1 {
+		t.Fatalf("Should deliver the subscribed event once: got %d", n)
+	}
+
+	received, errs := rc.deliveries()
+	if len(errs) != 0 {
+		t.Fatalf("Should sign the delivery: got %v", errs)
+	}
+
+	dls, err := core.QueryDeliveries(ctx, sub.ID, 1, 10)
+	if err != nil || len(dls) != 1 {
+		t.Fatalf("Should be able to query the delivery: %v %v", dls, err)
+	}
+
+	if dls[0].Status != webhook.StatusDelivered || dls[0].Attempts != 1 || dls[0].StatusCode != http.StatusNoContent {
+		t.Errorf("Should record the delivery: got %+v", dls[0])
+	}
+
+	if received[0].Get(webhook.HeaderID) != dls[0].EventID.String() || received[0].Get(webhook.HeaderEvent) != "patient.created" {
+		t.Errorf("Should send the event ID and type: got %v", received[0])
+	}
+
+	if n := deliver(t, dr, wrk); n != 0 {
+		t.Errorf("Should not deliver an event twice: got %d", n)
+	}
+}
+
+func Test_Failures(t *testing.T) {
+	ctx := context.Background()
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	rc := receiver{status: http.StatusInternalServerError, secret: "0123456789abcdef"}
+	srv := httptest.NewServer(&rc)
+	defer srv.Close()
+
+	store := newMemoryStore()
+	dlg := delegate.New(log)
+	core := webhook.NewCore(log, dlg, store)
+
+	sub, err := core.Create(ctx, webhook.NewSubscription{
+		URL:        srv.URL,
+		EventTypes: []string{webhook.EventType(patient.Domain, patient.ActionCreated)},
+		Secret:     rc.secret,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create a subscription: %s", err)
+	}
+
+	dr, wrk := newDeliverer(t, core, &http.Client{})
+
+	create := func(name string) {
+		if err := dlg.Call(ctx, patient.ActionCreatedData(patient.Patient{ID: uuid.New(), Name: name})); err != nil {
+			t.Fatalf("Should be able to call the delegate: %s", err)
+		}
+	}
+
+	// The first event fails every attempt.
+	create("first")
+	for range 3 {
+		deliver(t, dr, wrk)
+	}
+
+	dls, err := core.QueryDeliveries(ctx, sub.ID, 1, 10)
+	if err != nil || len(dls) != 1 {
+		t.Fatalf("Should be able to query the delivery: %v %v", dls, err)
+	}
+
+	if dls[0].Status != webhook.StatusFailed || dls[0].Attempts != 2 || dls[0].StatusCode != http.StatusInternalServerError || dls[0].LastError == "" {
+		t.Errorf("Should fail the delivery after the max attempts: got %+v", dls[0])
+	}
+
+	// The next failure is the third in a row and disables the subscription.
+	create("second")
+	deliver(t, dr, wrk)
+
+	sub, err = core.QueryByID(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("Should be able to query the subscription: %s", err)
+	}
+
+	if sub.Enabled || sub.Failures != 3 {
+		t.Fatalf("Should disable the subscription: got %+v", sub)
+	}
+
+	create("third")
+	if n := deliver(t, dr, wrk); n != 0 {
+		t.Errorf("Should not deliver to a disabled subscription: got %d", n)
+	}
+
+	if total, _ := core.CountDeliveries(ctx, sub.ID); total != 2 {
+		t.Errorf("Should not queue events for a disabled subscription: got %d", total)
+	}
+
+	// Enabling the subscription clears the failures and resumes the pending
+	// delivery.
+	enabled := true
+	sub, err = core.Update(ctx, sub, webhook.UpdateSubscription{Enabled: &enabled})
+	if err != nil {
+		t.Fatalf("Should be able to enable the subscription: %s", err)
+	}
+
+	if !sub.Enabled || sub.Failures != 0 {
+		t.Fatalf("Should clear the failures: got %+v", sub)
+	}
+
+	rc.mu.Lock()
+	rc.status = http.StatusOK
+	rc.mu.Unlock()
+
+	if n := deliver(t, dr, wrk); n != 1 {
+		t.Errorf("Should resume the pending delivery: got %d", n)
+	}
+
+	if received, errs := rc.deliveries(); len(received) != 4 || len(errs) != 0 {
+		t.Errorf("Should send every attempt signed: got %d %v", len(received), errs)
+	}
+}
+
+func Test_BlockedAddress(t *testing.T) {
+	ctx := context.Background()
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	// The receiver listens on 127.0.0.1, which the client of the service
+	// must not connect to.
+	rc := receiver{status: http.StatusNoContent, secret: "0123456789abcdef"}
+	srv := httptest.NewServer(&rc)
+	defer srv.Close()
+
+	dlg := delegate.New(log)
+	core := webhook.NewCore(log, dlg, newMemoryStore())
+
+	sub, err := core.Create(ctx, webhook.NewSubscription{
+		URL:        srv.URL,
+		EventTypes: []string{webhook.EventType(patient.Domain, patient.ActionCreated)},
+		Secret:     rc.secret,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create a subscription: %s", err)
+	}
+
+	if err := dlg.Call(ctx, patient.ActionCreatedData(patient.Patient{ID: uuid.New(), Name: "Patient"})); err != nil {
+		t.Fatalf("Should be able to call the delegate: %s", err)
+	}
+
+	dr, wrk := newDeliverer(t, core, webhook.NewClient())
+	deliver(t, dr, wrk)
+
+	if received, _ := rc.deliveries(); len(received) != 0 {
+		t.Errorf("Should not connect to a loopback address: got %d deliveries", len(received))
+	}
+
+	dls, err := core.QueryDeliveries(ctx, sub.ID, 1, 10)
+	if err != nil || len(dls) != 1 {
+		t.Fatalf("Should be able to query the delivery: %v %v", dls, err)
+	}
+
+	if dls[0].Status == webhook.StatusDelivered || dls[0].StatusCode != 0 || !strings.Contains(dls[0].LastError, webhook.ErrBlockedAddress.Error()) {
+		t.Errorf("Should reject the delivery: got %+v", dls[0])
+	}
+}
+
+func Test_Subscription(t *testing.T) {
+	ctx := context.Background()
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	core := webhook.NewCore(log, nil, newMemoryStore())
+	eventTypes := []string{webhook.EventType(patient.Domain, patient.ActionCreated)}
+
+	for _, rawURL := range []string{"ftp://example.com/hook", "file:///etc/passwd", "http:///hook", "://"} {
+		_, err := core.Create(ctx, webhook.NewSubscription{URL: rawURL, EventTypes: eventTypes, Secret: "0123456789abcdef"})
+		if !errors.Is(err, webhook.ErrInvalidURL) {
+			t.Errorf("Should not be able to subscribe %q: got %v", rawURL, err)
+		}
+	}
+
+	sub, err := core.Create(ctx, webhook.NewSubscription{URL: "https://example.com/hook", EventTypes: eventTypes, Secret: "0123456789abcdef"})
+	if err != nil {
+		t.Fatalf("Should be able to create a subscription: %s", err)
+	}
+
+	if sub.Version != 1 {
+		t.Errorf("Should start at version 1: got %d", sub.Version)
+	}
+
+	ftpURL := "ftp://example.com/hook"
+	if _, err := core.Update(ctx, sub, webhook.UpdateSubscription{URL: &ftpURL}); !errors.Is(err, webhook.ErrInvalidURL) {
+		t.Errorf("Should not be able to update to an ftp url: got %v", err)
+	}
+
+	enabled := false
+	upSub, err := core.Update(ctx, sub, webhook.UpdateSubscription{Enabled: &enabled})
+	if err != nil {
+		t.Fatalf("Should be able to update the subscription: %s", err)
+	}
+
+	if upSub.Version != 2 {
+		t.Errorf("Should move to version 2: got %d", upSub.Version)
+	}
+
+	// The copy read before the update is stale.
+	if _, err := core.Update(ctx, sub, webhook.UpdateSubscription{Enabled: &enabled}); !errors.Is(err, webhook.ErrVersionConflict) {
+		t.Errorf("Should not be able to update a stale copy: got %v", err)
+	}
+
+	if err := core.Delete(ctx, sub); !errors.Is(err, webhook.ErrVersionConflict) {
+		t.Errorf("Should not be able to delete a stale copy: got %v", err)
+	}
+
+	if err := core.Delete(ctx, upSub); err != nil {
+		t.Errorf("Should be able to delete the subscription: %s", err)
+	}
+}
+
+func Test_Verify(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"id":"1"}`)
+
+	header := make(http.Header)
+
+	sign := func(secret string, ts time.Time) {
+		header.Set(webhook.HeaderTimestamp, strconv.FormatInt(ts.Unix(), 10))
+		header.Set(webhook.HeaderSignature, webhook.Sign(secret, ts.Unix(), body))
+	}
+
+	sign("secret", now)
+	if err := webhook.Verify("secret", header, body, time.Minute, now); err != nil {
+		t.Errorf("Should verify a signed delivery: %s", err)
+	}
+
+	if err := webhook.Verify("other", header, body, time.Minute, now); err == nil {
+		t.Errorf("Should not verify a delivery signed with another secret")
+	}
+
+	if err := webhook.Verify("secret", header, []byte(`{"id":"2"}`), time.Minute, now); err == nil {
+		t.Errorf("Should not verify a changed body")
+	}
+
+	sign("secret", now.Add(-time.Hour))
+	if err := webhook.Verify("secret", header, body, time.Minute, now); err == nil {
+		t.Errorf("Should not verify a delivery signed outside the tolerance")
+	}
+}
@@ -22,7 +22,6 @@ import (
 	"github.com/fadhilijuma/gateone-service/foundation/docker"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
-	"github.com/golang-jwt/jwt/v4"
 	"github.com/jmoiron/sqlx"
 	"math/rand"
 	"net/mail"
@@ -137,6 +136,7 @@ func NewTest(t *testing.T, c *docker.Container, testName string) *Test {
 		Log:       log,
 		DB:        db,
 		KeyLookup: &keyStore{},
+		Issuer:    "service project",
 	}
 	a, err := auth.New(cfg)
 	if err != nil {
@@ -182,17 +182,7 @@ func (test *Test) TokenV1(email string, pass string) string {
 		return ""
 	}
 
-	claims := auth.Claims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			Subject:   dbUsr.ID.String(),
-			Issuer:    "service project",
-			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		},
-		Roles: dbUsr.Roles,
-	}
-
-	token, err := test.V1.Auth.GenerateToken(kid, claims)
+	token, err := test.V1.Auth.IssueToken(kid, dbUsr)
 	if err != nil {
 		test.t.Fatal(err)
 	}
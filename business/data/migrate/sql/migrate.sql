@@ -72,3 +72,197 @@ CREATE TABLE roles
 
     PRIMARY KEY (role_id)
 );
+
+-- Version: 1.06
+-- Description: Notify listeners when a user is updated or deleted
+CREATE OR REPLACE FUNCTION notify_user_changed() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('user_changed', json_build_object('user_id', OLD.user_id, 'email', OLD.email)::TEXT);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_changed
+    AFTER UPDATE OR DELETE ON users
+    FOR EACH ROW EXECUTE FUNCTION notify_user_changed();
+
+-- Version: 1.07
+-- Description: Allow users without a region and index users by region
+ALTER TABLE users ALTER COLUMN region_id DROP NOT NULL;
+
+CREATE INDEX users_region_id_idx ON users (region_id);
+
+-- Version: 1.08
+-- Description: Create table rate_limits
+CREATE TABLE rate_limits
+(
+    key        TEXT             NOT NULL,
+    tokens     DOUBLE PRECISION NOT NULL,
+    allowed    BOOLEAN          NOT NULL,
+    updated_at TIMESTAMPTZ      NOT NULL,
+
+    PRIMARY KEY (key)
+);
+
+CREATE INDEX rate_limits_updated_at_idx ON rate_limits (updated_at);
+
+-- Version: 1.09
+-- Description: Create table idempotency_keys
+CREATE TABLE idempotency_keys
+(
+    scope        TEXT      NOT NULL,
+    key          TEXT      NOT NULL,
+    fingerprint  TEXT      NOT NULL,
+    status_code  INT       NOT NULL,
+    content_type TEXT      NOT NULL,
+    body         BYTEA     NOT NULL,
+    date_created TIMESTAMP NOT NULL,
+    expires_at   TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+
+-- Version: 1.10
+-- Description: Add a version to every domain table for optimistic concurrency
+ALTER TABLE users ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE patients ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE regions ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE conditions ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE roles ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+-- Version: 1.11
+-- Description: Create tables delegate_outbox and delegate_dead_letters
+CREATE TABLE delegate_outbox
+(
+    event_id     UUID      NOT NULL,
+    domain       TEXT      NOT NULL,
+    action       TEXT      NOT NULL,
+    params       BYTEA     NOT NULL,
+    attempts     INT       NOT NULL,
+    last_error   TEXT      NOT NULL,
+    available_at TIMESTAMP NOT NULL,
+    date_created TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (event_id)
+);
+
+CREATE INDEX delegate_outbox_available_at_idx ON delegate_outbox (available_at);
+
+CREATE TABLE delegate_dead_letters
+(
+    event_id     UUID      NOT NULL,
+    domain       TEXT      NOT NULL,
+    action       TEXT      NOT NULL,
+    params       BYTEA     NOT NULL,
+    attempts     INT       NOT NULL,
+    last_error   TEXT      NOT NULL,
+    available_at TIMESTAMP NOT NULL,
+    date_created TIMESTAMP NOT NULL,
+    date_failed  TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (event_id)
+);
+
+CREATE INDEX delegate_dead_letters_date_failed_idx ON delegate_dead_letters (date_failed);
+
+-- Version: 1.12
+-- Description: Add the version of the parameters to the delegate events
+ALTER TABLE delegate_outbox ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE delegate_dead_letters ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- Version: 1.13
+-- Description: Create the append only table audit_log
+CREATE TABLE audit_log
+(
+    seq          BIGINT    NOT NULL,
+    entry_id     UUID      NOT NULL,
+    actor_id     UUID      NOT NULL,
+    trace_id     TEXT      NOT NULL,
+    client_ip    TEXT      NOT NULL,
+    action       TEXT      NOT NULL,
+    entity_type  TEXT      NOT NULL,
+    entity_id    UUID      NOT NULL,
+    changes      TEXT      NOT NULL,
+    date_created TIMESTAMP NOT NULL,
+    prev_hash    TEXT      NOT NULL,
+    hash         TEXT      NOT NULL,
+
+    PRIMARY KEY (seq),
+    UNIQUE (entry_id)
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity_type, entity_id);
+CREATE INDEX audit_log_actor_id_idx ON audit_log (actor_id);
+
+CREATE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update_or_delete BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+-- Version: 1.14
+-- Description: Create the tables webhook_subscriptions and webhook_deliveries
+CREATE TABLE webhook_subscriptions
+(
+    subscription_id UUID      NOT NULL,
+    url             TEXT      NOT NULL,
+    event_types     TEXT[]    NOT NULL,
+    secret          TEXT      NOT NULL,
+    enabled         BOOLEAN   NOT NULL,
+    failures        INT       NOT NULL,
+    date_created    TIMESTAMP NOT NULL,
+    date_updated    TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (subscription_id)
+);
+
+CREATE TABLE webhook_deliveries
+(
+    delivery_id     UUID      NOT NULL,
+    subscription_id UUID      NOT NULL,
+    event_id        UUID      NOT NULL,
+    event_type      TEXT      NOT NULL,
+    payload         BYTEA     NOT NULL,
+    status          TEXT      NOT NULL,
+    attempts        INT       NOT NULL,
+    status_code     INT       NOT NULL,
+    last_error      TEXT      NOT NULL,
+    next_attempt_at TIMESTAMP NOT NULL,
+    date_created    TIMESTAMP NOT NULL,
+    date_updated    TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (delivery_id),
+    UNIQUE (subscription_id, event_id),
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(subscription_id) ON DELETE CASCADE
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, date_created);
+
+-- Version: 1.15
+-- Description: Add the replayed response headers to the idempotency keys
+ALTER TABLE idempotency_keys ADD COLUMN headers TEXT NOT NULL DEFAULT '{}';
+
+-- Version: 1.16
+-- Description: Create the single row table audit_head locked to append to the audit log
+CREATE TABLE audit_head
+(
+    id BOOLEAN NOT NULL DEFAULT TRUE,
+
+    PRIMARY KEY (id),
+    CHECK (id)
+);
+
+INSERT INTO audit_head (id) VALUES (TRUE);
+
+-- Version: 1.17
+-- Description: Add a version to the webhook subscriptions for optimistic concurrency
+ALTER TABLE webhook_subscriptions ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
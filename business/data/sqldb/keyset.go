@@ -0,0 +1,85 @@
+package sqldb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OrderColumn represents a column rows are ordered by.
+type OrderColumn struct {
+	Name       string
+	Descending bool
+}
+
+// OrderByClause returns the ORDER BY clause for the columns.
+func OrderByClause(cols []OrderColumn) string {
+	return orderByClause(cols, false)
+}
+
+// Keyset describes a keyset (seek) query. The columns must identify a row
+// uniquely, so the last column is normally the primary key. Values holds the
+// column values of the row the query continues from and is empty for the
+// first page. When Backward is true the rows before that row are selected.
+type Keyset struct {
+	Columns  []OrderColumn
+	Values   []string
+	Backward bool
+}
+
+// Where returns the condition selecting the rows positioned after the row
+// holding the keyset values, adding the values to data as named parameters.
+// It returns an empty string when there are no values.
+func (ks Keyset) Where(data map[string]any) (string, error) {
+	if len(ks.Values) == 0 {
+		return "", nil
+	}
+
+	if len(ks.Values) != len(ks.Columns) {
+		return "", fmt.Errorf("keyset has %d values for %d columns", len(ks.Values), len(ks.Columns))
+	}
+
+	// Rows are positioned after the values when they are past them in the
+	// first column or equal in the first columns and past them in the next,
+	// for example: a > :a OR (a = :a AND b > :b).
+	ors := make([]string, len(ks.Columns))
+	for i, col := range ks.Columns {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, fmt.Sprintf("%s = :keyset_%d", ks.Columns[j].Name, j))
+		}
+		ands = append(ands, fmt.Sprintf("%s %s :keyset_%d", col.Name, ks.operator(col), i))
+
+		ors[i] = "(" + strings.Join(ands, " AND ") + ")"
+		data[fmt.Sprintf("keyset_%d", i)] = ks.Values[i]
+	}
+
+	return "(" + strings.Join(ors, " OR ") + ")", nil
+}
+
+// OrderBy returns the ORDER BY clause for the keyset. Backward keysets are
+// ordered in reverse so the rows nearest the keyset values come first and the
+// caller must reverse the rows it receives.
+func (ks Keyset) OrderBy() string {
+	return orderByClause(ks.Columns, ks.Backward)
+}
+
+func orderByClause(cols []OrderColumn, reverse bool) string {
+	clauses := make([]string, len(cols))
+	for i, col := range cols {
+		direction := "ASC"
+		if col.Descending != reverse {
+			direction = "DESC"
+		}
+		clauses[i] = col.Name + " " + direction
+	}
+
+	return " ORDER BY " + strings.Join(clauses, ", ")
+}
+
+func (ks Keyset) operator(col OrderColumn) string {
+	if col.Descending != ks.Backward {
+		return "<"
+	}
+
+	return ">"
+}
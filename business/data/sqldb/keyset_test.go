@@ -0,0 +1,64 @@
+package sqldb_test
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
+	"testing"
+)
+
+func Test_Keyset(t *testing.T) {
+	cols := []sqldb.OrderColumn{{Name: "name", Descending: true}, {Name: "patient_id"}}
+
+	tests := []struct {
+		name    string
+		keyset  sqldb.Keyset
+		where   string
+		orderBy string
+	}{
+		{
+			name:    "start",
+			keyset:  sqldb.Keyset{Columns: cols},
+			where:   "",
+			orderBy: " ORDER BY name DESC, patient_id ASC",
+		},
+		{
+			name:    "forward",
+			keyset:  sqldb.Keyset{Columns: cols, Values: []string{"Bill", "1"}},
+			where:   "((name < :keyset_0) OR (name = :keyset_0 AND patient_id > :keyset_1))",
+			orderBy: " ORDER BY name DESC, patient_id ASC",
+		},
+		{
+			name:    "backward",
+			keyset:  sqldb.Keyset{Columns: cols, Values: []string{"Bill", "1"}, Backward: true},
+			where:   "((name > :keyset_0) OR (name = :keyset_0 AND patient_id < :keyset_1))",
+			orderBy: " ORDER BY name ASC, patient_id DESC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := map[string]any{}
+
+			where, err := tt.keyset.Where(data)
+			if err != nil {
+				t.Fatalf("Should be able to build the condition: %s", err)
+			}
+
+			if where != tt.where {
+				t.Errorf("Should get the condition:\n got: %s\nwant: %s", where, tt.where)
+			}
+
+			if orderBy := tt.keyset.OrderBy(); orderBy != tt.orderBy {
+				t.Errorf("Should get the order:\n got: %s\nwant: %s", orderBy, tt.orderBy)
+			}
+
+			if len(data) != len(tt.keyset.Values) {
+				t.Errorf("Should add a parameter for every value: got %v", data)
+			}
+		})
+	}
+
+	ks := sqldb.Keyset{Columns: cols, Values: []string{"Bill"}}
+	if _, err := ks.Where(map[string]any{}); err == nil {
+		t.Error("Should not accept fewer values than columns.")
+	}
+}
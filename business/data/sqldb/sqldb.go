@@ -9,13 +9,19 @@ import (
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
 	"net/url"
+	"reflect"
+	"runtime"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // lib/pq errorCodeNames
@@ -131,10 +137,13 @@ func NamedExecContext(ctx context.Context, log *logger.Logger, db sqlx.ExtContex
 		}
 	}()
 
-	ctx, span := web.AddSpan(ctx, "business.sys.database.exec", attribute.String("query", q))
-	defer span.End()
+	ctx, span := querySpan(ctx, "business.sys.database.exec", query)
+	defer func() {
+		endSpan(span, err)
+	}()
 
-	if _, err := sqlx.NamedExecContext(ctx, db, query, data); err != nil {
+	res, err := sqlx.NamedExecContext(ctx, db, query, data)
+	if err != nil {
 		if pqerr, ok := err.(*pgconn.PgError); ok {
 			switch pqerr.Code {
 			case undefinedTable:
@@ -146,6 +155,10 @@ func NamedExecContext(ctx context.Context, log *logger.Logger, db sqlx.ExtContex
 		return err
 	}
 
+	if rows, err := res.RowsAffected(); err == nil {
+		span.SetAttributes(attribute.Int64("db.rows", rows))
+	}
+
 	return nil
 }
 
@@ -178,8 +191,10 @@ func namedQuerySlice[T any](ctx context.Context, log *logger.Logger, db sqlx.Ext
 		}
 	}()
 
-	ctx, span := web.AddSpan(ctx, "business.sys.database.queryslice", attribute.String("query", q))
-	defer span.End()
+	ctx, span := querySpan(ctx, "business.sys.database.queryslice", query)
+	defer func() {
+		endSpan(span, err)
+	}()
 
 	var rows *sqlx.Rows
 
@@ -222,6 +237,8 @@ func namedQuerySlice[T any](ctx context.Context, log *logger.Logger, db sqlx.Ext
 	}
 	*dest = slice
 
+	span.SetAttributes(attribute.Int("db.rows", len(slice)))
+
 	return nil
 }
 
@@ -253,8 +270,10 @@ func namedQueryStruct(ctx context.Context, log *logger.Logger, db sqlx.ExtContex
 		}
 	}()
 
-	ctx, span := web.AddSpan(ctx, "business.sys.database.query", attribute.String("query", q))
-	defer span.End()
+	ctx, span := querySpan(ctx, "business.sys.database.query", query)
+	defer func() {
+		endSpan(span, err)
+	}()
 
 	var rows *sqlx.Rows
 
@@ -280,14 +299,14 @@ func namedQueryStruct(ctx context.Context, log *logger.Logger, db sqlx.ExtContex
 	}
 
 	if err != nil {
-		if pqerr, ok := err.(*pgconn.PgError); ok && pqerr.Code == undefinedTable {
-			return ErrUndefinedTable
-		}
-		return err
+		return queryError(err)
 	}
 	defer rows.Close()
 
 	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return queryError(err)
+		}
 		return ErrDBNotFound
 	}
 
@@ -295,12 +314,110 @@ func namedQueryStruct(ctx context.Context, log *logger.Logger, db sqlx.ExtContex
 		return err
 	}
 
+	span.SetAttributes(attribute.Int("db.rows", 1))
+
 	return nil
 }
 
+// querySpan starts the span of a database call. The span carries the name of
+// the function that made the call, such as patientdb.(*Store).Create, and the
+// statement without the values so no data ends up in the traces.
+func querySpan(ctx context.Context, spanName string, query string) (context.Context, trace.Span) {
+	return web.AddSpan(ctx, spanName,
+		attribute.String("db.query.name", queryName()),
+		attribute.String("db.statement", strings.TrimSpace(query)),
+	)
+}
+
+// endSpan records the error of a failed database call and ends the span. Not
+// finding a row is an expected result and not recorded as an error.
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, ErrDBNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+var pkgPath = reflect.TypeFor[Config]().PkgPath()
+
+// queryName returns the name of the first function in the call stack outside
+// of this package, without its import path.
+func queryName() string {
+	pc := make([]uintptr, 16)
+	n := runtime.Callers(3, pc)
+	frames := runtime.CallersFrames(pc[:n])
+
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, pkgPath+".") {
+			return frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+		}
+
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// queryError translates the postgres errors callers need to act on. A
+// statement with a RETURNING clause can fail with a constraint violation.
+func queryError(err error) error {
+	var pqerr *pgconn.PgError
+	if errors.As(err, &pqerr) {
+		switch pqerr.Code {
+		case undefinedTable:
+			return ErrUndefinedTable
+		case uniqueViolation:
+			return ErrDBDuplicatedEntry
+		}
+	}
+
+	return err
+}
+
+// mapper maps the fields of the query arguments to names the way sqlx does.
+var mapper = reflectx.NewMapperFunc("db", sqlx.NameMapper)
+
+// redactArgs returns the arguments of a query with the values of the fields
+// tagged with log:"redact" replaced, so the query can be logged. Arguments
+// without such fields are returned as they are.
+func redactArgs(data any) any {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	if v.Kind() != reflect.Struct {
+		return data
+	}
+
+	tm := mapper.TypeMap(v.Type())
+
+	redacts := slices.ContainsFunc(tm.Index, func(fi *reflectx.FieldInfo) bool {
+		return logger.Redacts(fi.Field)
+	})
+	if !redacts {
+		return data
+	}
+
+	args := make(map[string]any, len(tm.Paths))
+	for path, fi := range tm.Paths {
+		if logger.Redacts(fi.Field) {
+			args[path] = logger.RedactedValue
+			continue
+		}
+
+		fv := reflectx.FieldByIndexesReadOnly(v, fi.Index)
+		if fv.CanInterface() {
+			args[path] = fv.Interface()
+		}
+	}
+
+	return args
+}
+
 // queryString provides a pretty print version of the query and parameters.
+// The values of the fields tagged with log:"redact" are not printed.
 func queryString(query string, args any) string {
-	query, params, err := sqlx.Named(query, args)
+	query, params, err := sqlx.Named(query, redactArgs(args))
 	if err != nil {
 		return err.Error()
 	}
@@ -6,25 +6,45 @@ package auth
 import (
 	"context"
 	"errors"
+	"expvar"
 	"fmt"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user/stores/userdb"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/metrics"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/open-policy-agent/opa/rego"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // ErrForbidden is returned when a auth issue is identified.
 var ErrForbidden = errors.New("attempted action is not allowed")
 
-// Claims represents the authorization claims transmitted via a JWT.
+// errDenied is returned by a policy evaluation that denied the input.
+var errDenied = errors.New("denied")
+
+// defaultTokenTTL is the lifetime of an access token when none is configured.
+const defaultTokenTTL = time.Hour
+
+// decisions holds the counters and timings for every policy decision made by
+// the process. The expvar package registers values as singletons so a single
+// map is shared by every Auth value.
+var decisions = expvar.NewMap("opa")
+
+// Claims represents the authorization claims transmitted via a JWT. The
+// RegionID is not part of the token. It is resolved from the user's record
+// during authentication so a change of region takes effect immediately.
 type Claims struct {
 	jwt.RegisteredClaims
-	Roles []user.Role `json:"roles"`
+	Roles    []user.Role `json:"roles"`
+	RegionID uuid.UUID   `json:"-"`
 }
 
 // HasRole checks if the specified role exists.
@@ -37,6 +57,16 @@ func (c Claims) HasRole(r user.Role) bool {
 	return false
 }
 
+// Scope represents the records of a resource a list may show a caller.
+type Scope int
+
+// Set of scopes a list can be narrowed to. The zero value is the narrowest.
+const (
+	ScopeSubject Scope = iota
+	ScopeRegion
+	ScopeAll
+)
+
 // KeyLookup declares a method set of behavior for looking up
 // private and public keys for JWT use. The return could be a
 // PEM encoded string or a JWS based key.
@@ -45,22 +75,40 @@ type KeyLookup interface {
 	PublicKey(kid string) (key string, err error)
 }
 
-// Config represents information required to initialize auth.
+// Config represents information required to initialize auth. Issuer and
+// Audience are placed in every token issued and required of every token
+// authenticated. ClockSkew is the leeway allowed when checking the time based
+// claims of tokens issued by other clocks. PolicyPath points at a directory
+// or bundle file of rego policies that replace the embedded ones.
+// Authorization decisions are written to DecisionLog, or Log when it is not
+// provided.
 type Config struct {
-	Log       *logger.Logger
-	DB        *sqlx.DB
-	KeyLookup KeyLookup
-	Issuer    string
+	Log         *logger.Logger
+	DB          *sqlx.DB
+	KeyLookup   KeyLookup
+	Issuer      string
+	Audience    string
+	TokenTTL    time.Duration
+	ClockSkew   time.Duration
+	PolicyPath  string
+	DecisionLog *logger.Logger
 }
 
 // Auth is used to authenticate clients. It can generate a token for a
 // set of user claims and recreate the claims by parsing the token.
 type Auth struct {
-	keyLookup KeyLookup
-	usrCore   *user.Core
-	method    jwt.SigningMethod
-	parser    *jwt.Parser
-	issuer    string
+	log         *logger.Logger
+	decisionLog *logger.Logger
+	keyLookup   KeyLookup
+	usrCore     *user.Core
+	parser      *jwt.Parser
+	issuer      string
+	audience    string
+	tokenTTL    time.Duration
+	clockSkew   time.Duration
+	now         func() time.Time
+	policyPath  string
+	policy      atomic.Pointer[policy]
 }
 
 // New creates an Auth to support authentication/authorization.
@@ -73,32 +121,79 @@ func New(cfg Config) (*Auth, error) {
 		usrCore = user.NewCore(cfg.Log, nil, userdb.NewStore(cfg.Log, cfg.DB))
 	}
 
+	decisionLog := cfg.DecisionLog
+	if decisionLog == nil {
+		decisionLog = cfg.Log
+	}
+
+	tokenTTL := cfg.TokenTTL
+	if tokenTTL <= 0 {
+		tokenTTL = defaultTokenTTL
+	}
+
 	a := Auth{
-		keyLookup: cfg.KeyLookup,
-		usrCore:   usrCore,
-		method:    jwt.GetSigningMethod(jwt.SigningMethodRS256.Name),
-		parser:    jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Name})),
-		issuer:    cfg.Issuer,
+		log:         cfg.Log,
+		decisionLog: decisionLog,
+		keyLookup:   cfg.KeyLookup,
+		usrCore:     usrCore,
+		parser:      jwt.NewParser(jwt.WithValidMethods(signingMethods), jwt.WithoutClaimsValidation()),
+		issuer:      cfg.Issuer,
+		audience:    cfg.Audience,
+		tokenTTL:    tokenTTL,
+		clockSkew:   cfg.ClockSkew,
+		now:         time.Now,
+		policyPath:  cfg.PolicyPath,
+	}
+
+	if err := a.ReloadPolicies(context.Background()); err != nil {
+		return nil, fmt.Errorf("loading policies: %w", err)
 	}
 
 	return &a, nil
 }
 
-// GenerateToken generates a signed JWT token string representing the user Claims.
-func (a *Auth) GenerateToken(kid string, claims Claims) (string, error) {
-	token := jwt.NewWithClaims(a.method, claims)
-	token.Header["kid"] = kid
+// IssueToken generates a signed access token for the user with the kid. The
+// token carries the configured issuer and audience and is valid from now
+// until the configured time to live has passed.
+func (a *Auth) IssueToken(kid string, usr user.User) (string, error) {
+	now := a.now().UTC()
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   usr.ID.String(),
+			Issuer:    a.issuer,
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.tokenTTL)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		Roles: usr.Roles,
+	}
+
+	if a.audience != "" {
+		claims.Audience = jwt.ClaimStrings{a.audience}
+	}
+
+	return a.GenerateToken(kid, claims)
+}
 
+// GenerateToken generates a signed JWT token string representing the user
+// Claims. The signing method is chosen by the type of key for the kid: RS256
+// for RSA, ES256 for ECDSA P-256 and EdDSA for Ed25519 keys.
+func (a *Auth) GenerateToken(kid string, claims Claims) (string, error) {
 	privateKeyPEM, err := a.keyLookup.PrivateKey(kid)
 	if err != nil {
 		return "", fmt.Errorf("private key: %w", err)
 	}
 
-	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM))
+	privateKey, method, err := parsePrivateKey(privateKeyPEM)
 	if err != nil {
 		return "", fmt.Errorf("parsing private pem: %w", err)
 	}
 
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+
 	str, err := token.SignedString(privateKey)
 	if err != nil {
 		return "", fmt.Errorf("signing token: %w", err)
@@ -135,22 +230,44 @@ func (a *Auth) Authenticate(ctx context.Context, bearerToken string) (Claims, er
 		return Claims{}, fmt.Errorf("failed to fetch public key: %w", err)
 	}
 
+	publicKey, method, err := parsePublicKey(pem)
+	if err != nil {
+		return Claims{}, fmt.Errorf("parsing public pem: %w", err)
+	}
+
+	// OPA can't verify EdDSA signatures so they are checked here and the
+	// result handed to the policy. The parser skips the claims since the
+	// policy validates them.
+	var verified bool
+	if method == jwt.SigningMethodEdDSA {
+		keyFunc := func(*jwt.Token) (any, error) { return publicKey, nil }
+		_, err := a.parser.ParseWithClaims(parts[1], &Claims{}, keyFunc)
+		verified = err == nil
+	}
+
 	input := map[string]any{
-		"Key":   pem,
-		"Token": parts[1],
-		"ISS":   a.issuer,
+		"Key":      pem,
+		"Alg":      method.Alg(),
+		"Verified": verified,
+		"Token":    parts[1],
+		"ISS":      a.issuer,
+		"AUD":      a.audience,
+		"Skew":     int64(a.clockSkew.Seconds()),
 	}
 
-	if err := a.opaPolicyEvaluation(ctx, opaAuthentication, RuleAuthenticate, input); err != nil {
+	if err := a.opaPolicyEvaluation(ctx, RuleAuthenticate, input); err != nil {
 		return Claims{}, fmt.Errorf("authentication failed : %w", err)
 	}
 
 	// Check the database for this user to verify they are still enabled.
 
-	if err := a.isUserEnabled(ctx, claims); err != nil {
+	usr, err := a.isUserEnabled(ctx, claims)
+	if err != nil {
 		return Claims{}, fmt.Errorf("user not enabled : %w", err)
 	}
 
+	claims.RegionID = usr.RegionID
+
 	return claims, nil
 }
 
@@ -158,32 +275,88 @@ func (a *Auth) Authenticate(ctx context.Context, bearerToken string) (Claims, er
 // none of the input roles are within the user's claims, we return an error
 // otherwise the user is authorized.
 func (a *Auth) Authorize(ctx context.Context, claims Claims, userID uuid.UUID, rule string) error {
+	return a.AuthorizeRegion(ctx, claims, userID, uuid.Nil, rule)
+}
+
+// AuthorizeRegion attempts to authorize the user against a resource that
+// belongs to the specified user and region. The caller's region from the
+// claims and the resource's region are both provided to the policy so
+// regional rules can compare them. A nil region is passed as empty.
+func (a *Auth) AuthorizeRegion(ctx context.Context, claims Claims, userID uuid.UUID, regionID uuid.UUID, rule string) error {
 	input := map[string]any{
-		"Roles":   claims.Roles,
-		"Subject": claims.Subject,
-		"UserID":  userID,
+		"Roles":            claims.Roles,
+		"Subject":          claims.Subject,
+		"UserID":           userID,
+		"RegionID":         regionString(claims.RegionID),
+		"ResourceRegionID": regionString(regionID),
 	}
 
-	if err := a.opaPolicyEvaluation(ctx, opaAuthorization, rule, input); err != nil {
+	err := a.opaPolicyEvaluation(ctx, rule, input)
+	a.logDecision(ctx, rule, input, err)
+
+	if err != nil {
 		return fmt.Errorf("rego evaluation failed : %w", err)
 	}
 
 	return nil
 }
 
-// opaPolicyEvaluation asks opa to evaluate the token against the specified token
-// policy and public key.
-func (a *Auth) opaPolicyEvaluation(ctx context.Context, opaPolicy string, rule string, input any) error {
-	query := fmt.Sprintf("x = data.%s.%s", opaPackage, rule)
+// Scope asks the policy which records of the resource a list may show the
+// caller. The widest scope the policy grants is returned and a caller the
+// policy grants no scope to only sees their own records.
+func (a *Auth) Scope(ctx context.Context, claims Claims, resource string) (Scope, error) {
+	input := map[string]any{
+		"Roles":    claims.Roles,
+		"Subject":  claims.Subject,
+		"RegionID": regionString(claims.RegionID),
+		"Resource": resource,
+	}
 
-	q, err := rego.New(
-		rego.Query(query),
-		rego.Module("policy.rego", opaPolicy),
-	).PrepareForEval(ctx)
-	if err != nil {
-		return err
+	scopes := []struct {
+		rule  string
+		scope Scope
+	}{
+		{RuleScopeAll, ScopeAll},
+		{RuleScopeRegion, ScopeRegion},
+	}
+
+	for _, s := range scopes {
+		err := a.opaPolicyEvaluation(ctx, s.rule, input)
+		a.logDecision(ctx, s.rule, input, err)
+
+		switch {
+		case err == nil:
+			return s.scope, nil
+		case !errors.Is(err, errDenied):
+			return ScopeSubject, fmt.Errorf("rego evaluation failed : %w", err)
+		}
 	}
 
+	return ScopeSubject, nil
+}
+
+// opaPolicyEvaluation asks opa to evaluate the input against the prepared
+// query for the specified rule. Prepared queries are safe for concurrent use.
+func (a *Auth) opaPolicyEvaluation(ctx context.Context, rule string, input any) error {
+	q, exists := a.policy.Load().queries[rule]
+	if !exists {
+		return fmt.Errorf("unknown rule %q", rule)
+	}
+
+	ctx, span := web.AddSpan(ctx, "business.web.auth.opa", attribute.String("rule", rule))
+
+	start := time.Now()
+	outcome := "error"
+	defer func() {
+		took := time.Since(start)
+		decisions.Add("eval_ns."+rule, took.Nanoseconds())
+		decisions.Add(outcome+"."+rule, 1)
+		metrics.ObservePolicy(rule, outcome, took)
+
+		span.SetAttributes(attribute.String("result", outcome))
+		span.End()
+	}()
+
 	results, err := q.Eval(ctx, rego.EvalInput(input))
 	if err != nil {
 		return fmt.Errorf("query: %w", err)
@@ -195,27 +368,62 @@ func (a *Auth) opaPolicyEvaluation(ctx context.Context, opaPolicy string, rule s
 
 	result, ok := results[0].Bindings["x"].(bool)
 	if !ok || !result {
-		return fmt.Errorf("bindings results[%v] ok[%v]", results, ok)
+		outcome = "deny"
+		return fmt.Errorf("%w: bindings results[%v] ok[%v]", errDenied, results, ok)
 	}
 
+	outcome = "allow"
+
 	return nil
 }
 
+// logDecision writes the outcome of an authorization decision to the
+// decision log for compliance review.
+func (a *Auth) logDecision(ctx context.Context, rule string, input map[string]any, err error) {
+	if a.decisionLog == nil {
+		return
+	}
+
+	result := "allow"
+	reason := ""
+	if err != nil {
+		result = "deny"
+		reason = err.Error()
+	}
+
+	a.decisionLog.Info(ctx, "decision", "rule", rule, "result", result, "reason", reason, "policy", a.PolicyRevision(), "input", input)
+}
+
 // isUserEnabled hits the database and checks the user is not disabled. If the
 // no database connection was provided, this check is skipped.
-func (a *Auth) isUserEnabled(ctx context.Context, claims Claims) error {
+func (a *Auth) isUserEnabled(ctx context.Context, claims Claims) (user.User, error) {
 	if a.usrCore == nil {
-		return nil
+		return user.User{}, nil
 	}
 
 	userID, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return fmt.Errorf("parse user: %w", err)
+		return user.User{}, fmt.Errorf("parse user: %w", err)
 	}
 
-	if _, err := a.usrCore.QueryByID(ctx, userID); err != nil {
-		return fmt.Errorf("query user: %w", err)
+	usr, err := a.usrCore.QueryByID(ctx, userID)
+	if err != nil {
+		return user.User{}, fmt.Errorf("query user: %w", err)
 	}
 
-	return nil
+	if !usr.Enabled {
+		return user.User{}, fmt.Errorf("user disabled: userID[%s]", userID)
+	}
+
+	return usr, nil
+}
+
+// regionString converts a region id for the policy input, where a nil id
+// means no region.
+func regionString(regionID uuid.UUID) string {
+	if regionID == uuid.Nil {
+		return ""
+	}
+
+	return regionID.String()
 }
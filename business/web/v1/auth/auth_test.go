@@ -3,10 +3,19 @@ package auth_test
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"io"
+	"os"
+	"path/filepath"
 	"runtime/debug"
 	"testing"
 	"time"
@@ -14,6 +23,7 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/open-policy-agent/opa/rego"
 )
 
 func Test_Auth(t *testing.T) {
@@ -229,6 +239,419 @@ func Test_Auth(t *testing.T) {
 	}
 }
 
+func Test_SigningMethods(t *testing.T) {
+	log, db, teardown := newUnit(t)
+	defer teardown()
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate an ECDSA key : %s", err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate an Ed25519 key : %s", err)
+	}
+
+	tests := []struct {
+		name string
+		key  any
+		pub  any
+		alg  string
+	}{
+		{name: "es256", key: ecKey, pub: &ecKey.PublicKey, alg: "ES256"},
+		{name: "eddsa", key: edKey, pub: edKey.Public(), alg: "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := newPEMKeyStore(t, tt.key, tt.pub)
+
+			a, err := auth.New(auth.Config{Log: log, DB: db, KeyLookup: ks, Issuer: "service project"})
+			if err != nil {
+				t.Fatalf("Should be able to create an authenticator: %s", err)
+			}
+
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    "service project",
+					Subject:   "5cf37266-3473-4006-984f-9325122678b7",
+					ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour)),
+					IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+				},
+				Roles: []user.Role{user.RoleAdmin},
+			}
+
+			token, err := a.GenerateToken(kid, claims)
+			if err != nil {
+				t.Fatalf("Should be able to generate a JWT : %s", err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &auth.Claims{})
+			if err != nil {
+				t.Fatalf("Should be able to parse the JWT : %s", err)
+			}
+
+			if parsed.Method.Alg() != tt.alg {
+				t.Fatalf("Should sign with %s : got %s", tt.alg, parsed.Method.Alg())
+			}
+
+			if _, err := a.Authenticate(context.Background(), "Bearer "+token); err != nil {
+				t.Fatalf("Should be able to authenticate the claims : %s", err)
+			}
+
+			// Flip a character in the signature to break it.
+			tampered := []byte(token)
+			i := len(tampered) - 5
+			tampered[i] ^= 'A' ^ 'B'
+
+			if _, err := a.Authenticate(context.Background(), "Bearer "+string(tampered)); err == nil {
+				t.Fatal("Should NOT be able to authenticate a tampered token")
+			}
+
+			claims.Issuer = "someone else"
+			token, err = a.GenerateToken(kid, claims)
+			if err != nil {
+				t.Fatalf("Should be able to generate a JWT : %s", err)
+			}
+
+			if _, err := a.Authenticate(context.Background(), "Bearer "+token); err == nil {
+				t.Fatal("Should NOT be able to authenticate a token from another issuer")
+			}
+		})
+	}
+}
+
+func Test_TokenClaims(t *testing.T) {
+	log, db, teardown := newUnit(t)
+	defer teardown()
+
+	cfg := auth.Config{
+		Log:       log,
+		DB:        db,
+		KeyLookup: &keyStore{},
+		Issuer:    "service project",
+		Audience:  "gateone-api",
+		TokenTTL:  time.Minute,
+		ClockSkew: 30 * time.Second,
+	}
+	a, err := auth.New(cfg)
+	if err != nil {
+		t.Fatalf("Should be able to create an authenticator: %s", err)
+	}
+
+	usr := user.User{
+		ID:    uuid.MustParse("5cf37266-3473-4006-984f-9325122678b7"),
+		Roles: []user.Role{user.RoleUser},
+	}
+
+	token, err := a.IssueToken(kid, usr)
+	if err != nil {
+		t.Fatalf("Should be able to issue a token : %s", err)
+	}
+
+	claims, err := a.Authenticate(context.Background(), "Bearer "+token)
+	if err != nil {
+		t.Fatalf("Should be able to authenticate an issued token : %s", err)
+	}
+
+	if claims.Issuer != cfg.Issuer || len(claims.Audience) != 1 || claims.Audience[0] != cfg.Audience {
+		t.Fatalf("Should get back the configured issuer and audience : %+v", claims.RegisteredClaims)
+	}
+
+	if got := claims.ExpiresAt.Sub(claims.IssuedAt.Time); got != cfg.TokenTTL {
+		t.Fatalf("Should get back the configured time to live : %s", got)
+	}
+
+	now := time.Now().UTC()
+	at := func(d time.Duration) *jwt.NumericDate { return jwt.NewNumericDate(now.Add(d)) }
+
+	tests := []struct {
+		name    string
+		claims  jwt.RegisteredClaims
+		success bool
+	}{
+		{"valid", jwt.RegisteredClaims{Audience: jwt.ClaimStrings{"gateone-api"}, IssuedAt: at(0), ExpiresAt: at(time.Minute)}, true},
+		{"other-audience", jwt.RegisteredClaims{Audience: jwt.ClaimStrings{"other"}, IssuedAt: at(0), ExpiresAt: at(time.Minute)}, false},
+		{"no-audience", jwt.RegisteredClaims{IssuedAt: at(0), ExpiresAt: at(time.Minute)}, false},
+		{"expired-within-skew", jwt.RegisteredClaims{Audience: jwt.ClaimStrings{"gateone-api"}, IssuedAt: at(-time.Hour), ExpiresAt: at(-10 * time.Second)}, true},
+		{"expired", jwt.RegisteredClaims{Audience: jwt.ClaimStrings{"gateone-api"}, IssuedAt: at(-time.Hour), ExpiresAt: at(-2 * time.Minute)}, false},
+		{"no-expiry", jwt.RegisteredClaims{Audience: jwt.ClaimStrings{"gateone-api"}, IssuedAt: at(0)}, false},
+		{"not-before-within-skew", jwt.RegisteredClaims{Audience: jwt.ClaimStrings{"gateone-api"}, IssuedAt: at(0), NotBefore: at(10 * time.Second), ExpiresAt: at(time.Minute)}, true},
+		{"not-before", jwt.RegisteredClaims{Audience: jwt.ClaimStrings{"gateone-api"}, IssuedAt: at(0), NotBefore: at(2 * time.Minute), ExpiresAt: at(time.Hour)}, false},
+		{"issued-in-future", jwt.RegisteredClaims{Audience: jwt.ClaimStrings{"gateone-api"}, IssuedAt: at(2 * time.Minute), ExpiresAt: at(time.Hour)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.claims.Issuer = "service project"
+			tt.claims.Subject = usr.ID.String()
+
+			token, err := a.GenerateToken(kid, auth.Claims{RegisteredClaims: tt.claims, Roles: usr.Roles})
+			if err != nil {
+				t.Fatalf("Should be able to generate a JWT : %s", err)
+			}
+
+			_, err = a.Authenticate(context.Background(), "Bearer "+token)
+			if tt.success && err != nil {
+				t.Errorf("Should be able to authenticate : %s", err)
+			}
+			if !tt.success && err == nil {
+				t.Error("Should NOT be able to authenticate")
+			}
+		})
+	}
+}
+
+func Test_RegionRules(t *testing.T) {
+	log, db, teardown := newUnit(t)
+	defer teardown()
+
+	a, err := auth.New(auth.Config{Log: log, DB: db, KeyLookup: &keyStore{}, Issuer: "service project"})
+	if err != nil {
+		t.Fatalf("Should be able to create an authenticator: %s", err)
+	}
+
+	north := uuid.MustParse("0b3d3c0e-3d3c-4d8e-9c55-0f0e8b0e0a01")
+	south := uuid.MustParse("0b3d3c0e-3d3c-4d8e-9c55-0f0e8b0e0a02")
+	subject := uuid.MustParse("5cf37266-3473-4006-984f-9325122678b7")
+	other := uuid.MustParse("45b5fbd3-755f-4379-8f07-a58d4a30fa2f")
+
+	tests := []struct {
+		name     string
+		roles    []user.Role
+		region   uuid.UUID
+		userID   uuid.UUID
+		resource uuid.UUID
+		rule     string
+		allowed  bool
+	}{
+		{"admin", []user.Role{user.RoleAdmin}, uuid.Nil, other, south, auth.RuleAdminOrRegionSupervisor, true},
+		{"supervisor-same-region", []user.Role{user.RoleSupervisor}, north, other, north, auth.RuleAdminOrRegionSupervisor, true},
+		{"supervisor-other-region", []user.Role{user.RoleSupervisor}, north, other, south, auth.RuleAdminOrRegionSupervisor, false},
+		{"supervisor-no-region", []user.Role{user.RoleSupervisor}, uuid.Nil, other, uuid.Nil, auth.RuleAdminOrRegionSupervisor, false},
+		{"user-same-region", []user.Role{user.RoleUser}, north, other, north, auth.RuleAdminOrRegionSupervisor, false},
+		{"subject", []user.Role{user.RoleUser}, north, subject, south, auth.RuleAdminRegionSupervisorOrSubject, true},
+		{"not-subject", []user.Role{user.RoleUser}, north, other, north, auth.RuleAdminRegionSupervisorOrSubject, false},
+		{"subject-without-role", nil, north, subject, south, auth.RuleAdminRegionSupervisorOrSubject, false},
+		{"supervisor-list", []user.Role{user.RoleSupervisor}, north, uuid.Nil, uuid.Nil, auth.RuleAdminOrSupervisor, true},
+		{"user-list", []user.Role{user.RoleUser}, north, uuid.Nil, uuid.Nil, auth.RuleAdminOrSupervisor, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: subject.String()},
+				Roles:            tt.roles,
+				RegionID:         tt.region,
+			}
+
+			err := a.AuthorizeRegion(context.Background(), claims, tt.userID, tt.resource, tt.rule)
+			if tt.allowed && err != nil {
+				t.Errorf("Should be allowed : %s", err)
+			}
+			if !tt.allowed && err == nil {
+				t.Error("Should NOT be allowed")
+			}
+		})
+	}
+}
+
+func Test_ScopeRules(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	a, err := auth.New(auth.Config{Log: log, KeyLookup: &keyStore{}, Issuer: "service project"})
+	if err != nil {
+		t.Fatalf("Should be able to create an authenticator: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		roles    []user.Role
+		resource string
+		scope    auth.Scope
+	}{
+		{"admin-patients", []user.Role{user.RoleAdmin}, "patients", auth.ScopeAll},
+		{"supervisor-patients", []user.Role{user.RoleSupervisor}, "patients", auth.ScopeRegion},
+		{"user-patients", []user.Role{user.RoleUser}, "patients", auth.ScopeAll},
+		{"user-supervisor-patients", []user.Role{user.RoleUser, user.RoleSupervisor}, "patients", auth.ScopeRegion},
+		{"admin-users", []user.Role{user.RoleAdmin}, "users", auth.ScopeAll},
+		{"supervisor-users", []user.Role{user.RoleSupervisor}, "users", auth.ScopeRegion},
+		{"user-users", []user.Role{user.RoleUser}, "users", auth.ScopeSubject},
+		{"no-roles", nil, "patients", auth.ScopeSubject},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: "5cf37266-3473-4006-984f-9325122678b7"},
+				Roles:            tt.roles,
+			}
+
+			scope, err := a.Scope(context.Background(), claims, tt.resource)
+			if err != nil {
+				t.Fatalf("Should be able to evaluate the scope : %s", err)
+			}
+
+			if scope != tt.scope {
+				t.Errorf("Should get scope %d: got %d", tt.scope, scope)
+			}
+		})
+	}
+}
+
+func Test_PolicyReload(t *testing.T) {
+	log, db, teardown := newUnit(t)
+	defer teardown()
+
+	dir := t.TempDir()
+	for _, name := range []string{"authentication.rego", "authorization.rego"} {
+		src, err := os.ReadFile(filepath.Join("rego", name))
+		if err != nil {
+			t.Fatalf("Should be able to read the policy: %s", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), src, 0600); err != nil {
+			t.Fatalf("Should be able to write the policy: %s", err)
+		}
+	}
+
+	a, err := auth.New(auth.Config{Log: log, DB: db, KeyLookup: &keyStore{}, Issuer: "service project", PolicyPath: dir})
+	if err != nil {
+		t.Fatalf("Should be able to create an authenticator: %s", err)
+	}
+
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "5cf37266-3473-4006-984f-9325122678b7"},
+		Roles:            []user.Role{user.RoleUser},
+	}
+	userID := uuid.MustParse(claims.Subject)
+	ctx := context.Background()
+
+	if err := a.Authorize(ctx, claims, userID, auth.RuleUserOnly); err != nil {
+		t.Fatalf("Should be able to authorize the RoleUser claim : %s", err)
+	}
+
+	revision := a.PolicyRevision()
+
+	// A policy that fails to compile must leave the last good one in place.
+	file := filepath.Join(dir, "authorization.rego")
+	if err := os.WriteFile(file, []byte("package ardan.rego\n\nrule_user_only if {"), 0600); err != nil {
+		t.Fatalf("Should be able to write the policy: %s", err)
+	}
+
+	if err := a.ReloadPolicies(ctx); err == nil {
+		t.Fatal("Should NOT be able to reload a broken policy")
+	}
+
+	if a.PolicyRevision() != revision {
+		t.Fatal("Should keep the last good policy")
+	}
+
+	if err := a.Authorize(ctx, claims, userID, auth.RuleUserOnly); err != nil {
+		t.Fatalf("Should still authorize with the last good policy : %s", err)
+	}
+
+	// A valid policy that locks users out must take effect.
+	locked := `package ardan.rego
+
+default rule_any := false
+
+default rule_admin_only := false
+
+default rule_user_only := false
+
+default rule_admin_or_subject := false
+
+default rule_admin_or_supervisor := false
+
+default rule_admin_or_region_supervisor := false
+
+default rule_admin_region_supervisor_or_subject := false
+
+default rule_scope_all := false
+
+default rule_scope_region := false
+`
+	if err := os.WriteFile(file, []byte(locked), 0600); err != nil {
+		t.Fatalf("Should be able to write the policy: %s", err)
+	}
+
+	if err := a.ReloadPolicies(ctx); err != nil {
+		t.Fatalf("Should be able to reload the policy : %s", err)
+	}
+
+	if err := a.Authorize(ctx, claims, userID, auth.RuleUserOnly); err == nil {
+		t.Fatal("Should NOT be able to authorize after the policy changed")
+	}
+}
+
+// Benchmark_Authorize compares evaluating the queries prepared by auth.New
+// with compiling the policy for every decision.
+func Benchmark_Authorize(b *testing.B) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	a, err := auth.New(auth.Config{Log: log, KeyLookup: &keyStore{}, Issuer: "service project"})
+	if err != nil {
+		b.Fatalf("Should be able to create an authenticator: %s", err)
+	}
+
+	policy, err := os.ReadFile("rego/authorization.rego")
+	if err != nil {
+		b.Fatalf("Should be able to read the policy: %s", err)
+	}
+
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "5cf37266-3473-4006-984f-9325122678b7",
+		},
+		Roles: []user.Role{user.RoleUser},
+	}
+	userID := uuid.MustParse(claims.Subject)
+
+	ctx := context.Background()
+
+	b.Run("prepared", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if err := a.Authorize(ctx, claims, userID, auth.RuleAdminOrSubject); err != nil {
+					b.Errorf("Should be able to authorize: %s", err)
+					return
+				}
+			}
+		})
+	})
+
+	b.Run("unprepared", func(b *testing.B) {
+		input := map[string]any{
+			"Roles":   claims.Roles,
+			"Subject": claims.Subject,
+			"UserID":  userID,
+		}
+
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				q, err := rego.New(
+					rego.Query("x = data.ardan.rego."+auth.RuleAdminOrSubject),
+					rego.Module("policy.rego", string(policy)),
+				).PrepareForEval(ctx)
+				if err != nil {
+					b.Errorf("Should be able to prepare the query: %s", err)
+					return
+				}
+
+				results, err := q.Eval(ctx, rego.EvalInput(input))
+				if err != nil || len(results) == 0 || results[0].Bindings["x"] != true {
+					b.Errorf("Should be able to authorize: %v %s", results, err)
+					return
+				}
+			}
+		})
+	})
+}
+
 func newUnit(t *testing.T) (*logger.Logger, *sqlx.DB, func()) {
 	var buf bytes.Buffer
 	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "00000000-0000-0000-0000-000000000000" })
@@ -246,6 +669,39 @@ func newUnit(t *testing.T) (*logger.Logger, *sqlx.DB, func()) {
 	return log, nil, teardown
 }
 
+// pemKeyStore holds a single generated key pair in PEM form.
+type pemKeyStore struct {
+	privatePEM string
+	publicPEM  string
+}
+
+func newPEMKeyStore(t *testing.T, key any, pub any) *pemKeyStore {
+	privateBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Should be able to marshal the private key : %s", err)
+	}
+
+	publicBytes, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("Should be able to marshal the public key : %s", err)
+	}
+
+	ks := pemKeyStore{
+		privatePEM: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateBytes})),
+		publicPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes})),
+	}
+
+	return &ks
+}
+
+func (ks *pemKeyStore) PrivateKey(kid string) (string, error) {
+	return ks.privatePEM, nil
+}
+
+func (ks *pemKeyStore) PublicKey(kid string) (string, error) {
+	return ks.publicPEM, nil
+}
+
 type keyStore struct{}
 
 func (ks *keyStore) PrivateKey(kid string) (string, error) {
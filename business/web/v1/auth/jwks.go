@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/foundation/jwks"
+	"sort"
+)
+
+// KeySet declares behavior for a KeyLookup that can list every public key it
+// holds so the keys can be published to other services.
+type KeySet interface {
+	PublicKeys() map[string]string
+}
+
+// KeyRotator declares behavior for a KeyLookup that rotates its signing keys
+// and knows which key new tokens should be signed with.
+type KeyRotator interface {
+	ActiveKID() (string, error)
+}
+
+// ErrNoActiveKey is returned when there is no key new tokens can be signed
+// with, such as while the only key is waiting to become active.
+var ErrNoActiveKey = errors.New("no active signing key")
+
+// ActiveKID returns the kid of the key new tokens should be signed with.
+func (a *Auth) ActiveKID() (string, error) {
+	kr, ok := a.keyLookup.(KeyRotator)
+	if !ok {
+		return "", errors.New("key lookup does not support key rotation")
+	}
+
+	kid, err := kr.ActiveKID()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrNoActiveKey, err)
+	}
+
+	return kid, nil
+}
+
+// Issuer returns the issuer this service places in and expects from tokens.
+func (a *Auth) Issuer() string {
+	return a.issuer
+}
+
+// PublicKeySet returns every public key known to the key lookup as a JSON
+// Web Key Set. The keys are ordered by kid.
+func (a *Auth) PublicKeySet() (jwks.Set, error) {
+	ks, ok := a.keyLookup.(KeySet)
+	if !ok {
+		return jwks.Set{}, errors.New("key lookup does not support listing public keys")
+	}
+
+	publicKeys := ks.PublicKeys()
+
+	kids := make([]string, 0, len(publicKeys))
+	for kid := range publicKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := jwks.Set{
+		Keys: make([]jwks.Key, 0, len(kids)),
+	}
+
+	for _, kid := range kids {
+		key, err := jwks.FromPublicPEM(kid, publicKeys[kid])
+		if err != nil {
+			return jwks.Set{}, fmt.Errorf("converting kid[%s]: %w", kid, err)
+		}
+		set.Keys = append(set.Keys, key)
+	}
+
+	return set, nil
+}
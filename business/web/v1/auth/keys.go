@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// signingMethods is the set of algorithms tokens can be signed with.
+var signingMethods = []string{
+	jwt.SigningMethodRS256.Alg(),
+	jwt.SigningMethodES256.Alg(),
+	jwt.SigningMethodEdDSA.Alg(),
+}
+
+// parsePrivateKey decodes a PEM encoded private key and returns it with the
+// signing method the type of key is used with.
+func parsePrivateKey(privatePEM string) (any, jwt.SigningMethod, error) {
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return nil, nil, errors.New("invalid key: key must be PEM encoded")
+	}
+
+	var key any
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			if key, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+				return nil, nil, errors.New("key is not a valid PKCS1, PKCS8 or SEC1 private key")
+			}
+		}
+	}
+
+	method, err := signingMethodFor(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, method, nil
+}
+
+// parsePublicKey decodes a PEM encoded public key and returns it with the
+// signing method the type of key is used with.
+func parsePublicKey(publicPEM string) (any, jwt.SigningMethod, error) {
+	block, _ := pem.Decode([]byte(publicPEM))
+	if block == nil {
+		return nil, nil, errors.New("invalid key: key must be PEM encoded")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing public key: %w", err)
+	}
+
+	method, err := signingMethodFor(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, method, nil
+}
+
+// signingMethodFor maps the type of key to the signing method it is used with.
+// The algorithm is chosen by the key and never by the token header.
+func signingMethodFor(key any) (jwt.SigningMethod, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+
+	case *ecdsa.PrivateKey:
+		return ecdsaMethod(k.Curve)
+
+	case *ecdsa.PublicKey:
+		return ecdsaMethod(k.Curve)
+
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %T", key)
+}
+
+func ecdsaMethod(curve elliptic.Curve) (jwt.SigningMethod, error) {
+	if curve != elliptic.P256() {
+		return nil, fmt.Errorf("unsupported curve %s: only P-256 is supported", curve.Params().Name)
+	}
+
+	return jwt.SigningMethodES256, nil
+}
@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/open-policy-agent/opa/loader"
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// module represents a single rego source file.
+type module struct {
+	name   string
+	source string
+}
+
+// policy represents a compiled set of modules with a prepared query for
+// every rule. A policy is immutable once constructed so it can be shared by
+// concurrent requests while a reload swaps in a new one.
+type policy struct {
+	revision string
+	queries  map[string]rego.PreparedEvalQuery
+}
+
+// embeddedModules returns the policies compiled into the binary.
+func embeddedModules() []module {
+	return []module{
+		{name: "authentication.rego", source: opaAuthentication},
+		{name: "authorization.rego", source: opaAuthorization},
+	}
+}
+
+// loadModules reads the rego modules from a policy directory or bundle file.
+// An empty path selects the embedded policies.
+func loadModules(path string) ([]module, error) {
+	if path == "" {
+		return embeddedModules(), nil
+	}
+
+	b, err := loader.NewFileLoader().AsBundle(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading bundle: %w", err)
+	}
+
+	if len(b.Modules) == 0 {
+		return nil, fmt.Errorf("no rego modules found in %s", path)
+	}
+
+	modules := make([]module, len(b.Modules))
+	for i, m := range b.Modules {
+		modules[i] = module{name: m.Path, source: string(m.Raw)}
+	}
+
+	return modules, nil
+}
+
+// newPolicy compiles the modules and prepares the query for every rule. A
+// rule must evaluate to a boolean even for an empty input, which means every
+// rule needs a default value.
+func newPolicy(ctx context.Context, modules []module) (*policy, error) {
+	options := make([]func(*rego.Rego), 0, len(modules)+1)
+	hash := sha256.New()
+
+	for _, m := range modules {
+		options = append(options, rego.Module(m.name, m.source))
+		hash.Write([]byte(m.name))
+		hash.Write([]byte(m.source))
+	}
+
+	queries := make(map[string]rego.PreparedEvalQuery, len(rules))
+
+	for _, rule := range rules {
+		query := fmt.Sprintf("x = data.%s.%s", opaPackage, rule)
+
+		q, err := rego.New(append(options, rego.Query(query))...).PrepareForEval(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("rule[%s]: %w", rule, err)
+		}
+
+		results, err := q.Eval(ctx, rego.EvalInput(map[string]any{}))
+		if err != nil {
+			return nil, fmt.Errorf("rule[%s]: validate: %w", rule, err)
+		}
+
+		if len(results) == 0 {
+			return nil, fmt.Errorf("rule[%s]: rule is not defined or has no default", rule)
+		}
+
+		if _, ok := results[0].Bindings["x"].(bool); !ok {
+			return nil, fmt.Errorf("rule[%s]: rule does not produce a boolean", rule)
+		}
+
+		queries[rule] = q
+	}
+
+	p := policy{
+		revision: hex.EncodeToString(hash.Sum(nil))[:12],
+		queries:  queries,
+	}
+
+	return &p, nil
+}
+
+// ReloadPolicies loads and compiles the policies again. If they fail to load
+// or compile, the last good policies remain in use and the error is returned.
+func (a *Auth) ReloadPolicies(ctx context.Context) error {
+	modules, err := loadModules(a.policyPath)
+	if err != nil {
+		return err
+	}
+
+	p, err := newPolicy(ctx, modules)
+	if err != nil {
+		return err
+	}
+
+	a.policy.Store(p)
+
+	return nil
+}
+
+// PolicyRevision returns an identifier for the policies currently in use.
+func (a *Auth) PolicyRevision() string {
+	return a.policy.Load().revision
+}
+
+// WatchPolicies polls the policy path every interval and reloads the policies
+// when the files change. The call blocks until the context is cancelled. It
+// does nothing when the embedded policies are in use.
+func (a *Auth) WatchPolicies(ctx context.Context, interval time.Duration) {
+	if a.policyPath == "" || interval <= 0 {
+		return
+	}
+
+	last, _ := fingerprint(a.policyPath)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		fp, err := fingerprint(a.policyPath)
+		if err != nil {
+			a.log.Error(ctx, "auth", "status", "policy stat failed", "path", a.policyPath, "msg", err)
+			continue
+		}
+
+		if fp == last {
+			continue
+		}
+		last = fp
+
+		if err := a.ReloadPolicies(ctx); err != nil {
+			a.log.Error(ctx, "auth", "status", "policy reload failed, keeping last good policy", "path", a.policyPath, "revision", a.PolicyRevision(), "msg", err)
+			continue
+		}
+
+		a.log.Info(ctx, "auth", "status", "policy reloaded", "path", a.policyPath, "revision", a.PolicyRevision())
+	}
+}
+
+// fingerprint summarizes the name, size and modification time of every file
+// under the path so changes can be detected without reading the files.
+func fingerprint(path string) (string, error) {
+	hash := sha256.New()
+
+	fn := func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(hash, "%s|%d|%d\n", name, info.Size(), info.ModTime().UnixNano())
+
+		return nil
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+
+	if err := filepath.WalkDir(path, fn); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
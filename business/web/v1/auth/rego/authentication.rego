@@ -5,12 +5,57 @@ import future.keywords.in
 
 default auth := false
 
+algorithms := {"RS256", "ES256", "EdDSA"}
+
 auth if {
-	[valid, _, _] := verify_jwt
-	valid = true
+	[header, payload, _] := io.jwt.decode(input.Token)
+	header.alg in algorithms
+	header.alg == input.Alg
+	valid_signature
+	payload.iss == input.ISS
+	valid_audience(payload)
+	valid_time(payload)
+}
+
+valid_signature if {
+	input.Alg == "RS256"
+	io.jwt.verify_rs256(input.Token, input.Key)
+}
+
+valid_signature if {
+	input.Alg == "ES256"
+	io.jwt.verify_es256(input.Token, input.Key)
+}
+
+# OPA does not implement EdDSA signatures. The service verifies the signature
+# and passes the result in.
+valid_signature if {
+	input.Alg == "EdDSA"
+	input.Verified == true
+}
+
+# Without a configured audience any audience is accepted.
+valid_audience(_) if input.AUD == ""
+
+valid_audience(payload) if {
+	input.AUD != ""
+	input.AUD in audiences(payload)
+}
+
+audiences(payload) := {payload.aud} if is_string(payload.aud)
+
+audiences(payload) := {aud | some aud in payload.aud} if is_array(payload.aud)
+
+# Time based claims are checked in seconds with the configured clock skew.
+# Tokens must expire and can't be issued in the future.
+now := time.now_ns() / 1000000000
+
+valid_time(payload) if {
+	now < payload.exp + input.Skew
+	payload.iat <= now + input.Skew
+	not_before(payload)
 }
 
-verify_jwt := io.jwt.decode_verify(input.Token, {
-	"cert": input.Key,
-	"iss": input.ISS,
-})
+not_before(payload) if not payload.nbf
+
+not_before(payload) if payload.nbf <= now + input.Skew
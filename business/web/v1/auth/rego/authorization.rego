@@ -11,11 +11,23 @@ default rule_user_only := false
 
 default rule_admin_or_subject := false
 
+default rule_admin_or_supervisor := false
+
+default rule_admin_or_region_supervisor := false
+
+default rule_admin_region_supervisor_or_subject := false
+
+default rule_scope_all := false
+
+default rule_scope_region := false
+
 role_user := "USER"
 
 role_admin := "ADMIN"
 
-role_all := {role_admin, role_user}
+role_supervisor := "SUPERVISOR"
+
+role_all := {role_admin, role_supervisor, role_user}
 
 rule_any if {
 	claim_roles := {role | some role in input.Roles}
@@ -45,3 +57,54 @@ rule_admin_or_subject if {
 	count(input_user) > 0
 	input.UserID == input.Subject
 }
+
+rule_admin_or_supervisor if {
+	claim_roles := {role | some role in input.Roles}
+	input_roles := {role_admin, role_supervisor} & claim_roles
+	count(input_roles) > 0
+}
+
+rule_admin_or_region_supervisor if {
+	is_admin
+} else if {
+	is_region_supervisor
+}
+
+rule_admin_region_supervisor_or_subject if {
+	is_admin
+} else if {
+	is_region_supervisor
+} else if {
+	role_user in input.Roles
+	input.UserID == input.Subject
+}
+
+# Lists are narrowed instead of forbidden. A caller sees every record of
+# the resource when rule_scope_all holds, otherwise the records in their
+# region when rule_scope_region holds and only their own records when
+# neither does.
+rule_scope_all if {
+	is_admin
+}
+
+rule_scope_all if {
+	input.Resource == "patients"
+	role_user in input.Roles
+	not role_supervisor in input.Roles
+}
+
+rule_scope_region if {
+	role_supervisor in input.Roles
+}
+
+is_admin if {
+	role_admin in input.Roles
+}
+
+# A supervisor may only act on resources in their own region. Callers and
+# resources without a region never match.
+is_region_supervisor if {
+	role_supervisor in input.Roles
+	input.RegionID != ""
+	input.RegionID == input.ResourceRegionID
+}
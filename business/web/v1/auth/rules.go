@@ -6,13 +6,33 @@ import (
 
 // These the current set of rules we have for auth.
 const (
-	RuleAuthenticate   = "auth"
-	RuleAny            = "rule_any"
-	RuleAdminOnly      = "rule_admin_only"
-	RuleUserOnly       = "rule_user_only"
-	RuleAdminOrSubject = "rule_admin_or_subject"
+	RuleAuthenticate                   = "auth"
+	RuleAny                            = "rule_any"
+	RuleAdminOnly                      = "rule_admin_only"
+	RuleUserOnly                       = "rule_user_only"
+	RuleAdminOrSubject                 = "rule_admin_or_subject"
+	RuleAdminOrSupervisor              = "rule_admin_or_supervisor"
+	RuleAdminOrRegionSupervisor        = "rule_admin_or_region_supervisor"
+	RuleAdminRegionSupervisorOrSubject = "rule_admin_region_supervisor_or_subject"
+	RuleScopeAll                       = "rule_scope_all"
+	RuleScopeRegion                    = "rule_scope_region"
 )
 
+// rules is the set of rules every policy must define. A query is prepared
+// for each of them when the policy is loaded.
+var rules = []string{
+	RuleAuthenticate,
+	RuleAny,
+	RuleAdminOnly,
+	RuleUserOnly,
+	RuleAdminOrSubject,
+	RuleAdminOrSupervisor,
+	RuleAdminOrRegionSupervisor,
+	RuleAdminRegionSupervisorOrSubject,
+	RuleScopeAll,
+	RuleScopeRegion,
+}
+
 // Package name of our rego code.
 const (
 	opaPackage string = "ardan.rego"
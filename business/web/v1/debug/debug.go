@@ -3,17 +3,27 @@ package debug
 
 import (
 	"expvar"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/metrics"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"net/http"
 	"net/http/pprof"
 
 	"github.com/arl/statsviz"
 )
 
+// Config contains the systems required by the debug endpoints.
+type Config struct {
+	Log  *logger.Logger
+	Auth *auth.Auth
+}
+
 // Mux registers all the debug routes from the standard library into a new mux
 // bypassing the use of the DefaultServerMux. Using the DefaultServerMux would
 // be a security risk since a dependency could inject a handler into our service
-// without us knowing it.
-func Mux() *http.ServeMux {
+// without us knowing it. The log level can be changed at runtime by admins
+// at /debug/loglevel.
+func Mux(cfg Config) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/debug/pprof/", pprof.Index)
@@ -22,6 +32,8 @@ func Mux() *http.ServeMux {
 	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 	mux.Handle("/debug/vars/", expvar.Handler())
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/debug/loglevel", logLevel(cfg.Log, cfg.Auth))
 
 	statsviz.Register(mux)
 
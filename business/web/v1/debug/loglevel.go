@@ -0,0 +1,63 @@
+package debug
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"net/http"
+
+	"github.com/go-json-experiment/json"
+	"github.com/google/uuid"
+)
+
+// LogLevel represents the minimum level of the records that are logged.
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+// logLevel returns a handler reporting the minimum log level on GET and
+// changing it on PUT. Only admins can use it, the debug server has no
+// middleware so the token is checked here.
+func logLevel(log *logger.Logger, a *auth.Auth) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		claims, err := a.Authenticate(ctx, r.Header.Get("authorization"))
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		if err := a.Authorize(ctx, claims, uuid.UUID{}, auth.RuleAdminOnly); err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+
+		case http.MethodPut:
+			var ll LogLevel
+			if err := json.UnmarshalRead(r.Body, &ll); err != nil {
+				http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			level, err := logger.ParseLevel(ll.Level)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			log.Warn(ctx, "log level", "status", "changed", "from", log.Level().String(), "to", level.String(), "subject", claims.Subject)
+			log.SetLevel(level)
+
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.MarshalWrite(w, LogLevel{Level: log.Level().String()})
+	}
+}
@@ -0,0 +1,57 @@
+// Package etag provides support for optimistic concurrency using entity tags
+// derived from the version of a resource.
+package etag
+
+import (
+	"errors"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Set of error variables for handling preconditions.
+var (
+	ErrPreconditionFailed   = errors.New("resource has changed, fetch the latest version and try again")
+	ErrPreconditionRequired = errors.New("If-Match header is required to change this resource")
+)
+
+// Format returns the entity tag for the specified version of a resource.
+func Format(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// Set adds the ETag header for the specified version to the response.
+func Set(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", Format(version))
+}
+
+// Check validates the If-Match header of the request against the current
+// version of the resource. A request without the header passes.
+func Check(r *http.Request, version int) error {
+	values := r.Header.Values("If-Match")
+	if len(values) == 0 {
+		return nil
+	}
+
+	if !Match(strings.Join(values, ","), version) {
+		return v1.NewTrustedError(ErrPreconditionFailed, http.StatusPreconditionFailed)
+	}
+
+	return nil
+}
+
+// Match reports whether the If-Match header value matches the version. Weak
+// tags never match since If-Match requires a strong comparison.
+func Match(header string, version int) bool {
+	tag := Format(version)
+
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimSpace(value)
+		if value == "*" || value == tag {
+			return true
+		}
+	}
+
+	return false
+}
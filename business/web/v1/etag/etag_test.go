@@ -0,0 +1,47 @@
+package etag_test
+
+import (
+	"errors"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/etag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		status  int
+	}{
+		{name: "none", ifMatch: "", status: 0},
+		{name: "match", ifMatch: `"3"`, status: 0},
+		{name: "list", ifMatch: `"2", "3"`, status: 0},
+		{name: "any", ifMatch: "*", status: 0},
+		{name: "stale", ifMatch: `"2"`, status: http.StatusPreconditionFailed},
+		{name: "weak", ifMatch: `W/"3"`, status: http.StatusPreconditionFailed},
+		{name: "unquoted", ifMatch: `3`, status: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/v1/patients/1", nil)
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			err := etag.Check(r, 3)
+
+			var status int
+			var trusted *v1.TrustedError
+			if errors.As(err, &trusted) {
+				status = trusted.Status
+			}
+
+			if status != tt.status {
+				t.Errorf("Should get status %d: got %d: %v", tt.status, status, err)
+			}
+		})
+	}
+}
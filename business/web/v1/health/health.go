@@ -0,0 +1,120 @@
+// Package health provides support for reporting whether the service and the
+// dependencies it needs are ready to receive traffic.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrShuttingDown is returned for every check once the service starts to
+// shut down.
+var ErrShuttingDown = errors.New("shutting down")
+
+// Check represents a dependency the service needs to serve traffic. The
+// function returns a non-nil error when the dependency can't be reached.
+type Check struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// Result represents the outcome of a single check.
+type Result struct {
+	Name string
+	Err  error
+}
+
+// Readiness reports whether the service is ready by running the checks of
+// the dependencies it needs. Once shutdown begins the service reports it is
+// not ready without running the checks, so load balancers stop sending
+// traffic before the server stops accepting it.
+type Readiness struct {
+	timeout      time.Duration
+	checks       []Check
+	shuttingDown atomic.Bool
+}
+
+// NewReadiness constructs a Readiness running the checks, each of which must
+// complete within the timeout.
+func NewReadiness(timeout time.Duration, checks ...Check) *Readiness {
+	return &Readiness{
+		timeout: timeout,
+		checks:  checks,
+	}
+}
+
+// Add registers another check that must pass for the service to be ready.
+// It should be called before the service starts to receive traffic.
+func (r *Readiness) Add(check Check) {
+	r.checks = append(r.checks, check)
+}
+
+// Shutdown marks the service as shutting down. Every check fails from now on.
+func (r *Readiness) Shutdown() {
+	r.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether the service started to shut down.
+func (r *Readiness) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Check runs all the checks concurrently and returns the results in the
+// order the checks were registered. The service is ready when the returned
+// error is nil.
+func (r *Readiness) Check(ctx context.Context) ([]Result, error) {
+	if r.ShuttingDown() {
+		return nil, ErrShuttingDown
+	}
+
+	results := make([]Result, len(r.checks))
+
+	var wg sync.WaitGroup
+	wg.Add(len(r.checks))
+
+	for i, check := range r.checks {
+		go func(i int, check Check) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+
+			results[i] = Result{
+				Name: check.Name,
+				Err:  run(ctx, check),
+			}
+		}(i, check)
+	}
+
+	wg.Wait()
+
+	var errs []error
+	for _, res := range results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Name, res.Err))
+		}
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// run executes the check and gives up when the context is done, even if the
+// check doesn't honor the context itself.
+func run(ctx context.Context, check Check) error {
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- check.Fn(ctx)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,88 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/health"
+	"testing"
+	"time"
+)
+
+func Test_Readiness(t *testing.T) {
+	pass := health.Check{
+		Name: "pass",
+		Fn:   func(ctx context.Context) error { return nil },
+	}
+
+	fail := health.Check{
+		Name: "fail",
+		Fn:   func(ctx context.Context) error { return errors.New("unreachable") },
+	}
+
+	hang := health.Check{
+		Name: "hang",
+		Fn: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	}
+
+	t.Run("ready", func(t *testing.T) {
+		r := health.NewReadiness(time.Second, pass)
+
+		results, err := r.Check(context.Background())
+		if err != nil {
+			t.Fatalf("Should be ready: %s", err)
+		}
+
+		if len(results) != 1 || results[0].Name != "pass" || results[0].Err != nil {
+			t.Errorf("Should get a passing result: got %+v", results)
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		r := health.NewReadiness(time.Second, pass)
+		r.Add(fail)
+
+		results, err := r.Check(context.Background())
+		if err == nil {
+			t.Fatal("Should not be ready with a failing check.")
+		}
+
+		if len(results) != 2 || results[0].Err != nil || results[1].Err == nil {
+			t.Errorf("Should get the results in order with only the second failing: got %+v", results)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		r := health.NewReadiness(10*time.Millisecond, hang)
+
+		start := time.Now()
+
+		results, err := r.Check(context.Background())
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Should time out: got %v", err)
+		}
+
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("Should not wait for the check to return: took %s", elapsed)
+		}
+
+		if len(results) != 1 || results[0].Err == nil {
+			t.Errorf("Should get a failing result: got %+v", results)
+		}
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		r := health.NewReadiness(time.Second, pass)
+		r.Shutdown()
+
+		if !r.ShuttingDown() {
+			t.Fatal("Should be shutting down.")
+		}
+
+		if _, err := r.Check(context.Background()); !errors.Is(err, health.ErrShuttingDown) {
+			t.Errorf("Should not be ready once shutdown begins: got %v", err)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+// Package idempotency provides support for replaying the response of a
+// request that was retried with the same Idempotency-Key.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	"time"
+)
+
+// Set of error variables for idempotency keys.
+var (
+	ErrNotFound   = errors.New("idempotency key not found")
+	ErrKeyInvalid = errors.New("idempotency key must be between 1 and 255 characters")
+	ErrKeyReused  = errors.New("idempotency key was already used for a different request")
+	ErrInProgress = errors.New("a request with this idempotency key is still in progress")
+	ErrBodyTooBig = errors.New("request body is too large to be made idempotent")
+)
+
+// MaxKeyLength is the longest idempotency key that is accepted.
+const MaxKeyLength = 255
+
+// DefaultMaxBodySize is the largest request body kept for a key when no
+// limit is configured.
+const DefaultMaxBodySize = 1 << 20
+
+// Record represents a request made with an idempotency key and the response
+// it produced. A StatusCode of zero means the request has not completed.
+// Headers holds the response headers replayed along with the body.
+type Record struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	StatusCode  int
+	ContentType string
+	Headers     map[string]string
+	Body        []byte
+	DateCreated time.Time
+	ExpiresAt   time.Time
+}
+
+// Completed reports whether the response of the request has been recorded.
+func (r Record) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve idempotency records.
+type Storer interface {
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+	Claim(ctx context.Context, rec Record) (bool, error)
+	QueryByKey(ctx context.Context, scope string, key string) (Record, error)
+	Complete(ctx context.Context, rec Record) error
+}
+
+// Fingerprint identifies the content of a request so a reused key can be
+// told apart from a retry.
+func Fingerprint(method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,155 @@
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/idempotency"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_Idempotency(t *testing.T) {
+	log := logger.New(os.Stdout, logger.LevelError, "TEST", func(context.Context) string { return "" })
+
+	var calls int
+	var handler web.Handler = func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		calls++
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, calls))
+		w.Header().Set("Location", fmt.Sprintf("/v1/patients/%d", calls))
+		return web.Respond(ctx, w, map[string]int{"call": calls}, http.StatusCreated)
+	}
+
+	h := mid.Idempotency(log, newMemoryStore(), time.Hour, 64)(handler)
+
+	call := func(key string, body string) (*httptest.ResponseRecorder, error) {
+		r := httptest.NewRequest(http.MethodPost, "/v1/patients", strings.NewReader(body))
+		if key != "" {
+			r.Header.Set("Idempotency-Key", key)
+		}
+		w := httptest.NewRecorder()
+
+		ctx := transaction.Set(context.Background(), tx{})
+
+		return w, h(ctx, w, r)
+	}
+
+	w, err := call("key-1", `{"name":"a"}`)
+	if err != nil {
+		t.Fatalf("Should be able to create: %s", err)
+	}
+	first := w.Body.String()
+
+	w, err = call("key-1", `{"name":"a"}`)
+	if err != nil {
+		t.Fatalf("Should be able to retry: %s", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("Should not execute the handler for a retry: got %d calls", calls)
+	}
+
+	if w.Code != http.StatusCreated || w.Body.String() != first {
+		t.Errorf("Should replay the response: got %d %s, exp %d %s", w.Code, w.Body.String(), http.StatusCreated, first)
+	}
+
+	if w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("Should mark the response as replayed")
+	}
+
+	if w.Header().Get("ETag") != `"1"` || w.Header().Get("Location") != "/v1/patients/1" {
+		t.Errorf("Should replay the ETag and Location headers: got %v", w.Header())
+	}
+
+	_, err = call("key-1", `{"name":"b"}`)
+
+	var trusted *v1.TrustedError
+	if !errors.As(err, &trusted) || trusted.Status != http.StatusUnprocessableEntity {
+		t.Errorf("Should reject a reused key with a different body: got %v", err)
+	}
+
+	_, err = call("key-3", `{"name":"`+strings.Repeat("a", 64)+`"}`)
+	if !errors.As(err, &trusted) || trusted.Status != http.StatusRequestEntityTooLarge {
+		t.Errorf("Should reject a body larger than the limit: got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := call("", `{"name":"a"}`); err != nil {
+			t.Fatalf("Should be able to create without a key: %s", err)
+		}
+	}
+
+	if calls != 3 {
+		t.Errorf("Should execute every request without a key: got %d calls", calls)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/patients", strings.NewReader(`{}`))
+	r.Header.Set("Idempotency-Key", "key-2")
+	if err := h(context.Background(), httptest.NewRecorder(), r); err == nil {
+		t.Error("Should fail outside of a transaction")
+	}
+}
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]idempotency.Record
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		records: make(map[string]idempotency.Record),
+	}
+}
+
+func (ms *memoryStore) ExecuteUnderTransaction(tx transaction.Transaction) (idempotency.Storer, error) {
+	return ms, nil
+}
+
+func (ms *memoryStore) Claim(ctx context.Context, rec idempotency.Record) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if prev, exists := ms.records[rec.Scope+rec.Key]; exists && prev.ExpiresAt.After(rec.DateCreated) {
+		return false, nil
+	}
+
+	ms.records[rec.Scope+rec.Key] = rec
+
+	return true, nil
+}
+
+func (ms *memoryStore) QueryByKey(ctx context.Context, scope string, key string) (idempotency.Record, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	rec, exists := ms.records[scope+key]
+	if !exists {
+		return idempotency.Record{}, fmt.Errorf("querybykey: %w", idempotency.ErrNotFound)
+	}
+
+	return rec, nil
+}
+
+func (ms *memoryStore) Complete(ctx context.Context, rec idempotency.Record) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.records[rec.Scope+rec.Key] = rec
+
+	return nil
+}
@@ -0,0 +1,164 @@
+// Package idempotencydb contains the database backed storage of idempotency
+// keys.
+package idempotencydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/idempotency"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for idempotency key database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (idempotency.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Claim inserts the record unless a live record already exists for the key.
+// An expired record is replaced. It reports whether the key was claimed.
+// While the claiming transaction is open, other claims for the same key
+// wait for it to finish.
+func (s *Store) Claim(ctx context.Context, rec idempotency.Record) (bool, error) {
+	const q = `
+	INSERT INTO idempotency_keys
+		(scope, key, fingerprint, status_code, content_type, headers, body, date_created, expires_at)
+	VALUES
+		(:scope, :key, :fingerprint, :status_code, :content_type, :headers, :body, :date_created, :expires_at)
+	ON CONFLICT (scope, key) DO UPDATE SET
+		fingerprint = EXCLUDED.fingerprint,
+		status_code = EXCLUDED.status_code,
+		content_type = EXCLUDED.content_type,
+		headers = EXCLUDED.headers,
+		body = EXCLUDED.body,
+		date_created = EXCLUDED.date_created,
+		expires_at = EXCLUDED.expires_at
+	WHERE
+		idempotency_keys.expires_at <= EXCLUDED.date_created
+	RETURNING
+		scope, key`
+
+	dbRec, err := toDBRecord(rec)
+	if err != nil {
+		return false, err
+	}
+
+	var dest struct {
+		Scope string `db:"scope"`
+		Key   string `db:"key"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, dbRec, &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return true, nil
+}
+
+// QueryByKey gets the record for the specified key from the database.
+func (s *Store) QueryByKey(ctx context.Context, scope string, key string) (idempotency.Record, error) {
+	data := struct {
+		Scope string `db:"scope"`
+		Key   string `db:"key"`
+	}{
+		Scope: scope,
+		Key:   key,
+	}
+
+	const q = `
+	SELECT
+		scope, key, fingerprint, status_code, content_type, headers, body, date_created, expires_at
+	FROM
+		idempotency_keys
+	WHERE
+		scope = :scope AND key = :key`
+
+	var dbRec dbRecord
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbRec); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return idempotency.Record{}, fmt.Errorf("namedquerystruct: %w", idempotency.ErrNotFound)
+		}
+		return idempotency.Record{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreRecord(dbRec)
+}
+
+// Complete records the response of the request.
+func (s *Store) Complete(ctx context.Context, rec idempotency.Record) error {
+	const q = `
+	UPDATE
+		idempotency_keys
+	SET
+		status_code = :status_code,
+		content_type = :content_type,
+		headers = :headers,
+		body = :body
+	WHERE
+		scope = :scope AND key = :key`
+
+	dbRec, err := toDBRecord(rec)
+	if err != nil {
+		return err
+	}
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, dbRec); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Prune removes the records that expired before the specified time.
+func (s *Store) Prune(ctx context.Context, now time.Time) error {
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now,
+	}
+
+	const q = `
+	DELETE FROM
+		idempotency_keys
+	WHERE
+		expires_at <= :now`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
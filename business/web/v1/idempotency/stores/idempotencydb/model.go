@@ -0,0 +1,73 @@
+package idempotencydb
+
+import (
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/idempotency"
+	"time"
+
+	"github.com/go-json-experiment/json"
+)
+
+type dbRecord struct {
+	Scope       string    `db:"scope"`
+	Key         string    `db:"key"`
+	Fingerprint string    `db:"fingerprint"`
+	StatusCode  int       `db:"status_code"`
+	ContentType string    `db:"content_type"`
+	Headers     string    `db:"headers"`
+	Body        []byte    `db:"body"`
+	DateCreated time.Time `db:"date_created"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
+
+func toDBRecord(rec idempotency.Record) (dbRecord, error) {
+	body := rec.Body
+	if body == nil {
+		body = []byte{}
+	}
+
+	headers := rec.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+
+	hdrs, err := json.Marshal(headers)
+	if err != nil {
+		return dbRecord{}, fmt.Errorf("marshal headers: %w", err)
+	}
+
+	dbRec := dbRecord{
+		Scope:       rec.Scope,
+		Key:         rec.Key,
+		Fingerprint: rec.Fingerprint,
+		StatusCode:  rec.StatusCode,
+		ContentType: rec.ContentType,
+		Headers:     string(hdrs),
+		Body:        body,
+		DateCreated: rec.DateCreated.UTC(),
+		ExpiresAt:   rec.ExpiresAt.UTC(),
+	}
+
+	return dbRec, nil
+}
+
+func toCoreRecord(dbRec dbRecord) (idempotency.Record, error) {
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(dbRec.Headers), &headers); err != nil {
+		return idempotency.Record{}, fmt.Errorf("unmarshal headers: %w", err)
+	}
+
+	rec := idempotency.Record{
+		Scope:       dbRec.Scope,
+		Key:         dbRec.Key,
+		Fingerprint: dbRec.Fingerprint,
+		StatusCode:  dbRec.StatusCode,
+		ContentType: dbRec.ContentType,
+		Headers:     headers,
+		Body:        dbRec.Body,
+		DateCreated: dbRec.DateCreated.In(time.Local),
+		ExpiresAt:   dbRec.ExpiresAt.In(time.Local),
+	}
+
+	return rec, nil
+}
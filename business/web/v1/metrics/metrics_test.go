@@ -0,0 +1,58 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/metrics"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_Handler(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelError, "TEST", func(context.Context) string { return "" })
+
+	app := web.NewApp(make(chan os.Signal, 1), nil, mid.Metrics(), mid.Errors(log), mid.Panics())
+
+	app.Handle(http.MethodGet, "v1", "/things/{thing_id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		if r.PathValue("thing_id") == "missing" {
+			return v1.NewTrustedError(errors.New("thing not found"), http.StatusNotFound)
+		}
+
+		return web.Respond(ctx, w, "ok", http.StatusOK)
+	})
+
+	for _, path := range []string{"/v1/things/1", "/v1/things/2", "/v1/things/missing"} {
+		app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Should be able to scrape the metrics: got status %d", w.Code)
+	}
+
+	body := w.Body.String()
+
+	exp := []string{
+		`http_requests_total{method="GET",route="/v1/things/{thing_id}",status="200"} 2`,
+		`http_requests_total{method="GET",route="/v1/things/{thing_id}",status="404"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/v1/things/{thing_id}",status="200"} 2`,
+		`http_requests_in_flight 0`,
+		`go_goroutines`,
+	}
+
+	for _, line := range exp {
+		if !strings.Contains(body, line) {
+			t.Errorf("Should find %s in the metrics", line)
+		}
+	}
+}
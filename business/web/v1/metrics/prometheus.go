@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// registry holds the Prometheus collectors. A registry of our own is used
+// instead of the default one so a dependency can't add metrics to our
+// endpoint without us knowing it, for the same reason the debug mux doesn't
+// use the DefaultServeMux.
+var registry = prometheus.NewRegistry()
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of requests handled by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of the requests handled by route, method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	inFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of requests being handled.",
+	})
+
+	policyDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "opa_evaluation_duration_seconds",
+		Help:    "Latency of the policy evaluations by rule and result.",
+		Buckets: []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1},
+	}, []string{"rule", "result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requests,
+		requestDuration,
+		inFlight,
+		policyDuration,
+	)
+}
+
+// Handler returns the handler serving the metrics in the Prometheus text
+// format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB adds the connection pool statistics of the database to the
+// metrics, labelled with the specified name.
+func RegisterDB(db *sql.DB, name string) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RequestStarted increments the number of requests in flight. It must be
+// paired with a call to RequestCompleted.
+func RequestStarted() {
+	inFlight.Inc()
+}
+
+// RequestCompleted decrements the number of requests in flight and records
+// the request against the route pattern it matched. The pattern is used
+// rather than the path so ids in the path don't create a series per id.
+func RequestCompleted(route string, method string, status int, took time.Duration) {
+	inFlight.Dec()
+
+	code := strconv.Itoa(status)
+	requests.WithLabelValues(route, method, code).Inc()
+	requestDuration.WithLabelValues(route, method, code).Observe(took.Seconds())
+}
+
+// ObservePolicy records how long evaluating the policy rule took and its
+// result.
+func ObservePolicy(rule string, result string, took time.Duration) {
+	policyDuration.WithLabelValues(rule, result).Observe(took.Seconds())
+}
@@ -0,0 +1,42 @@
+package mid
+
+import (
+	"context"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/audit"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// AuditActor returns the authenticated user making the request, the trace
+// of the request and the address it came from for the audit log.
+func AuditActor(ctx context.Context, r *http.Request) audit.Actor {
+	return audit.Actor{
+		UserID:   GetUserID(ctx),
+		TraceID:  web.GetTraceID(ctx),
+		ClientIP: clientIP(ctx, r),
+	}
+}
+
+// AuditChange appends an entry for the change of the entity to the audit log
+// with the actor making the request. Before is nil for an entity that was
+// created and after is nil for one that was deleted. It must be called with
+// the audit core of the transaction making the change.
+func AuditChange(ctx context.Context, r *http.Request, core *audit.Core, action string, entityType string, entityID uuid.UUID, before any, after any) error {
+	ne := audit.NewEntry{
+		Actor:      AuditActor(ctx, r),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     before,
+		After:      after,
+	}
+
+	if _, err := core.Create(ctx, ne); err != nil {
+		return fmt.Errorf("audit: %s[%s]: %w", entityType, entityID, err)
+	}
+
+	return nil
+}
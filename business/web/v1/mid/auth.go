@@ -3,6 +3,7 @@ package mid
 import (
 	"context"
 	"errors"
+	"fmt"
 	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
@@ -47,10 +48,10 @@ func Authenticate(a *auth.Auth) web.MidHandler {
 }
 
 // Authorize executes the specified role and does not extract any domain data.
-func Authorize(a *auth.Auth, rule string) web.MidHandler {
+func Authorize(a *auth.Auth, rule string) web.DocMidHandler {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-			claims := getClaims(ctx)
+			claims := GetClaims(ctx)
 			if err := a.Authorize(ctx, claims, uuid.UUID{}, rule); err != nil {
 				return auth.NewAuthError("authorize: you are not authorized for that action, claims[%v] rule[%v]: %s", claims.Roles, rule, err)
 			}
@@ -61,5 +62,39 @@ func Authorize(a *auth.Auth, rule string) web.MidHandler {
 		return h
 	}
 
+	return describeRule(m, rule)
+}
+
+// describeRule documents the routes the authorization middleware is bound to
+// with the rule it enforces.
+func describeRule(m web.MidHandler, rule string) web.DocMidHandler {
+	dm := web.DocMidHandler{
+		MidHandler: m,
+		Describe: func(doc *web.RouteDoc) {
+			doc.Rule = rule
+		},
+	}
+
+	return dm
+}
+
+// Scope asks the policy which records of the resource a list may show the
+// caller and stores the answer for the handler to narrow its filter with.
+func Scope(a *auth.Auth, resource string) web.MidHandler {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			scope, err := a.Scope(ctx, GetClaims(ctx), resource)
+			if err != nil {
+				return fmt.Errorf("scope: resource[%s]: %w", resource, err)
+			}
+
+			ctx = setScope(ctx, scope)
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
 	return m
 }
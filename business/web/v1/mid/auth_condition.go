@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/condition"
-	"github.com/fadhilijuma/gateone-service/business/core/crud/patient"
 	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
@@ -35,12 +34,12 @@ func setCondition(ctx context.Context, cn condition.Condition) context.Context {
 // condition from the DB if a condition id is specified in the call. Depending on
 // the rule specified, the userid from the claims may be compared with the
 // specified user id from the condition.
-func AuthorizeCondition(a *auth.Auth, rule string, cnCore *condition.Core) web.MidHandler {
+func AuthorizeCondition(a *auth.Auth, rule string, cnCore *condition.Core) web.DocMidHandler {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 			var userID uuid.UUID
 
-			if id := web.Param(r, "patient_id"); id != "" {
+			if id := web.Param(r, "condition_id"); id != "" {
 				var err error
 				conditionID, err := uuid.Parse(id)
 				if err != nil {
@@ -50,8 +49,8 @@ func AuthorizeCondition(a *auth.Auth, rule string, cnCore *condition.Core) web.M
 				cn, err := cnCore.QueryByID(ctx, conditionID)
 				if err != nil {
 					switch {
-					case errors.Is(err, patient.ErrNotFound):
-						return v1.NewTrustedError(err, http.StatusNoContent)
+					case errors.Is(err, condition.ErrNotFound):
+						return v1.NewTrustedError(err, http.StatusNotFound)
 					default:
 						return fmt.Errorf("querybyid: conditionID[%s]: %w", conditionID, err)
 					}
@@ -61,7 +60,7 @@ func AuthorizeCondition(a *auth.Auth, rule string, cnCore *condition.Core) web.M
 				ctx = setCondition(ctx, cn)
 			}
 
-			claims := getClaims(ctx)
+			claims := GetClaims(ctx)
 
 			if err := a.Authorize(ctx, claims, userID, rule); err != nil {
 				return auth.NewAuthError("authorize: you are not authorized for that action, claims[%v] rule[%v]: %s", claims.Roles, rule, err)
@@ -73,5 +72,5 @@ func AuthorizeCondition(a *auth.Auth, rule string, cnCore *condition.Core) web.M
 		return h
 	}
 
-	return m
+	return describeRule(m, rule)
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/patient"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
 	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
@@ -33,11 +34,13 @@ func setPatient(ctx context.Context, pn patient.Patient) context.Context {
 // AuthorizePatient executes the specified role and extracts the specified
 // patient from the DB if a patient id is specified in the call. Depending on
 // the rule specified, the userid from the claims may be compared with the
-// specified user id from the patient.
-func AuthorizePatient(a *auth.Auth, rule string, prdCore *patient.Core) web.MidHandler {
+// specified user id from the patient. A patient belongs to the region of the
+// user responsible for them, which is resolved for regional rules.
+func AuthorizePatient(a *auth.Auth, rule string, prdCore *patient.Core, usrCore *user.Core) web.DocMidHandler {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 			var userID uuid.UUID
+			var regionID uuid.UUID
 
 			if id := web.Param(r, "patient_id"); id != "" {
 				var err error
@@ -50,19 +53,25 @@ func AuthorizePatient(a *auth.Auth, rule string, prdCore *patient.Core) web.MidH
 				if err != nil {
 					switch {
 					case errors.Is(err, patient.ErrNotFound):
-						return v1.NewTrustedError(err, http.StatusNoContent)
+						return v1.NewTrustedError(err, http.StatusNotFound)
 					default:
 						return fmt.Errorf("querybyid: patientID[%s]: %w", patientID, err)
 					}
 				}
 
+				usr, err := usrCore.QueryByID(ctx, prd.UserID)
+				if err != nil {
+					return fmt.Errorf("querybyid: userID[%s]: %w", prd.UserID, err)
+				}
+
 				userID = prd.UserID
+				regionID = usr.RegionID
 				ctx = setPatient(ctx, prd)
 			}
 
-			claims := getClaims(ctx)
+			claims := GetClaims(ctx)
 
-			if err := a.Authorize(ctx, claims, userID, rule); err != nil {
+			if err := a.AuthorizeRegion(ctx, claims, userID, regionID, rule); err != nil {
 				return auth.NewAuthError("authorize: you are not authorized for that action, claims[%v] rule[%v]: %s", claims.Roles, rule, err)
 			}
 
@@ -72,5 +81,5 @@ func AuthorizePatient(a *auth.Auth, rule string, prdCore *patient.Core) web.MidH
 		return h
 	}
 
-	return m
+	return describeRule(m, rule)
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/fadhilijuma/gateone-service/business/core/crud/patient"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/region"
 	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
@@ -35,12 +34,12 @@ func setRegion(ctx context.Context, rn region.Region) context.Context {
 // region from the DB if a role id is specified in the call. Depending on
 // the rule specified, the userid from the claims may be compared with the
 // specified user id from the region.
-func AuthorizeRegion(a *auth.Auth, rule string, rCore *region.Core) web.MidHandler {
+func AuthorizeRegion(a *auth.Auth, rule string, rCore *region.Core) web.DocMidHandler {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 			var userID uuid.UUID
 
-			if id := web.Param(r, "patient_id"); id != "" {
+			if id := web.Param(r, "region_id"); id != "" {
 				var err error
 				roleID, err := uuid.Parse(id)
 				if err != nil {
@@ -50,8 +49,8 @@ func AuthorizeRegion(a *auth.Auth, rule string, rCore *region.Core) web.MidHandl
 				reg, err := rCore.QueryByID(ctx, roleID)
 				if err != nil {
 					switch {
-					case errors.Is(err, patient.ErrNotFound):
-						return v1.NewTrustedError(err, http.StatusNoContent)
+					case errors.Is(err, region.ErrNotFound):
+						return v1.NewTrustedError(err, http.StatusNotFound)
 					default:
 						return fmt.Errorf("querybyid: roleID[%s]: %w", roleID, err)
 					}
@@ -61,7 +60,7 @@ func AuthorizeRegion(a *auth.Auth, rule string, rCore *region.Core) web.MidHandl
 				ctx = setRegion(ctx, reg)
 			}
 
-			claims := getClaims(ctx)
+			claims := GetClaims(ctx)
 
 			if err := a.Authorize(ctx, claims, userID, rule); err != nil {
 				return auth.NewAuthError("authorize: you are not authorized for that action, claims[%v] rule[%v]: %s", claims.Roles, rule, err)
@@ -73,5 +72,5 @@ func AuthorizeRegion(a *auth.Auth, rule string, rCore *region.Core) web.MidHandl
 		return h
 	}
 
-	return m
+	return describeRule(m, rule)
 }
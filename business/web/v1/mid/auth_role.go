@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/fadhilijuma/gateone-service/business/core/crud/patient"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/role"
 	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
@@ -35,33 +34,33 @@ func setRole(ctx context.Context, rl role.Role) context.Context {
 // role from the DB if a role id is specified in the call. Depending on
 // the rule specified, the userid from the claims may be compared with the
 // specified user id from the role.
-func AuthorizeRole(a *auth.Auth, rule string, rCore *role.Core) web.MidHandler {
+func AuthorizeRole(a *auth.Auth, rule string, rCore *role.Core) web.DocMidHandler {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 			var userID uuid.UUID
 
-			if id := web.Param(r, "patient_id"); id != "" {
+			if id := web.Param(r, "role_id"); id != "" {
 				var err error
 				roleID, err := uuid.Parse(id)
 				if err != nil {
 					return v1.NewTrustedError(ErrInvalidID, http.StatusBadRequest)
 				}
 
-				role, err := rCore.QueryByID(ctx, roleID)
+				rl, err := rCore.QueryByID(ctx, roleID)
 				if err != nil {
 					switch {
-					case errors.Is(err, patient.ErrNotFound):
-						return v1.NewTrustedError(err, http.StatusNoContent)
+					case errors.Is(err, role.ErrNotFound):
+						return v1.NewTrustedError(err, http.StatusNotFound)
 					default:
 						return fmt.Errorf("querybyid: roleID[%s]: %w", roleID, err)
 					}
 				}
 
-				userID = role.UserID
-				ctx = setRole(ctx, role)
+				userID = rl.UserID
+				ctx = setRole(ctx, rl)
 			}
 
-			claims := getClaims(ctx)
+			claims := GetClaims(ctx)
 
 			if err := a.Authorize(ctx, claims, userID, rule); err != nil {
 				return auth.NewAuthError("authorize: you are not authorized for that action, claims[%v] rule[%v]: %s", claims.Roles, rule, err)
@@ -73,5 +72,5 @@ func AuthorizeRole(a *auth.Auth, rule string, rCore *role.Core) web.MidHandler {
 		return h
 	}
 
-	return m
+	return describeRule(m, rule)
 }
@@ -49,11 +49,12 @@ func setUser(ctx context.Context, usr user.User) context.Context {
 // AuthorizeUser executes the specified role and extracts the specified user
 // from the DB if a user id is specified in the call. Depending on the rule
 // specified, the userid from the claims may be compared with the specified
-// user id.
-func AuthorizeUser(a *auth.Auth, rule string, usrCore *user.Core) web.MidHandler {
+// user id and the caller's region with the user's region.
+func AuthorizeUser(a *auth.Auth, rule string, usrCore *user.Core) web.DocMidHandler {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 			var userID uuid.UUID
+			var regionID uuid.UUID
 
 			if id := web.Param(r, "user_id"); id != "" {
 				var err error
@@ -66,17 +67,18 @@ func AuthorizeUser(a *auth.Auth, rule string, usrCore *user.Core) web.MidHandler
 				if err != nil {
 					switch {
 					case errors.Is(err, user.ErrNotFound):
-						return v1.NewTrustedError(err, http.StatusNoContent)
+						return v1.NewTrustedError(err, http.StatusNotFound)
 					default:
 						return fmt.Errorf("querybyid: userID[%s]: %w", userID, err)
 					}
 				}
 
+				regionID = usr.RegionID
 				ctx = setUser(ctx, usr)
 			}
 
-			claims := getClaims(ctx)
-			if err := a.Authorize(ctx, claims, userID, rule); err != nil {
+			claims := GetClaims(ctx)
+			if err := a.AuthorizeRegion(ctx, claims, userID, regionID, rule); err != nil {
 				return auth.NewAuthError("authorize: you are not authorized for that action, claims[%v] rule[%v]: %s", claims.Roles, rule, err)
 			}
 
@@ -86,5 +88,5 @@ func AuthorizeUser(a *auth.Auth, rule string, usrCore *user.Core) web.MidHandler
 		return h
 	}
 
-	return m
+	return describeRule(m, rule)
 }
@@ -0,0 +1,114 @@
+package mid
+
+import (
+	"context"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type ctxClientIPKey int
+
+const clientIPKey ctxClientIPKey = 1
+
+// ClientIP resolves the address of the client for the rate limits, the
+// idempotency keys and the audit log. The address of the direct peer is used
+// unless the peer is one of the trusted proxies. Only then the
+// X-Forwarded-For entries are walked from the right, past the trusted proxies,
+// to the first address that isn't one since the entries to its left are
+// provided by the client and can't be trusted.
+func ClientIP(trusted []netip.Prefix) web.MidHandler {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			ctx = context.WithValue(ctx, clientIPKey, resolveClientIP(r, trusted))
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// ParseTrustedProxies parses the addresses of the trusted proxies. Every
+// entry is either a single address or a CIDR range.
+func ParseTrustedProxies(specs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(specs))
+
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+
+		if addr, err := netip.ParseAddr(spec); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(spec)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", spec, err)
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// clientIP returns the address of the client resolved by the ClientIP
+// middleware, or the address of the direct peer when it didn't run.
+func clientIP(ctx context.Context, r *http.Request) string {
+	if ip, ok := ctx.Value(clientIPKey).(string); ok {
+		return ip
+	}
+
+	return resolveClientIP(r, nil)
+}
+
+// resolveClientIP walks the hops of the request from the direct peer towards
+// the client while they are trusted proxies.
+func resolveClientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	peer, err := netip.ParseAddr(host)
+	if err != nil || !trustedProxy(peer, trusted) {
+		return host
+	}
+
+	client := peer.Unmap().String()
+
+	entries := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(entries) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(entries[i]))
+		if err != nil {
+			break
+		}
+
+		client = hop.Unmap().String()
+
+		if !trustedProxy(hop, trusted) {
+			break
+		}
+	}
+
+	return client
+}
+
+// trustedProxy reports whether the address belongs to a trusted proxy.
+func trustedProxy(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
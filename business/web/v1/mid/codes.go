@@ -0,0 +1,70 @@
+package mid
+
+import (
+	"errors"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/condition"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/patient"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/region"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/role"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/etag"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/idempotency"
+	"net/http"
+)
+
+// errorCode represents the code, status and title returned for an error.
+type errorCode struct {
+	err    error
+	code   string
+	status int
+	title  string
+}
+
+// errorCodes is the catalog of errors that have a stable code. A trusted
+// error matches an entry when errors.Is reports true, so wrapped core errors
+// are found as well. Any other error only matches when it's the catalog
+// error itself.
+var errorCodes = []errorCode{
+	{ErrInvalidID, v1.CodeInvalidID, http.StatusBadRequest, "Invalid ID"},
+	{user.ErrAuthenticationFailure, v1.CodeAuthenticationFailed, http.StatusUnauthorized, "Authentication failed"},
+	{user.ErrNotFound, v1.CodeUserNotFound, http.StatusNotFound, "User not found"},
+	{user.ErrUniqueEmail, v1.CodeEmailAlreadyUsed, http.StatusConflict, "Email already used"},
+	{patient.ErrNotFound, v1.CodePatientNotFound, http.StatusNotFound, "Patient not found"},
+	{condition.ErrNotFound, v1.CodeConditionNotFound, http.StatusNotFound, "Condition not found"},
+	{region.ErrNotFound, v1.CodeRegionNotFound, http.StatusNotFound, "Region not found"},
+	{role.ErrNotFound, v1.CodeRoleNotFound, http.StatusNotFound, "Role not found"},
+	{patient.ErrUserDisabled, v1.CodeUserDisabled, http.StatusBadRequest, "User disabled"},
+	{condition.ErrUserDisabled, v1.CodeUserDisabled, http.StatusBadRequest, "User disabled"},
+	{region.ErrUserDisabled, v1.CodeUserDisabled, http.StatusBadRequest, "User disabled"},
+	{role.ErrUserDisabled, v1.CodeUserDisabled, http.StatusBadRequest, "User disabled"},
+	{user.ErrVersionConflict, v1.CodeVersionConflict, http.StatusPreconditionFailed, "Version conflict"},
+	{patient.ErrVersionConflict, v1.CodeVersionConflict, http.StatusPreconditionFailed, "Version conflict"},
+	{condition.ErrVersionConflict, v1.CodeVersionConflict, http.StatusPreconditionFailed, "Version conflict"},
+	{region.ErrVersionConflict, v1.CodeVersionConflict, http.StatusPreconditionFailed, "Version conflict"},
+	{role.ErrVersionConflict, v1.CodeVersionConflict, http.StatusPreconditionFailed, "Version conflict"},
+	{etag.ErrPreconditionFailed, v1.CodeVersionConflict, http.StatusPreconditionFailed, "Version conflict"},
+	{etag.ErrPreconditionRequired, v1.CodePreconditionRequired, http.StatusPreconditionRequired, "Precondition required"},
+	{ErrRateLimited, v1.CodeRateLimited, http.StatusTooManyRequests, "Rate limit exceeded"},
+	{idempotency.ErrKeyInvalid, v1.CodeIdempotencyKeyInvalid, http.StatusBadRequest, "Invalid idempotency key"},
+	{idempotency.ErrKeyReused, v1.CodeIdempotencyKeyReused, http.StatusUnprocessableEntity, "Idempotency key reused"},
+	{idempotency.ErrInProgress, v1.CodeIdempotencyInProgress, http.StatusConflict, "Request in progress"},
+}
+
+// lookupErrorCode finds the catalog entry for the error. An internal error
+// that happens to wrap a catalog error isn't found, so it's still reported
+// as internal without its details.
+func lookupErrorCode(err error) (errorCode, bool) {
+	trsErr := v1.GetTrustedError(err)
+
+	for _, ec := range errorCodes {
+		switch {
+		case trsErr != nil && errors.Is(trsErr.Err, ec.err):
+			return ec, true
+		case err == ec.err:
+			return ec, true
+		}
+	}
+
+	return errorCode{}, false
+}
@@ -0,0 +1,49 @@
+package mid
+
+import (
+	"context"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// DebugLogHeader is the header admins set to true to log a request at debug
+// level regardless of the minimum log level.
+const DebugLogHeader = "X-Debug-Log"
+
+// DebugLog enables debug logging for the request when the header is set by
+// an admin. The header is ignored for anyone else, it doesn't fail the
+// request since authentication is left to the routes.
+func DebugLog(a *auth.Auth) web.MidHandler {
+	if a == nil {
+		return nil
+	}
+
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			if enabled, _ := strconv.ParseBool(r.Header.Get(DebugLogHeader)); enabled && isAdmin(ctx, a, r) {
+				ctx = logger.WithDebug(ctx)
+			}
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// isAdmin reports whether the request carries the token of an admin.
+func isAdmin(ctx context.Context, a *auth.Auth, r *http.Request) bool {
+	claims, err := a.Authenticate(ctx, r.Header.Get("authorization"))
+	if err != nil {
+		return false
+	}
+
+	return a.Authorize(ctx, claims, uuid.UUID{}, auth.RuleAdminOnly) == nil
+}
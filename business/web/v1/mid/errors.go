@@ -8,11 +8,13 @@ import (
 	"github.com/fadhilijuma/gateone-service/foundation/validate"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
 	"net/http"
+
+	"github.com/go-json-experiment/json"
 )
 
 // Errors handles errors coming out of the call chain. It detects normal
-// application errors which are used to respond to the client in a uniform way.
-// Unexpected errors (status >= 500) are logged.
+// application errors which are used to respond to the client in a uniform way
+// as RFC 7807 problem documents. Unexpected errors (status >= 500) are logged.
 func Errors(log *logger.Logger) web.MidHandler {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
@@ -23,42 +25,14 @@ func Errors(log *logger.Logger) web.MidHandler {
 				span.RecordError(err)
 				span.End()
 
-				var er v1.ErrorResponse
-				var status int
-
-				switch {
-				case v1.IsTrustedError(err):
-					trsErr := v1.GetTrustedError(err)
-
-					if validate.IsFieldErrors(trsErr.Err) {
-						fieldErrors := validate.GetFieldErrors(trsErr.Err)
-						er = v1.ErrorResponse{
-							Error:  "data validation error",
-							Fields: fieldErrors.Fields(),
-						}
-						status = trsErr.Status
-						break
-					}
-
-					er = v1.ErrorResponse{
-						Error: trsErr.Error(),
-					}
-					status = trsErr.Status
-
-				case auth.IsAuthError(err):
-					er = v1.ErrorResponse{
-						Error: http.StatusText(http.StatusUnauthorized),
-					}
-					status = http.StatusUnauthorized
-
-				default:
-					er = v1.ErrorResponse{
-						Error: http.StatusText(http.StatusInternalServerError),
-					}
-					status = http.StatusInternalServerError
+				prob := toProblem(ctx, r, err)
+
+				data, jsonErr := json.Marshal(prob)
+				if jsonErr != nil {
+					return jsonErr
 				}
 
-				if err := web.Respond(ctx, w, er, status); err != nil {
+				if err := web.RespondRaw(ctx, w, v1.ProblemContentType, data, prob.Status); err != nil {
 					return err
 				}
 
@@ -77,3 +51,58 @@ func Errors(log *logger.Logger) web.MidHandler {
 
 	return m
 }
+
+// toProblem constructs the problem document for the error. Field errors are
+// reported as failed validation, errors in the catalog get their stable
+// code, other trusted errors get the generic code for their status and
+// anything else is reported as an internal error without details.
+func toProblem(ctx context.Context, r *http.Request, err error) v1.Problem {
+	prob := v1.Problem{
+		Instance: r.URL.Path,
+		TraceID:  web.GetTraceID(ctx),
+	}
+
+	trsErr := v1.GetTrustedError(err)
+
+	switch ec, found := lookupErrorCode(err); {
+	case validate.IsFieldErrors(err):
+		prob.Code = v1.CodeValidationFailed
+		prob.Status = http.StatusBadRequest
+		if trsErr != nil {
+			prob.Status = trsErr.Status
+		}
+		prob.Title = "Validation failed"
+		prob.Detail = "data validation error"
+		prob.Fields = validate.GetFieldErrors(err).Fields()
+
+	case found:
+		prob.Code = ec.code
+		prob.Status = ec.status
+		prob.Title = ec.title
+		prob.Detail = ec.err.Error()
+		if trsErr != nil {
+			prob.Status = trsErr.Status
+			prob.Detail = trsErr.Error()
+		}
+
+	case trsErr != nil:
+		prob.Code = v1.StatusCode(trsErr.Status)
+		prob.Status = trsErr.Status
+		prob.Title = http.StatusText(trsErr.Status)
+		prob.Detail = trsErr.Error()
+
+	case auth.IsAuthError(err):
+		prob.Code = v1.CodeUnauthorized
+		prob.Status = http.StatusUnauthorized
+		prob.Title = http.StatusText(http.StatusUnauthorized)
+
+	default:
+		prob.Code = v1.CodeInternal
+		prob.Status = http.StatusInternalServerError
+		prob.Title = http.StatusText(http.StatusInternalServerError)
+	}
+
+	prob.Type = v1.ProblemType(prob.Code)
+
+	return prob
+}
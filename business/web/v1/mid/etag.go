@@ -0,0 +1,33 @@
+package mid
+
+import (
+	"context"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/etag"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+)
+
+// RequireIfMatch rejects requests that do not provide an If-Match header so
+// clients can't change a resource without stating which version they saw.
+// The handler compares the header with the resource. The middleware is
+// disabled when the header is not required.
+func RequireIfMatch(required bool) web.MidHandler {
+	if !required {
+		return nil
+	}
+
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			if r.Header.Get("If-Match") == "" {
+				return v1.NewTrustedError(etag.ErrPreconditionRequired, http.StatusPreconditionRequired)
+			}
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
@@ -0,0 +1,171 @@
+package mid
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/data/transaction"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/idempotency"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"io"
+	"net/http"
+	"time"
+)
+
+// replayHeaders are the response headers recorded and replayed along with
+// the body of the response.
+var replayHeaders = []string{"ETag", "Location"}
+
+// Idempotency replays the recorded response when a request is retried with
+// the same Idempotency-Key header and rejects a key reused for a different
+// request. Keys are scoped to the caller and kept for the window. A body
+// larger than maxBody is rejected since it is read into memory to be
+// fingerprinted. It must run after ExecuteInTransaction so the key is only
+// kept when the changes made by the handler commit. Requests without the
+// header are not affected.
+func Idempotency(log *logger.Logger, store idempotency.Storer, window time.Duration, maxBody int64) web.MidHandler {
+	if store == nil || window <= 0 {
+		return nil
+	}
+
+	if maxBody <= 0 {
+		maxBody = idempotency.DefaultMaxBodySize
+	}
+
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" {
+				return handler(ctx, w, r)
+			}
+
+			if len(key) > idempotency.MaxKeyLength {
+				return v1.NewTrustedError(idempotency.ErrKeyInvalid, http.StatusBadRequest)
+			}
+
+			tx, ok := transaction.Get(ctx)
+			if !ok {
+				return errors.New("idempotency: no transaction, must run after ExecuteInTransaction")
+			}
+
+			store, err := store.ExecuteUnderTransaction(tx)
+			if err != nil {
+				return fmt.Errorf("idempotency: %w", err)
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+			if err != nil {
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					return v1.NewTrustedError(idempotency.ErrBodyTooBig, http.StatusRequestEntityTooLarge)
+				}
+				return v1.NewTrustedError(fmt.Errorf("unable to read payload: %w", err), http.StatusBadRequest)
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			now := web.GetTime(ctx)
+
+			rec := idempotency.Record{
+				Scope:       idempotencyScope(ctx, r),
+				Key:         key,
+				Fingerprint: idempotency.Fingerprint(r.Method, r.URL.Path, body),
+				DateCreated: now,
+				ExpiresAt:   now.Add(window),
+			}
+
+			claimed, err := store.Claim(ctx, rec)
+			if err != nil {
+				return fmt.Errorf("idempotency: claim: %w", err)
+			}
+
+			if !claimed {
+				log.Info(ctx, "idempotency", "status", "replaying response", "key", key)
+				return replay(ctx, w, store, rec)
+			}
+
+			rw := responseRecorder{ResponseWriter: w}
+			if err := handler(ctx, &rw, r); err != nil {
+				return err
+			}
+
+			rec.StatusCode = rw.statusCode
+			rec.ContentType = rw.Header().Get("Content-Type")
+			rec.Headers = make(map[string]string)
+			for _, name := range replayHeaders {
+				if v := rw.Header().Get(name); v != "" {
+					rec.Headers[name] = v
+				}
+			}
+			rec.Body = rw.body.Bytes()
+
+			if err := store.Complete(ctx, rec); err != nil {
+				return fmt.Errorf("idempotency: complete: %w", err)
+			}
+
+			return nil
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// replay sends the response recorded for the key when the request matches
+// the one that claimed it.
+func replay(ctx context.Context, w http.ResponseWriter, store idempotency.Storer, rec idempotency.Record) error {
+	prev, err := store.QueryByKey(ctx, rec.Scope, rec.Key)
+	if err != nil {
+		return fmt.Errorf("idempotency: querybykey: %w", err)
+	}
+
+	if prev.Fingerprint != rec.Fingerprint {
+		return v1.NewTrustedError(idempotency.ErrKeyReused, http.StatusUnprocessableEntity)
+	}
+
+	if !prev.Completed() {
+		return v1.NewTrustedError(idempotency.ErrInProgress, http.StatusConflict)
+	}
+
+	for name, v := range prev.Headers {
+		w.Header().Set(name, v)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+
+	return web.RespondRaw(ctx, w, prev.ContentType, prev.Body, prev.StatusCode)
+}
+
+// idempotencyScope keeps the keys of different callers apart.
+func idempotencyScope(ctx context.Context, r *http.Request) string {
+	if claims := GetClaims(ctx); claims.Subject != "" {
+		return "user:" + claims.Subject
+	}
+
+	return "ip:" + clientIP(ctx, r)
+}
+
+// responseRecorder passes the response through to the client while keeping a
+// copy so it can be replayed.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(statusCode int) {
+	if rr.statusCode == 0 {
+		rr.statusCode = statusCode
+	}
+	rr.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.statusCode == 0 {
+		rr.statusCode = http.StatusOK
+	}
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
@@ -5,23 +5,38 @@ import (
 	"github.com/fadhilijuma/gateone-service/business/web/v1/metrics"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
 	"net/http"
+	"time"
 )
 
-// Metrics updates program counters.
+// Metrics updates program counters and records the request in the Prometheus
+// metrics. It runs outside of Errors so the status of failed requests is
+// known by the time it is recorded.
 func Metrics() web.MidHandler {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 			ctx = metrics.Set(ctx)
 
+			metrics.RequestStarted()
+			start := time.Now()
+
 			err := handler(ctx, w, r)
 
+			v := web.GetValues(ctx)
+
+			status := v.StatusCode
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			metrics.RequestCompleted(v.Route, r.Method, status, time.Since(start))
+
 			n := metrics.AddRequests(ctx)
 
 			if n%1000 == 0 {
 				metrics.AddGoroutines(ctx)
 			}
 
-			if err != nil {
+			if err != nil || status >= http.StatusBadRequest {
 				metrics.AddErrors(ctx)
 			}
 
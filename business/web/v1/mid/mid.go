@@ -8,16 +8,34 @@ import (
 
 type ctxKey int
 
-const claimKey ctxKey = 1
+const (
+	claimKey ctxKey = iota + 1
+	scopeKey
+)
 
 func setClaims(ctx context.Context, claims auth.Claims) context.Context {
 	return context.WithValue(ctx, claimKey, claims)
 }
 
-func getClaims(ctx context.Context) auth.Claims {
+// GetClaims returns the claims from the context.
+func GetClaims(ctx context.Context) auth.Claims {
 	v, ok := ctx.Value(claimKey).(auth.Claims)
 	if !ok {
 		return auth.Claims{}
 	}
 	return v
 }
+
+func setScope(ctx context.Context, scope auth.Scope) context.Context {
+	return context.WithValue(ctx, scopeKey, scope)
+}
+
+// GetScope returns the scope of a list from the context. Without one the
+// list is narrowed to the records of the caller.
+func GetScope(ctx context.Context) auth.Scope {
+	v, ok := ctx.Value(scopeKey).(auth.Scope)
+	if !ok {
+		return auth.ScopeSubject
+	}
+	return v
+}
@@ -0,0 +1,69 @@
+package mid
+
+import (
+	"context"
+	"errors"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/ratelimit"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrRateLimited is returned when a caller has used all of their requests.
+var ErrRateLimited = errors.New("rate limit exceeded, try again later")
+
+// RateLimit limits the rate of requests for the specified group of routes.
+// Authenticated requests are limited per user and must run after
+// Authenticate, anonymous requests are limited per client IP. If the store
+// fails the request is allowed so an outage of the store is not an outage of
+// the service. A nil store or a zero limit disables the middleware.
+func RateLimit(log *logger.Logger, store ratelimit.Storer, group string, limit ratelimit.Limit) web.MidHandler {
+	if store == nil || !limit.Enabled() {
+		return nil
+	}
+
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			key := rateLimitKey(ctx, group, r)
+
+			res, err := store.Take(ctx, key, limit)
+			if err != nil {
+				log.Error(ctx, "ratelimit", "status", "store failed, allowing request", "key", key, "msg", err)
+				return handler(ctx, w, r)
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", headerSeconds(res.Reset))
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", headerSeconds(res.RetryAfter))
+				return v1.NewTrustedError(ErrRateLimited, http.StatusTooManyRequests)
+			}
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// rateLimitKey identifies the bucket for the request.
+func rateLimitKey(ctx context.Context, group string, r *http.Request) string {
+	if claims := GetClaims(ctx); claims.Subject != "" {
+		return group + ":user:" + claims.Subject
+	}
+
+	return group + ":ip:" + clientIP(ctx, r)
+}
+
+// headerSeconds formats a duration as the whole number of seconds used by the
+// rate limit headers.
+func headerSeconds(d time.Duration) string {
+	return strconv.Itoa(int(d / time.Second))
+}
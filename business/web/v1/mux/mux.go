@@ -3,14 +3,22 @@
 package mux
 
 import (
+	"fmt"
 	"github.com/fadhilijuma/gateone-service/business/core/crud/delegate"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/user/stores/usercache"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/auth"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/health"
 	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/openapi"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/ratelimit"
 	"github.com/fadhilijuma/gateone-service/foundation/logger"
 	"github.com/fadhilijuma/gateone-service/foundation/web"
 	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
+	"net/netip"
 	"os"
+	"time"
 )
 
 // Options represent optional parameters.
@@ -27,12 +35,22 @@ func WithCORS(origins []string) func(opts *Options) {
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Build    string
-	Shutdown chan os.Signal
-	Log      *logger.Logger
-	Delegate *delegate.Delegate
-	Auth     *auth.Auth
-	DB       *sqlx.DB
+	Build              string
+	Shutdown           chan os.Signal
+	Log                *logger.Logger
+	Tracer             trace.Tracer
+	Delegate           *delegate.Delegate
+	Auth               *auth.Auth
+	DB                 *sqlx.DB
+	UserCache          *usercache.Cache
+	RateLimiter        ratelimit.Storer
+	RateLimits         map[string]ratelimit.Limit
+	IdempotencyWindow  time.Duration
+	IdempotencyMaxBody int64
+	RequireIfMatch     bool
+	Readiness          *health.Readiness
+	PublicURL          string
+	TrustedProxies     []netip.Prefix
 }
 
 // RouteAdder defines behavior that sets the routes to bind for an instance
@@ -41,8 +59,9 @@ type RouteAdder interface {
 	Add(app *web.App, cfg Config)
 }
 
-// WebAPI constructs a http.Handler with all application routes bound.
-func WebAPI(cfg Config, routeAdder RouteAdder, options ...func(opts *Options)) http.Handler {
+// WebAPI constructs a http.Handler with all application routes bound. The
+// OpenAPI document describing the routes is served at /v1/openapi.json.
+func WebAPI(cfg Config, routeAdder RouteAdder, options ...func(opts *Options)) (http.Handler, error) {
 	var opts Options
 	for _, option := range options {
 		option(&opts)
@@ -50,9 +69,12 @@ func WebAPI(cfg Config, routeAdder RouteAdder, options ...func(opts *Options)) h
 
 	app := web.NewApp(
 		cfg.Shutdown,
+		cfg.Tracer,
+		mid.ClientIP(cfg.TrustedProxies),
+		mid.DebugLog(cfg.Auth),
 		mid.Logger(cfg.Log),
-		mid.Errors(cfg.Log),
 		mid.Metrics(),
+		mid.Errors(cfg.Log),
 		mid.Panics(),
 	)
 
@@ -62,5 +84,17 @@ func WebAPI(cfg Config, routeAdder RouteAdder, options ...func(opts *Options)) h
 
 	routeAdder.Add(app, cfg)
 
-	return app
+	doc, err := openapi.New(openapi.Info{Title: "gateone-api", Version: cfg.Build}, app.Routes())
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+
+	docHandler, err := openapi.Handler(doc)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+
+	app.Handle(http.MethodGet, "v1", "/openapi.json", docHandler)
+
+	return app, nil
 }
@@ -0,0 +1,246 @@
+// Package openapi generates an OpenAPI 3.1 document describing the routes
+// bound to an application from the routes and their model types.
+package openapi
+
+import (
+	"context"
+	"fmt"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// Version is the version of the OpenAPI specification documents follow.
+const Version = "3.1.0"
+
+// Set of names for the security schemes routes can require.
+const (
+	bearerAuth = "bearerAuth"
+	basicAuth  = "basicAuth"
+)
+
+// Document represents an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info represents the metadata about the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem represents the operations available on a path keyed by the
+// lower case method.
+type PathItem map[string]Operation
+
+// Operation represents a single API operation on a path.
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	AuthRule    string                `json:"x-auth-rule,omitempty"`
+}
+
+// Parameter represents a path or query parameter of an operation.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitzero"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody represents the body of a request.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response represents a response of an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType represents the schema of a body for a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas and security schemes referenced by the
+// operations.
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme represents a way to authenticate with the API.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// New constructs the document describing the routes. Every route is
+// expected to be described, routes without a summary are reported as an
+// error so they are not left out of the document unnoticed.
+func New(info Info, routes []web.Route) (Document, error) {
+	g := newGenerator("#/components/schemas/")
+
+	doc := Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+	}
+
+	securitySchemes := make(map[string]SecurityScheme)
+
+	for _, rt := range routes {
+		if rt.Doc.Summary == "" {
+			return Document{}, fmt.Errorf("route %s %s is not described", rt.Method, rt.Path)
+		}
+
+		op, err := g.operation(rt)
+		if err != nil {
+			return Document{}, fmt.Errorf("route %s %s: %w", rt.Method, rt.Path, err)
+		}
+
+		switch rt.Doc.Security {
+		case web.SecurityBearer:
+			op.Security = []map[string][]string{{bearerAuth: {}}}
+			securitySchemes[bearerAuth] = SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}
+
+		case web.SecurityBasic:
+			op.Security = []map[string][]string{{basicAuth: {}}}
+			securitySchemes[basicAuth] = SecurityScheme{Type: "http", Scheme: "basic"}
+		}
+
+		item, exists := doc.Paths[rt.Path]
+		if !exists {
+			item = make(PathItem)
+			doc.Paths[rt.Path] = item
+		}
+		item[strings.ToLower(rt.Method)] = op
+	}
+
+	doc.Components = Components{
+		Schemas:         g.schemas,
+		SecuritySchemes: securitySchemes,
+	}
+
+	return doc, nil
+}
+
+// Marshal returns the JSON form of the document. Keys are sorted so the
+// same routes always produce the same bytes.
+func (doc Document) Marshal() ([]byte, error) {
+	return json.Marshal(doc, json.Deterministic(true), jsontext.WithIndent("  "))
+}
+
+// Handler returns a handler responding with the document.
+func Handler(doc Document) (web.Handler, error) {
+	data, err := doc.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return web.RespondRaw(ctx, w, "application/json", data, http.StatusOK)
+	}
+
+	return h, nil
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// operation constructs the operation for the route.
+func (g *generator) operation(rt web.Route) (Operation, error) {
+	op := Operation{
+		OperationID: operationID(rt),
+		Summary:     rt.Doc.Summary,
+		Responses:   make(map[string]Response),
+		AuthRule:    rt.Doc.Rule,
+	}
+
+	for _, m := range pathParam.FindAllStringSubmatch(rt.Path, -1) {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     strings.TrimSuffix(m[1], "..."),
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	for _, name := range rt.Doc.Query {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:   name,
+			In:     "query",
+			Schema: &Schema{Type: "string"},
+		})
+	}
+
+	if rt.Doc.Request != nil {
+		schema, err := g.schemaOf(rt.Doc.Request)
+		if err != nil {
+			return Operation{}, fmt.Errorf("request: %w", err)
+		}
+
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: schema}},
+		}
+	}
+
+	status := rt.Doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	success := Response{
+		Description: http.StatusText(status),
+	}
+
+	if rt.Doc.Response != nil {
+		schema, err := g.schemaOf(rt.Doc.Response)
+		if err != nil {
+			return Operation{}, fmt.Errorf("response: %w", err)
+		}
+
+		success.Content = map[string]MediaType{"application/json": {Schema: schema}}
+	}
+
+	op.Responses[strconv.Itoa(status)] = success
+
+	problem, err := g.schemaOf(v1.Problem{})
+	if err != nil {
+		return Operation{}, fmt.Errorf("problem: %w", err)
+	}
+
+	op.Responses["default"] = Response{
+		Description: "Error",
+		Content:     map[string]MediaType{v1.ProblemContentType: {Schema: problem}},
+	}
+
+	return op, nil
+}
+
+var nonWord = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// operationID derives a unique id for the operation from its method and
+// path, for example get_v1_patients_patient_id.
+func operationID(rt web.Route) string {
+	id := nonWord.ReplaceAllString(strings.ToLower(rt.Method)+"_"+rt.Path, "_")
+	return strings.Trim(id, "_")
+}
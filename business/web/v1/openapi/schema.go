@@ -0,0 +1,218 @@
+package openapi
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Schema represents the JSON schema of a value.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// generator constructs schemas from Go types. Named struct types become
+// component schemas that are referenced by refPrefix and their name wherever
+// the type is used.
+type generator struct {
+	refPrefix string
+	schemas   map[string]*Schema
+	names     map[string]reflect.Type
+}
+
+func newGenerator(refPrefix string) *generator {
+	return &generator{
+		refPrefix: refPrefix,
+		schemas:   make(map[string]*Schema),
+		names:     make(map[string]reflect.Type),
+	}
+}
+
+// JSONSchema returns the standalone JSON schema of the type of the value.
+// The schemas of the named struct types it uses are held in $defs.
+func JSONSchema(v any) (*Schema, error) {
+	g := newGenerator("#/$defs/")
+
+	schema, err := g.schemaOf(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(g.schemas) > 0 {
+		schema.Defs = g.schemas
+	}
+
+	return schema, nil
+}
+
+// schemaOf returns the schema for the type of the value.
+func (g *generator) schemaOf(v any) (*Schema, error) {
+	return g.schema(reflect.TypeOf(v))
+}
+
+// schema returns the schema of a type the way the json package marshals it.
+func (g *generator) schema(t reflect.Type) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}, nil
+
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return &Schema{Type: "array", Items: items}, nil
+
+	case reflect.Map:
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+
+	case reflect.Interface:
+		return &Schema{}, nil
+
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+
+		return g.component(t)
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// component returns a reference to the component schema of a named struct
+// type, adding the component the first time the type is seen.
+func (g *generator) component(t reflect.Type) (*Schema, error) {
+	name := schemaName(t)
+	ref := &Schema{Ref: g.refPrefix + name}
+
+	if seen, exists := g.names[name]; exists {
+		if seen != t {
+			return nil, fmt.Errorf("types %s and %s have the same schema name %s", seen, t, name)
+		}
+		return ref, nil
+	}
+
+	// Record the type before generating the schema so recursive types
+	// reference the component instead of recursing forever.
+	g.names[name] = t
+
+	schema, err := g.object(t)
+	if err != nil {
+		return nil, err
+	}
+	g.schemas[name] = schema
+
+	return ref, nil
+}
+
+// object returns the schema of the fields of a struct type. Fields with a
+// required validation are listed as required.
+func (g *generator) object(t reflect.Type) (*Schema, error) {
+	schema := Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		fld := t.Field(i)
+		if !fld.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(fld.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		if strings.Contains(","+opts+",", ",inline,") {
+			inline, err := g.object(fld.Type)
+			if err != nil {
+				return nil, err
+			}
+
+			for k, v := range inline.Properties {
+				schema.Properties[k] = v
+			}
+			schema.Required = append(schema.Required, inline.Required...)
+			continue
+		}
+
+		if name == "" {
+			name = fld.Name
+		}
+
+		prop, err := g.schema(fld.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", fld.Name, err)
+		}
+		schema.Properties[name] = prop
+
+		for _, rule := range strings.Split(fld.Tag.Get("validate"), ",") {
+			if rule == "required" {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+
+	return &schema, nil
+}
+
+var packagePath = regexp.MustCompile(`[\w.\-]+/`)
+
+// schemaName returns the component name of a type, which is the type
+// qualified by its package name, for example patientgrp.AppPatient. Type
+// arguments of generic types are joined by underscores as in
+// v1.PageDocument_patientgrp.AppPatient.
+func schemaName(t reflect.Type) string {
+	name := packagePath.ReplaceAllString(t.String(), "")
+
+	return strings.NewReplacer("[", "_", "]", "", ",", "_", " ", "").Replace(name)
+}
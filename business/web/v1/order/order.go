@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/fadhilijuma/gateone-service/foundation/validate"
 	"net/http"
+	"net/url"
 	"strings"
 )
 
@@ -20,54 +21,135 @@ var directions = map[string]string{
 	DESC: "DESC",
 }
 
-// By represents a field used to order by and direction.
-type By struct {
-	Field     string
+// Field represents a field used to order by and direction.
+type Field struct {
+	Name      string
 	Direction string
 }
 
-// NewBy constructs a new By value with no checks.
+// By represents the ordered list of fields used to order by. Rows are
+// ordered by the first field, then by the next field for rows that are equal
+// in the first and so on.
+type By struct {
+	Fields []Field
+}
+
+// NewBy constructs a new By value ordering by a single field with no checks.
 func NewBy(field string, direction string) By {
+	return By{}.Then(field, direction)
+}
+
+// Then returns a copy of the By value that also orders by the specified
+// field, for rows that are equal in the fields before it.
+func (b By) Then(field string, direction string) By {
 	if _, exists := directions[direction]; !exists {
-		return By{
-			Field:     field,
-			Direction: ASC,
-		}
+		direction = ASC
 	}
 
+	fields := make([]Field, len(b.Fields), len(b.Fields)+1)
+	copy(fields, b.Fields)
+
 	return By{
-		Field:     field,
-		Direction: direction,
+		Fields: append(fields, Field{Name: field, Direction: direction}),
+	}
+}
+
+// Map returns a copy of the By value with the field names replaced by the
+// names they map to. It fails for fields missing from the mapping, which
+// makes the mapping a whitelist of the fields that can be ordered by.
+func (b By) Map(names map[string]string) (By, error) {
+	fields := make([]Field, len(b.Fields))
+	for i, fld := range b.Fields {
+		name, exists := names[fld.Name]
+		if !exists {
+			return By{}, validate.NewFieldsError(fld.Name, errors.New("order field does not exist"))
+		}
+
+		fields[i] = Field{Name: name, Direction: fld.Direction}
 	}
+
+	return By{Fields: fields}, nil
+}
+
+// String returns the order in the form of "field,direction;field,direction".
+func (b By) String() string {
+	fields := make([]string, len(b.Fields))
+	for i, fld := range b.Fields {
+		fields[i] = fld.Name + "," + fld.Direction
+	}
+
+	return strings.Join(fields, ";")
 }
 
-// Parse constructs a By value by parsing a string in the form
-// of "field,direction".
+// Param is the query string parameter Parse reads.
+const Param = "orderBy"
+
+// Parse constructs a By value by parsing a string in the form of
+// "field,direction" with any number of fields separated by a semicolon, for
+// example "condition,ASC;name,DESC". The direction is optional and defaults
+// to ascending.
 func Parse(r *http.Request, defaultOrder By) (By, error) {
-	v := r.URL.Query().Get("orderBy")
+	v, err := queryValue(r, Param)
+	if err != nil {
+		return By{}, validate.NewFieldsError(Param, err)
+	}
 
 	if v == "" {
 		return defaultOrder, nil
 	}
 
-	orderParts := strings.Split(v, ",")
-
 	var by By
-	switch len(orderParts) {
-	case 1:
-		by = NewBy(strings.TrimSpace(orderParts[0]), ASC)
-
-	case 2:
-		direction := strings.Trim(orderParts[1], " ")
-		if _, exists := directions[direction]; !exists {
-			return By{}, validate.NewFieldsError(v, fmt.Errorf("unknown direction: %s", by.Direction))
+	seen := make(map[string]bool)
+
+	for _, key := range strings.Split(v, ";") {
+		orderParts := strings.Split(key, ",")
+
+		var field, direction string
+		switch len(orderParts) {
+		case 1:
+			field = strings.TrimSpace(orderParts[0])
+			direction = ASC
+
+		case 2:
+			field = strings.TrimSpace(orderParts[0])
+			direction = strings.TrimSpace(orderParts[1])
+			if _, exists := directions[direction]; !exists {
+				return By{}, validate.NewFieldsError(v, fmt.Errorf("unknown direction: %s", direction))
+			}
+
+		default:
+			return By{}, validate.NewFieldsError(v, errors.New("unknown order field"))
 		}
 
-		by = NewBy(strings.Trim(orderParts[0], " "), direction)
+		if field == "" {
+			return By{}, validate.NewFieldsError(v, errors.New("missing order field"))
+		}
 
-	default:
-		return By{}, validate.NewFieldsError(v, errors.New("unknown order field"))
+		if seen[field] {
+			return By{}, validate.NewFieldsError(v, fmt.Errorf("duplicate order field: %s", field))
+		}
+		seen[field] = true
+
+		by = by.Then(field, direction)
 	}
 
 	return by, nil
 }
+
+// queryValue returns the first value of the key in the query string. The
+// query is split on ampersands only since url.Query drops the pairs holding
+// the semicolons that separate the order fields.
+func queryValue(r *http.Request, key string) (string, error) {
+	for _, pair := range strings.Split(r.URL.RawQuery, "&") {
+		k, v, _ := strings.Cut(pair, "=")
+
+		k, err := url.QueryUnescape(k)
+		if err != nil || k != key {
+			continue
+		}
+
+		return url.QueryUnescape(v)
+	}
+
+	return "", nil
+}
@@ -0,0 +1,85 @@
+package order_test
+
+import (
+	"github.com/fadhilijuma/gateone-service/business/web/v1/order"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func Test_Parse(t *testing.T) {
+	defaultOrder := order.NewBy("patient_id", order.ASC)
+
+	tests := []struct {
+		name    string
+		orderBy string
+		exp     string
+		raw     bool
+		fail    bool
+	}{
+		{name: "default", orderBy: "", exp: "patient_id,ASC"},
+		{name: "single", orderBy: "name,DESC", exp: "name,DESC"},
+		{name: "direction", orderBy: "name", exp: "name,ASC"},
+		{name: "multiple", orderBy: "condition,ASC;name,DESC", exp: "condition,ASC;name,DESC"},
+		{name: "unescaped", orderBy: "condition,ASC;name,DESC", exp: "condition,ASC;name,DESC", raw: true},
+		{name: "spaces", orderBy: " condition , ASC ; name ", exp: "condition,ASC;name,ASC"},
+		{name: "unknown-direction", orderBy: "name,UP", fail: true},
+		{name: "duplicate", orderBy: "name,ASC;name,DESC", fail: true},
+		{name: "empty-field", orderBy: "name,ASC;", fail: true},
+		{name: "malformed", orderBy: "name,ASC,DESC", fail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.QueryEscape(tt.orderBy)
+			if tt.raw {
+				query = tt.orderBy
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/patients?rows=2&orderBy="+query, nil)
+
+			orderBy, err := order.Parse(r, defaultOrder)
+			if tt.fail {
+				if err == nil {
+					t.Fatalf("Should not be able to parse %q: got %s", tt.orderBy, orderBy)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Should be able to parse %q: %s", tt.orderBy, err)
+			}
+
+			if orderBy.String() != tt.exp {
+				t.Errorf("Should get %s: got %s", tt.exp, orderBy)
+			}
+		})
+	}
+}
+
+func Test_Map(t *testing.T) {
+	fields := map[string]string{
+		"condition": "condition",
+		"name":      "patient_name",
+	}
+
+	orderBy := order.NewBy("condition", order.ASC).Then("name", order.DESC)
+
+	mapped, err := orderBy.Map(fields)
+	if err != nil {
+		t.Fatalf("Should be able to map the order: %s", err)
+	}
+
+	if exp := "condition,ASC;patient_name,DESC"; mapped.String() != exp {
+		t.Errorf("Should get %s: got %s", exp, mapped)
+	}
+
+	if orderBy.String() != "condition,ASC;name,DESC" {
+		t.Errorf("Should not change the original order: got %s", orderBy)
+	}
+
+	if _, err := order.NewBy("age", order.ASC).Map(fields); err == nil {
+		t.Error("Should not be able to map a field missing from the whitelist.")
+	}
+}
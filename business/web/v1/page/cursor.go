@@ -0,0 +1,111 @@
+package page
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/go-json-experiment/json"
+)
+
+// ErrCursorOrder is returned when a cursor is used with a different order
+// than the one it was issued for.
+var ErrCursorOrder = errors.New("cursor does not match the order")
+
+// Cursor represents a position in an ordered list of rows. Values holds the
+// values of the order fields of the row the position is taken from, followed
+// by the row's ID, and is empty for the start of the list. A Backward cursor
+// continues with the rows before that row. Clients only see the encoded form
+// and must treat it as opaque.
+type Cursor struct {
+	OrderBy  string   `json:"o,omitempty"`
+	Values   []string `json:"v,omitempty"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// DecodeCursor parses an encoded cursor. An empty string is the cursor for
+// the start of the list.
+func DecodeCursor(s string) (Cursor, error) {
+	if s == "" {
+		return Cursor{}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+
+	var cur Cursor
+	if err := json.Unmarshal(data, &cur); err != nil {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+
+	return cur, nil
+}
+
+// Encode returns the opaque form of the cursor handed to clients.
+func (c Cursor) Encode() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Check validates the cursor was issued for the specified order, which is
+// the string form of the order used for the query. A cursor for the start of
+// the list matches any order.
+func (c Cursor) Check(orderBy string) error {
+	if c.OrderBy != "" && c.OrderBy != orderBy {
+		return fmt.Errorf("%w: cursor[%s] order[%s]", ErrCursorOrder, c.OrderBy, orderBy)
+	}
+
+	return nil
+}
+
+// Window trims the rows queried for a cursor page and returns the encoded
+// cursors of the next and previous pages, which are empty when there are no
+// more rows that way. The rows must be queried with one more row than the
+// rows per page, so Window can tell if there are more, and be in list order.
+// The values function returns the cursor values of a row.
+func Window[T any](p Page, orderBy string, rows []T, values func(T) []string) (items []T, next string, prev string) {
+	cur := Cursor{OrderBy: orderBy}
+	if p.Cursor != nil {
+		cur = *p.Cursor
+		cur.OrderBy = orderBy
+	}
+
+	more := len(rows) > p.RowsPerPage
+	if more {
+		switch cur.Backward {
+		case true:
+			rows = rows[len(rows)-p.RowsPerPage:]
+		default:
+			rows = rows[:p.RowsPerPage]
+		}
+	}
+
+	// Coming from a position means there are rows on the side the cursor
+	// came from, even if the page itself is empty.
+	fromPosition := len(cur.Values) > 0
+	hasNext := more && !cur.Backward || fromPosition && cur.Backward
+	hasPrev := more && cur.Backward || fromPosition && !cur.Backward
+
+	nextValues := cur.Values
+	prevValues := cur.Values
+	if len(rows) > 0 {
+		nextValues = values(rows[len(rows)-1])
+		prevValues = values(rows[0])
+	}
+
+	if hasNext {
+		next = Cursor{OrderBy: orderBy, Values: nextValues}.Encode()
+	}
+
+	if hasPrev {
+		prev = Cursor{OrderBy: orderBy, Values: prevValues, Backward: true}.Encode()
+	}
+
+	return rows, next, prev
+}
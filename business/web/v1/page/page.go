@@ -2,37 +2,58 @@
 package page
 
 import (
+	"errors"
 	"github.com/fadhilijuma/gateone-service/foundation/validate"
 	"net/http"
 	"strconv"
 )
 
-// Page represents the requested page and rows per page.
+// Set of query string parameters read by Parse.
+const (
+	queryPage   = "page"
+	queryRows   = "rows"
+	queryCursor = "cursor"
+)
+
+// Params returns the query string parameters Parse reads. The cursor is only
+// included for routes that support cursor paging.
+func Params(cursor bool) []string {
+	if cursor {
+		return []string{queryPage, queryRows, queryCursor}
+	}
+
+	return []string{queryPage, queryRows}
+}
+
+// Page represents the requested page and rows per page. When Cursor is set
+// the rows are paged by cursor instead of by page number.
 type Page struct {
 	Number      int
 	RowsPerPage int
+	Cursor      *Cursor
 }
 
 // Parse parses the request for the page and rows query string. The
-// defaults are provided as well.
+// defaults are provided as well. Providing the cursor query string, even
+// empty to start from the first row, selects cursor paging.
 func Parse(r *http.Request) (Page, error) {
 	values := r.URL.Query()
 
 	number := 1
-	if page := values.Get("page"); page != "" {
+	if page := values.Get(queryPage); page != "" {
 		var err error
 		number, err = strconv.Atoi(page)
 		if err != nil {
-			return Page{}, validate.NewFieldsError("page", err)
+			return Page{}, validate.NewFieldsError(queryPage, err)
 		}
 	}
 
 	rowsPerPage := 10
-	if rows := values.Get("rows"); rows != "" {
+	if rows := values.Get(queryRows); rows != "" {
 		var err error
 		rowsPerPage, err = strconv.Atoi(rows)
 		if err != nil {
-			return Page{}, validate.NewFieldsError("rows", err)
+			return Page{}, validate.NewFieldsError(queryRows, err)
 		}
 	}
 
@@ -41,5 +62,22 @@ func Parse(r *http.Request) (Page, error) {
 		RowsPerPage: rowsPerPage,
 	}
 
+	if values.Has(queryCursor) {
+		if values.Has(queryPage) {
+			return Page{}, validate.NewFieldsError(queryCursor, errors.New("cannot be used with page"))
+		}
+
+		if rowsPerPage < 1 {
+			return Page{}, validate.NewFieldsError(queryRows, errors.New("must be at least 1"))
+		}
+
+		cur, err := DecodeCursor(values.Get(queryCursor))
+		if err != nil {
+			return Page{}, validate.NewFieldsError(queryCursor, err)
+		}
+
+		p.Cursor = &cur
+	}
+
 	return p, nil
 }
@@ -0,0 +1,144 @@
+package page_test
+
+import (
+	"errors"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/page"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func Test_Parse(t *testing.T) {
+	cur := page.Cursor{OrderBy: "name,ASC", Values: []string{"Bill", "1"}}.Encode()
+
+	tests := []struct {
+		name   string
+		query  string
+		cursor *page.Cursor
+		fail   bool
+	}{
+		{name: "offset", query: "page=2&rows=5"},
+		{name: "start", query: "cursor=&rows=5", cursor: &page.Cursor{}},
+		{name: "cursor", query: "cursor=" + cur, cursor: &page.Cursor{OrderBy: "name,ASC", Values: []string{"Bill", "1"}}},
+		{name: "invalid", query: "cursor=!!", fail: true},
+		{name: "both", query: "cursor=&page=2", fail: true},
+		{name: "rows", query: "cursor=&rows=0", fail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/patients?"+tt.query, nil)
+
+			p, err := page.Parse(r)
+			if tt.fail {
+				if err == nil {
+					t.Fatal("Should not be able to parse the page.")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Should be able to parse the page: %s", err)
+			}
+
+			switch {
+			case tt.cursor == nil:
+				if p.Cursor != nil {
+					t.Errorf("Should use offset paging: got %+v", p.Cursor)
+				}
+			case p.Cursor == nil:
+				t.Error("Should use cursor paging.")
+			case p.Cursor.OrderBy != tt.cursor.OrderBy || !slices.Equal(p.Cursor.Values, tt.cursor.Values):
+				t.Errorf("Should get cursor %+v: got %+v", tt.cursor, p.Cursor)
+			}
+		})
+	}
+}
+
+func Test_Check(t *testing.T) {
+	cur := page.Cursor{OrderBy: "name,ASC", Values: []string{"Bill", "1"}}
+
+	if err := cur.Check("name,ASC"); err != nil {
+		t.Errorf("Should accept the order the cursor was issued for: %s", err)
+	}
+
+	if err := cur.Check("name,DESC"); !errors.Is(err, page.ErrCursorOrder) {
+		t.Errorf("Should reject a different order: got %v", err)
+	}
+
+	if err := (page.Cursor{}).Check("name,DESC"); err != nil {
+		t.Errorf("Should accept any order at the start: %s", err)
+	}
+}
+
+func Test_Window(t *testing.T) {
+	const orderBy = "id,ASC"
+	values := func(row int) []string { return []string{strconv.Itoa(row)} }
+
+	// rows returns the rows a query for the cursor would return from a list
+	// of the rows 1 to 5, with one more row than the page holds.
+	rows := func(cur page.Cursor, rowsPerPage int) []int {
+		all := []int{1, 2, 3, 4, 5}
+
+		var pos int
+		if len(cur.Values) > 0 {
+			pos, _ = strconv.Atoi(cur.Values[0])
+		}
+
+		var res []int
+		switch cur.Backward {
+		case true:
+			for i := len(all) - 1; i >= 0 && len(res) <= rowsPerPage; i-- {
+				if all[i] < pos {
+					res = append([]int{all[i]}, res...)
+				}
+			}
+		default:
+			for _, row := range all {
+				if row > pos && len(res) <= rowsPerPage {
+					res = append(res, row)
+				}
+			}
+		}
+
+		return res
+	}
+
+	window := func(encoded string) ([]int, string, string) {
+		cur, err := page.DecodeCursor(encoded)
+		if err != nil {
+			t.Fatalf("Should be able to decode the cursor: %s", err)
+		}
+
+		p := page.Page{RowsPerPage: 2, Cursor: &cur}
+
+		return page.Window(p, orderBy, rows(cur, p.RowsPerPage+1), values)
+	}
+
+	items, next, prev := window("")
+	if !slices.Equal(items, []int{1, 2}) || next == "" || prev != "" {
+		t.Fatalf("Should get the first page: got %v next[%s] prev[%s]", items, next, prev)
+	}
+
+	items, next, prev = window(next)
+	if !slices.Equal(items, []int{3, 4}) || next == "" || prev == "" {
+		t.Fatalf("Should get the second page: got %v next[%s] prev[%s]", items, next, prev)
+	}
+
+	items, next, prev = window(next)
+	if !slices.Equal(items, []int{5}) || next != "" || prev == "" {
+		t.Fatalf("Should get the last page: got %v next[%s] prev[%s]", items, next, prev)
+	}
+
+	items, next, prev = window(prev)
+	if !slices.Equal(items, []int{3, 4}) || next == "" || prev == "" {
+		t.Fatalf("Should get the second page going back: got %v next[%s] prev[%s]", items, next, prev)
+	}
+
+	items, next, prev = window(prev)
+	if !slices.Equal(items, []int{1, 2}) || next == "" || prev != "" {
+		t.Fatalf("Should get the first page going back: got %v next[%s] prev[%s]", items, next, prev)
+	}
+}
@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType is the media type of a problem document.
+const ProblemContentType = "application/problem+json"
+
+// Set of stable error codes returned in problem documents. Clients branch on
+// these values so they must never change once released. Errors without a
+// specific code use the code for their status, see StatusCode.
+const (
+	CodeInternal              = "internal_server_error"
+	CodeValidationFailed      = "validation_failed"
+	CodeInvalidID             = "invalid_id"
+	CodeUnauthorized          = "unauthorized"
+	CodeAuthenticationFailed  = "authentication_failed"
+	CodeUserNotFound          = "user_not_found"
+	CodeUserDisabled          = "user_disabled"
+	CodeEmailAlreadyUsed      = "email_already_used"
+	CodePatientNotFound       = "patient_not_found"
+	CodeConditionNotFound     = "condition_not_found"
+	CodeRegionNotFound        = "region_not_found"
+	CodeRoleNotFound          = "role_not_found"
+	CodeVersionConflict       = "version_conflict"
+	CodePreconditionRequired  = "precondition_required"
+	CodeRateLimited           = "rate_limited"
+	CodeIdempotencyKeyInvalid = "idempotency_key_invalid"
+	CodeIdempotencyKeyReused  = "idempotency_key_reused"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+)
+
+// Problem is the form used for API responses from failures in the API. It
+// follows RFC 7807 with the code and trace id added as extension members.
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Code     string            `json:"code"`
+	TraceID  string            `json:"traceId"`
+	Fields   map[string]string `json:"fields,omitempty"`
+}
+
+// ProblemType returns the URI that identifies the type of problem for a code.
+func ProblemType(code string) string {
+	return "urn:gateone:problem:" + code
+}
+
+// StatusCode returns the generic error code for an HTTP status, such as
+// not_found for 404.
+func StatusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return CodeInternal
+	}
+
+	text = strings.ReplaceAll(text, "-", " ")
+	text = strings.ReplaceAll(text, "'", "")
+
+	return strings.ToLower(strings.ReplaceAll(text, " ", "_"))
+}
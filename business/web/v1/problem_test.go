@@ -0,0 +1,129 @@
+package v1_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/patient"
+	"github.com/fadhilijuma/gateone-service/business/core/crud/user"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/validate"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-json-experiment/json"
+)
+
+func Test_Problem(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelError, "TEST", func(context.Context) string { return "" })
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		detail string
+	}{
+		{
+			name:   "catalog",
+			err:    patient.ErrNotFound,
+			status: http.StatusNotFound,
+			code:   v1.CodePatientNotFound,
+			detail: patient.ErrNotFound.Error(),
+		},
+		{
+			name:   "trusted-wrapped-catalog",
+			err:    v1.NewTrustedError(fmt.Errorf("querybyid: %w", patient.ErrNotFound), http.StatusNotFound),
+			status: http.StatusNotFound,
+			code:   v1.CodePatientNotFound,
+			detail: "querybyid: " + patient.ErrNotFound.Error(),
+		},
+		{
+			name:   "wrapped-catalog",
+			err:    fmt.Errorf("create: %w", patient.ErrUserDisabled),
+			status: http.StatusInternalServerError,
+			code:   v1.CodeInternal,
+			detail: "",
+		},
+		{
+			name:   "trusted-catalog",
+			err:    v1.NewTrustedError(user.ErrUniqueEmail, http.StatusConflict),
+			status: http.StatusConflict,
+			code:   v1.CodeEmailAlreadyUsed,
+			detail: user.ErrUniqueEmail.Error(),
+		},
+		{
+			name:   "validation",
+			err:    v1.NewTrustedError(validate.NewFieldsError("name", errors.New("required")), http.StatusBadRequest),
+			status: http.StatusBadRequest,
+			code:   v1.CodeValidationFailed,
+			detail: "data validation error",
+		},
+		{
+			name:   "fields",
+			err:    validate.NewFieldsError("cursor", errors.New("invalid cursor")),
+			status: http.StatusBadRequest,
+			code:   v1.CodeValidationFailed,
+			detail: "data validation error",
+		},
+		{
+			name:   "trusted",
+			err:    v1.NewTrustedError(errors.New("bad page"), http.StatusBadRequest),
+			status: http.StatusBadRequest,
+			code:   "bad_request",
+			detail: "bad page",
+		},
+		{
+			name:   "internal",
+			err:    errors.New("connection refused"),
+			status: http.StatusInternalServerError,
+			code:   v1.CodeInternal,
+			detail: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handler web.Handler = func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				return tt.err
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/patients/1", nil)
+			w := httptest.NewRecorder()
+
+			if err := mid.Errors(log)(handler)(context.Background(), w, r); err != nil {
+				t.Fatalf("Should be able to handle the error: %s", err)
+			}
+
+			if ct := w.Header().Get("Content-Type"); ct != v1.ProblemContentType {
+				t.Errorf("Should respond with a problem document: got %s", ct)
+			}
+
+			var prob v1.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &prob); err != nil {
+				t.Fatalf("Should be able to unmarshal the problem: %s", err)
+			}
+
+			if w.Code != tt.status || prob.Status != tt.status {
+				t.Errorf("Should get status %d: got %d and %d", tt.status, w.Code, prob.Status)
+			}
+
+			if prob.Code != tt.code || prob.Type != v1.ProblemType(tt.code) {
+				t.Errorf("Should get code %s: got %s %s", tt.code, prob.Code, prob.Type)
+			}
+
+			if prob.Detail != tt.detail {
+				t.Errorf("Should get detail %q: got %q", tt.detail, prob.Detail)
+			}
+
+			if prob.Instance != "/v1/patients/1" || prob.TraceID == "" {
+				t.Errorf("Should identify the request: got %q %q", prob.Instance, prob.TraceID)
+			}
+		})
+	}
+}
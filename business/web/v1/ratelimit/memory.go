@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// bucket represents the state of a single token bucket and the limit it is
+// refilled with.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// MemoryStore keeps token buckets in the memory of the process. Limits are
+// only enforced per instance of the service. Buckets that have refilled are
+// removed periodically to bound memory use.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryStore constructs a memory store for use.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]bucket),
+		now:     time.Now,
+	}
+}
+
+// Take implements the Storer interface.
+func (ms *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := ms.now()
+	ms.sweep(now)
+
+	tokens := float64(limit.Burst)
+	if b, exists := ms.buckets[key]; exists {
+		tokens = Refill(limit, b.tokens, now.Sub(b.updated))
+	}
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+
+	ms.buckets[key] = bucket{tokens: tokens, updated: now, limit: limit}
+
+	return NewResult(limit, tokens, allowed), nil
+}
+
+// sweep removes the buckets that have refilled with their own limit since a
+// full bucket is the same as no bucket. It runs at most once a minute. The
+// caller must hold the lock.
+func (ms *MemoryStore) sweep(now time.Time) {
+	if now.Sub(ms.lastSweep) < time.Minute {
+		return
+	}
+	ms.lastSweep = now
+
+	for key, b := range ms.buckets {
+		if Refill(b.limit, b.tokens, now.Sub(b.updated)) >= float64(b.limit.Burst) {
+			delete(ms.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Package ratelimit provides token bucket rate limiting with pluggable
+// storage for the buckets.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit represents the configuration of a token bucket. Rate is the number of
+// tokens added to the bucket every second and Burst is the size of the
+// bucket. A request takes one token. A zero Limit disables rate limiting.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled reports whether the limit restricts any requests.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// String implements the fmt.Stringer interface.
+func (l Limit) String() string {
+	return fmt.Sprintf("%g/s burst %d", l.Rate, l.Burst)
+}
+
+// Result represents the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// Storer interface declares the behavior this package needs to keep token
+// buckets. Take must refill the bucket for the time elapsed since it was last
+// used and take a token if one is available, as a single atomic operation.
+type Storer interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Refill returns the number of tokens in a bucket that held the specified
+// tokens elapsed ago.
+func Refill(limit Limit, tokens float64, elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	return math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate)
+}
+
+// NewResult constructs the Result for a bucket left holding the specified
+// tokens after a request was allowed or denied.
+func NewResult(limit Limit, tokens float64, allowed bool) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+
+	return res
+}
+
+// ParseLimits parses per group limits in the form group:rate:burst and
+// returns the limit for every group in groups. Groups without a spec get the
+// default limit.
+func ParseLimits(defaultLimit Limit, groups []string, specs []string) (map[string]Limit, error) {
+	limits := make(map[string]Limit, len(groups))
+	for _, group := range groups {
+		limits[group] = defaultLimit
+	}
+
+	for _, spec := range specs {
+		parts := strings.Split(strings.TrimSpace(spec), ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid limit %q: expecting group:rate:burst", spec)
+		}
+
+		if _, exists := limits[parts[0]]; !exists {
+			return nil, fmt.Errorf("invalid limit %q: unknown group %q", spec, parts[0])
+		}
+
+		rate, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit %q: rate: %w", spec, err)
+		}
+
+		burst, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit %q: burst: %w", spec, err)
+		}
+
+		limits[parts[0]] = Limit{Rate: rate, Burst: burst}
+	}
+
+	return limits, nil
+}
+
+// seconds converts the number of seconds into a duration rounded up to the
+// next whole second, as used by the rate limit headers.
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+
+	return time.Duration(math.Ceil(s)) * time.Second
+}
@@ -0,0 +1,192 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	v1 "github.com/fadhilijuma/gateone-service/business/web/v1"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/mid"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/ratelimit"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_MemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Rate: 1, Burst: 3}
+
+	for i := 0; i < limit.Burst; i++ {
+		res, err := store.Take(ctx, "user:1", limit)
+		if err != nil {
+			t.Fatalf("Should be able to take a token: %s", err)
+		}
+
+		if !res.Allowed {
+			t.Fatalf("Should allow request %d within the burst", i+1)
+		}
+
+		if exp := limit.Burst - i - 1; res.Remaining != exp {
+			t.Errorf("Should have %d tokens remaining: got %d", exp, res.Remaining)
+		}
+	}
+
+	res, err := store.Take(ctx, "user:1", limit)
+	if err != nil {
+		t.Fatalf("Should be able to take a token: %s", err)
+	}
+
+	if res.Allowed {
+		t.Fatal("Should deny the request once the burst is used")
+	}
+
+	if res.RetryAfter != time.Second {
+		t.Errorf("Should retry after a second: got %s", res.RetryAfter)
+	}
+
+	if res.Reset != 3*time.Second {
+		t.Errorf("Should reset after three seconds: got %s", res.Reset)
+	}
+
+	res, err = store.Take(ctx, "user:2", limit)
+	if err != nil {
+		t.Fatalf("Should be able to take a token: %s", err)
+	}
+
+	if !res.Allowed {
+		t.Error("Should keep a separate bucket for every key")
+	}
+}
+
+func Test_ParseLimits(t *testing.T) {
+	def := ratelimit.Limit{Rate: 10, Burst: 20}
+
+	limits, err := ratelimit.ParseLimits(def, []string{"patients", "tokens"}, []string{"tokens:0.5:5"})
+	if err != nil {
+		t.Fatalf("Should be able to parse the limits: %s", err)
+	}
+
+	if limits["patients"] != def {
+		t.Errorf("Should use the default limit: got %s", limits["patients"])
+	}
+
+	if exp := (ratelimit.Limit{Rate: 0.5, Burst: 5}); limits["tokens"] != exp {
+		t.Errorf("Should use the group limit: got %s", limits["tokens"])
+	}
+
+	for _, spec := range []string{"tokens:5", "unknown:1:1", "tokens:x:1", "tokens:1:x"} {
+		if _, err := ratelimit.ParseLimits(def, []string{"tokens"}, []string{spec}); err == nil {
+			t.Errorf("Should not be able to parse %q", spec)
+		}
+	}
+}
+
+func Test_Middleware(t *testing.T) {
+	log := logger.New(os.Stdout, logger.LevelError, "TEST", func(context.Context) string { return "" })
+
+	var handler web.Handler = func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	m := mid.RateLimit(log, ratelimit.NewMemoryStore(), "tokens", ratelimit.Limit{Rate: 1, Burst: 1})
+	h := m(handler)
+
+	call := func(remoteAddr string) (*httptest.ResponseRecorder, error) {
+		r := httptest.NewRequest(http.MethodGet, "/v1/users/token", nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+
+		return w, h(context.Background(), w, r)
+	}
+
+	w, err := call("10.0.0.1:5000")
+	if err != nil {
+		t.Fatalf("Should allow the first request: %s", err)
+	}
+
+	if w.Header().Get("RateLimit-Limit") != "1" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("Should set the rate limit headers: got %v", w.Header())
+	}
+
+	w, err = call("10.0.0.1:5001")
+
+	var trusted *v1.TrustedError
+	if !errors.As(err, &trusted) || trusted.Status != http.StatusTooManyRequests {
+		t.Fatalf("Should limit the second request from the same IP: got %v", err)
+	}
+
+	if w.Header().Get("Retry-After") != "1" {
+		t.Errorf("Should set the Retry-After header: got %q", w.Header().Get("Retry-After"))
+	}
+
+	if _, err := call("10.0.0.2:5000"); err != nil {
+		t.Errorf("Should allow a request from another IP: %s", err)
+	}
+
+	if mid.RateLimit(log, ratelimit.NewMemoryStore(), "tokens", ratelimit.Limit{}) != nil {
+		t.Error("Should disable the middleware for a zero limit")
+	}
+}
+
+func Test_ClientIP(t *testing.T) {
+	log := logger.New(os.Stdout, logger.LevelError, "TEST", func(context.Context) string { return "" })
+
+	var handler web.Handler = func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	trusted, err := mid.ParseTrustedProxies([]string{"10.0.0.0/24", "192.0.2.1"})
+	if err != nil {
+		t.Fatalf("Should be able to parse the trusted proxies: %s", err)
+	}
+
+	if _, err := mid.ParseTrustedProxies([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("Should not be able to parse an invalid trusted proxy")
+	}
+
+	tests := []struct {
+		name  string
+		first [2]string
+		next  [2]string
+		limit bool
+	}{
+		{"spoofed-direct", [2]string{"203.0.113.7:5000", "1.1.1.1"}, [2]string{"203.0.113.7:5001", "2.2.2.2"}, true},
+		{"spoofed-behind-proxy", [2]string{"10.0.0.1:5000", "1.1.1.1, 203.0.113.7"}, [2]string{"10.0.0.2:5000", "2.2.2.2, 203.0.113.7"}, true},
+		{"past-trusted-hops", [2]string{"10.0.0.1:5000", "203.0.113.7, 192.0.2.1"}, [2]string{"192.0.2.1:5000", "203.0.113.7"}, true},
+		{"clients-behind-proxy", [2]string{"10.0.0.1:5000", "203.0.113.7"}, [2]string{"10.0.0.1:5000", "203.0.113.8"}, false},
+		{"untrusted-peer", [2]string{"198.51.100.1:5000", "203.0.113.7"}, [2]string{"198.51.100.2:5000", "203.0.113.7"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := mid.RateLimit(log, ratelimit.NewMemoryStore(), "tokens", ratelimit.Limit{Rate: 0.001, Burst: 1})
+			h := mid.ClientIP(trusted)(rl(handler))
+
+			call := func(req [2]string) error {
+				r := httptest.NewRequest(http.MethodGet, "/v1/users/token", nil)
+				r.RemoteAddr = req[0]
+				r.Header.Set("X-Forwarded-For", req[1])
+
+				return h(context.Background(), httptest.NewRecorder(), r)
+			}
+
+			if err := call(tt.first); err != nil {
+				t.Fatalf("Should allow the first request: %s", err)
+			}
+
+			err := call(tt.next)
+
+			var trusted *v1.TrustedError
+			limited := errors.As(err, &trusted) && trusted.Status == http.StatusTooManyRequests
+			if limited != tt.limit {
+				t.Errorf("Should limit the second request %t: got %v", tt.limit, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Package ratelimitdb contains the database backed token bucket store so
+// limits can be shared between replicas of the service.
+package ratelimitdb
+
+import (
+	"context"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/business/data/sqldb"
+	"github.com/fadhilijuma/gateone-service/business/web/v1/ratelimit"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for rate limit database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Take implements the ratelimit.Storer interface. The bucket is refilled and
+// a token taken in a single statement using the database clock, so replicas
+// never race each other or disagree about the time.
+func (s *Store) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	data := struct {
+		Key   string  `db:"key"`
+		Rate  float64 `db:"rate"`
+		Burst float64 `db:"burst"`
+	}{
+		Key:   key,
+		Rate:  limit.Rate,
+		Burst: float64(limit.Burst),
+	}
+
+	// refill is the number of tokens in the bucket before this request.
+	const refill = `LEAST(CAST(:burst AS DOUBLE PRECISION), rate_limits.tokens +
+		CAST(EXTRACT(EPOCH FROM now() - rate_limits.updated_at) AS DOUBLE PRECISION) * CAST(:rate AS DOUBLE PRECISION))`
+
+	const q = `
+	INSERT INTO rate_limits
+		(key, tokens, allowed, updated_at)
+	VALUES
+		(:key, CAST(:burst AS DOUBLE PRECISION) - 1, TRUE, now())
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = CASE WHEN ` + refill + ` >= 1 THEN ` + refill + ` - 1 ELSE ` + refill + ` END,
+		allowed = ` + refill + ` >= 1,
+		updated_at = now()
+	RETURNING
+		tokens, allowed`
+
+	var dest struct {
+		Tokens  float64 `db:"tokens"`
+		Allowed bool    `db:"allowed"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dest); err != nil {
+		return ratelimit.Result{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return ratelimit.NewResult(limit, dest.Tokens, dest.Allowed), nil
+}
+
+// Prune removes the buckets that have not been used for the specified
+// duration. Buckets refill long before they are pruned so removing them does
+// not change any limits.
+func (s *Store) Prune(ctx context.Context, idle time.Duration) error {
+	data := struct {
+		Idle float64 `db:"idle"`
+	}{
+		Idle: idle.Seconds(),
+	}
+
+	const q = `
+	DELETE FROM
+		rate_limits
+	WHERE
+		updated_at < now() - make_interval(secs => CAST(:idle AS DOUBLE PRECISION))`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
@@ -3,12 +3,6 @@ package v1
 
 import "errors"
 
-// ErrorResponse is the form used for API responses from failures in the API.
-type ErrorResponse struct {
-	Error  string            `json:"error"`
-	Fields map[string]string `json:"fields,omitempty"`
-}
-
 // TrustedError is used to pass an error during the request through the
 // application with web specific context.
 type TrustedError struct {
@@ -28,6 +22,12 @@ func (te *TrustedError) Error() string {
 	return te.Err.Error()
 }
 
+// Unwrap returns the wrapped error so errors.Is can match the core errors
+// carried by a trusted error.
+func (te *TrustedError) Unwrap() error {
+	return te.Err
+}
+
 // IsTrustedError checks if an error of type TrustedError exists.
 func IsTrustedError(err error) bool {
 	var te *TrustedError
@@ -60,3 +60,24 @@ func NewPageDocument[T any](items []T, total int, page int, rowsPerPage int) Pag
 		RowsPerPage: rowsPerPage,
 	}
 }
+
+// CursorDocument is the form used for API responses from query API calls
+// that are paged by cursor. Next and Prev are the cursors for the pages on
+// either side and are omitted when there are no more rows that way.
+type CursorDocument[T any] struct {
+	Items       []T    `json:"items"`
+	Next        string `json:"next,omitempty"`
+	Prev        string `json:"prev,omitempty"`
+	RowsPerPage int    `json:"rowsPerPage"`
+}
+
+// NewCursorDocument constructs a response value for a web cursor paging
+// request.
+func NewCursorDocument[T any](items []T, next string, prev string, rowsPerPage int) CursorDocument[T] {
+	return CursorDocument[T]{
+		Items:       items,
+		Next:        next,
+		Prev:        prev,
+		RowsPerPage: rowsPerPage,
+	}
+}
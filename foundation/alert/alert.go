@@ -0,0 +1,477 @@
+// Package alert provides support for sending alerts for the records logged
+// at configured levels to pluggable sinks. Alerts are deduplicated by their
+// fingerprint, rate limited and batched per level, and delivered in the
+// background so a failing or slow sink never blocks the caller logging.
+package alert
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Alert represents a record that is alerted on. Count is the number of
+// times the record was logged, it is greater than 1 when duplicates were
+// suppressed within the dedup window.
+type Alert struct {
+	Fingerprint string         `json:"fingerprint"`
+	Time        time.Time      `json:"time"`
+	Level       string         `json:"level"`
+	Message     string         `json:"message"`
+	TraceID     string         `json:"trace_id,omitzero"`
+	Count       int            `json:"count"`
+	Attributes  map[string]any `json:"attributes,omitzero"`
+}
+
+// Sink represents a destination alerts are sent to. Send receives the
+// alerts of a batch, which all have the same level.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, alerts []Alert) error
+}
+
+// Level represents the settings for the alerts of a single level. Alerts
+// with the same fingerprint are sent once per DedupWindow. At most Rate
+// alerts are sent per RatePeriod and the rest are dropped. Alerts are sent
+// in batches of up to BatchSize alerts, a batch is sent at the latest
+// BatchWait after its first alert.
+type Level struct {
+	Sinks       []Sink
+	DedupWindow time.Duration
+	Rate        int
+	RatePeriod  time.Duration
+	BatchSize   int
+	BatchWait   time.Duration
+}
+
+// Config represents the settings for alerting. Alerts are queued for the
+// background delivery in a queue of QueueSize alerts and dropped when the
+// queue is full. SendTimeout bounds the time a sink has to send a batch.
+// Log is used to report sink failures, those records are never alerted on.
+type Config struct {
+	Log         *logger.Logger
+	Levels      map[logger.Level]Level
+	QueueSize   int
+	SendTimeout time.Duration
+}
+
+// Set of defaults for the settings that are not set.
+const (
+	defaultQueueSize   = 1000
+	defaultSendTimeout = 10 * time.Second
+	defaultRatePeriod  = time.Minute
+	defaultBatchWait   = 10 * time.Second
+	tickInterval       = 100 * time.Millisecond
+)
+
+// Alerter sends alerts for the records logged at the configured levels.
+type Alerter struct {
+	state   atomic.Pointer[state]
+	dropped atomic.Uint64
+}
+
+// state holds what a started Alerter needs to accept and process alerts.
+type state struct {
+	cfg    Config
+	queue  chan Alert
+	done   chan struct{}
+	closed chan struct{}
+	sends  sync.WaitGroup
+}
+
+// New constructs an Alerter that drops every record until it is started.
+// This lets the event functions be handed to the logger before the
+// configuration of the alerts has been loaded.
+func New() *Alerter {
+	return &Alerter{}
+}
+
+// Events returns the event functions that alert on the records logged at
+// the warn and error levels, the levels that are not configured are ignored.
+// Lower levels are not hooked to keep every record from being copied for
+// the events.
+func (a *Alerter) Events() logger.Events {
+	return logger.Events{
+		Warn:  a.Notify,
+		Error: a.Notify,
+	}
+}
+
+// Start begins processing the alerts of the configured levels.
+func (a *Alerter) Start(cfg Config) error {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+
+	if cfg.SendTimeout <= 0 {
+		cfg.SendTimeout = defaultSendTimeout
+	}
+
+	levels := make(map[logger.Level]Level, len(cfg.Levels))
+	for level, lc := range cfg.Levels {
+		if len(lc.Sinks) == 0 {
+			continue
+		}
+
+		if lc.Rate < 0 || lc.BatchSize < 0 || lc.DedupWindow < 0 {
+			return fmt.Errorf("level %s: rate, batch size and dedup window must not be negative", level)
+		}
+
+		if lc.RatePeriod <= 0 {
+			lc.RatePeriod = defaultRatePeriod
+		}
+
+		if lc.BatchSize == 0 {
+			lc.BatchSize = 1
+		}
+
+		if lc.BatchWait <= 0 {
+			lc.BatchWait = defaultBatchWait
+		}
+
+		levels[level] = lc
+	}
+	cfg.Levels = levels
+
+	s := state{
+		cfg:    cfg,
+		queue:  make(chan Alert, cfg.QueueSize),
+		done:   make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+
+	if !a.state.CompareAndSwap(nil, &s) {
+		return errors.New("alerter already started")
+	}
+
+	go func() {
+		defer close(s.closed)
+		a.process(&s)
+	}()
+
+	return nil
+}
+
+// Shutdown stops accepting alerts, sends the alerts that are pending and
+// waits for the sends to complete before closing the sinks.
+func (a *Alerter) Shutdown(ctx context.Context) error {
+	s := a.state.Load()
+	if s == nil {
+		return nil
+	}
+
+	close(s.done)
+
+	ch := make(chan struct{})
+	go func() {
+		<-s.closed
+		s.sends.Wait()
+		close(ch)
+	}()
+
+	select {
+	case <-ch:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	var errs []error
+	for _, sink := range s.sinks() {
+		if c, ok := sink.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Dropped returns the number of alerts dropped since the queue was full or
+// the rate limit of their level was reached.
+func (a *Alerter) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Notify queues an alert for the record if its level is configured. It
+// never blocks, the alert is dropped when the queue is full.
+func (a *Alerter) Notify(ctx context.Context, r logger.Record) {
+	if suppressed(ctx) {
+		return
+	}
+
+	s := a.state.Load()
+	if s == nil {
+		return
+	}
+
+	if _, exists := s.cfg.Levels[r.Level]; !exists {
+		return
+	}
+
+	select {
+	case <-s.done:
+	case s.queue <- toAlert(r):
+	default:
+		a.dropped.Add(1)
+	}
+}
+
+// =============================================================================
+
+// sinks returns every sink once.
+func (s *state) sinks() []Sink {
+	seen := make(map[Sink]bool)
+
+	var sinks []Sink
+	for _, lc := range s.cfg.Levels {
+		for _, sink := range lc.Sinks {
+			if !seen[sink] {
+				seen[sink] = true
+				sinks = append(sinks, sink)
+			}
+		}
+	}
+
+	return sinks
+}
+
+// seen tracks the alerts of a fingerprint within the dedup window.
+type seen struct {
+	until      time.Time
+	last       Alert
+	suppressed int
+}
+
+// pending tracks the alerts of a level waiting to be sent.
+type pending struct {
+	cfg         Level
+	dedup       map[string]*seen
+	batch       []Alert
+	batchUntil  time.Time
+	rateUntil   time.Time
+	rateCount   int
+	rateDropped int
+}
+
+// process deduplicates, rate limits and batches the queued alerts until the
+// Alerter is shut down.
+func (a *Alerter) process(s *state) {
+	levels := make(map[string]*pending, len(s.cfg.Levels))
+	for level, lc := range s.cfg.Levels {
+		levels[level.String()] = &pending{cfg: lc, dedup: make(map[string]*seen)}
+	}
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case alert := <-s.queue:
+			a.add(s, levels[alert.Level], alert, time.Now())
+
+		case <-ticker.C:
+			now := time.Now()
+			for _, p := range levels {
+				a.expire(s, p, now)
+			}
+
+		case <-s.done:
+			a.drain(s, levels)
+			return
+		}
+	}
+}
+
+// drain processes the alerts left in the queue and sends everything that is
+// pending, including the duplicates suppressed within open dedup windows.
+func (a *Alerter) drain(s *state, levels map[string]*pending) {
+	now := time.Now()
+
+	for {
+		select {
+		case alert := <-s.queue:
+			a.add(s, levels[alert.Level], alert, now)
+
+		default:
+			for _, p := range levels {
+				a.repeated(s, p, now, true)
+				a.flush(s, p)
+			}
+			return
+		}
+	}
+}
+
+// add deduplicates and rate limits the alert before adding it to the batch.
+func (a *Alerter) add(s *state, p *pending, alert Alert, now time.Time) {
+	if p.cfg.DedupWindow > 0 {
+		if sn, exists := p.dedup[alert.Fingerprint]; exists && now.Before(sn.until) {
+			sn.suppressed++
+			sn.last = alert
+			return
+		}
+
+		p.dedup[alert.Fingerprint] = &seen{until: now.Add(p.cfg.DedupWindow)}
+	}
+
+	a.batch(s, p, alert, now)
+}
+
+// batch adds the alert to the batch when the rate limit allows it and sends
+// the batch when it is full.
+func (a *Alerter) batch(s *state, p *pending, alert Alert, now time.Time) {
+	if !now.Before(p.rateUntil) {
+		if p.rateDropped > 0 {
+			s.warn("alerts rate limited", "level", alert.Level, "dropped", p.rateDropped)
+		}
+
+		p.rateUntil = now.Add(p.cfg.RatePeriod)
+		p.rateCount = 0
+		p.rateDropped = 0
+	}
+
+	if p.cfg.Rate > 0 && p.rateCount >= p.cfg.Rate {
+		p.rateDropped++
+		a.dropped.Add(1)
+		return
+	}
+	p.rateCount++
+
+	if len(p.batch) == 0 {
+		p.batchUntil = now.Add(p.cfg.BatchWait)
+	}
+	p.batch = append(p.batch, alert)
+
+	if len(p.batch) >= p.cfg.BatchSize {
+		a.flush(s, p)
+	}
+}
+
+// expire reports the duplicates suppressed within the dedup windows that
+// have ended and sends the batch once it waited long enough.
+func (a *Alerter) expire(s *state, p *pending, now time.Time) {
+	a.repeated(s, p, now, false)
+
+	if len(p.batch) > 0 && !now.Before(p.batchUntil) {
+		a.flush(s, p)
+	}
+}
+
+// repeated adds an alert to the batch for the duplicates suppressed within
+// the dedup windows that have ended, or within all of them when all is set.
+// The alert counts the duplicates and holds the last of them.
+func (a *Alerter) repeated(s *state, p *pending, now time.Time, all bool) {
+	for fp, sn := range p.dedup {
+		if !all && now.Before(sn.until) {
+			continue
+		}
+
+		delete(p.dedup, fp)
+
+		if sn.suppressed > 0 {
+			alert := sn.last
+			alert.Count = sn.suppressed
+			a.batch(s, p, alert, now)
+		}
+	}
+}
+
+// flush sends the batch to the sinks of the level in the background.
+func (a *Alerter) flush(s *state, p *pending) {
+	if len(p.batch) == 0 {
+		return
+	}
+
+	batch := p.batch
+	p.batch = nil
+
+	for _, sink := range p.cfg.Sinks {
+		s.sends.Add(1)
+
+		go func(sink Sink) {
+			defer s.sends.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), s.cfg.SendTimeout)
+			defer cancel()
+
+			if err := sink.Send(ctx, batch); err != nil {
+				s.warn("alert sink failed", "sink", sink.Name(), "alerts", len(batch), "msg", err)
+			}
+		}(sink)
+	}
+}
+
+// warn logs the problems of the alerter itself. They are not alerted on to
+// keep a failing sink from feeding itself alerts.
+func (s *state) warn(msg string, args ...any) {
+	if s.cfg.Log == nil {
+		return
+	}
+
+	s.cfg.Log.Warn(withoutAlerts(context.Background()), msg, args...)
+}
+
+// =============================================================================
+
+// toAlert constructs an alert from the record. Errors and other values
+// that don't marshal on their own are replaced by their text.
+func toAlert(r logger.Record) Alert {
+	attrs := make(map[string]any, len(r.Attributes))
+	for k, v := range r.Attributes {
+		switch v := v.(type) {
+		case error:
+			attrs[k] = v.Error()
+		case fmt.Stringer:
+			attrs[k] = v.String()
+		default:
+			attrs[k] = v
+		}
+	}
+
+	traceID, _ := attrs["trace_id"].(string)
+	delete(attrs, "trace_id")
+
+	return Alert{
+		Fingerprint: Fingerprint(r.Level, r.Message),
+		Time:        r.Time,
+		Level:       r.Level.String(),
+		Message:     r.Message,
+		TraceID:     traceID,
+		Count:       1,
+		Attributes:  attrs,
+	}
+}
+
+// Fingerprint returns the identity of the records alerts are deduplicated
+// by, which is the level and the message.
+func Fingerprint(level logger.Level, msg string) string {
+	sum := sha256.Sum256([]byte(level.String() + "\x00" + msg))
+	return hex.EncodeToString(sum[:8])
+}
+
+type ctxKey int
+
+const suppressKey ctxKey = 1
+
+// withoutAlerts returns a context whose records are not alerted on.
+func withoutAlerts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, suppressKey, true)
+}
+
+// suppressed reports whether the records of the context are not alerted on.
+func suppressed(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+
+	v, _ := ctx.Value(suppressKey).(bool)
+	return v
+}
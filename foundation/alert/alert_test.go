@@ -0,0 +1,312 @@
+package alert_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/fadhilijuma/gateone-service/foundation/alert"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-json-experiment/json"
+)
+
+// memorySink records the batches it is sent.
+type memorySink struct {
+	mu      sync.Mutex
+	batches [][]alert.Alert
+	err     error
+	block   chan struct{}
+}
+
+func (ms *memorySink) Name() string {
+	return "memory"
+}
+
+func (ms *memorySink) Send(ctx context.Context, alerts []alert.Alert) error {
+	if ms.block != nil {
+		select {
+		case <-ms.block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.batches = append(ms.batches, alerts)
+	return ms.err
+}
+
+func (ms *memorySink) alerts() []alert.Alert {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var alerts []alert.Alert
+	for _, batch := range ms.batches {
+		alerts = append(alerts, batch...)
+	}
+
+	return alerts
+}
+
+func newLogger(t *testing.T, alerts *alert.Alerter) (*logger.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	log := logger.NewWithEvents(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "trace-1" }, alerts.Events())
+
+	return log, &buf
+}
+
+func Test_Dedup(t *testing.T) {
+	alerts := alert.New()
+	log, _ := newLogger(t, alerts)
+
+	sink := memorySink{}
+	err := alerts.Start(alert.Config{
+		Levels: map[logger.Level]alert.Level{
+			logger.LevelError: {Sinks: []alert.Sink{&sink}, DedupWindow: 300 * time.Millisecond, BatchSize: 10, BatchWait: 50 * time.Millisecond},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Should be able to start the alerter: %s", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		log.Error(ctx, "database down", "msg", errors.New("connection refused"))
+	}
+	log.Warn(ctx, "not configured")
+
+	time.Sleep(200 * time.Millisecond)
+
+	got := sink.alerts()
+	if len(got) != 1 {
+		t.Fatalf("Should send the first alert only within the window: got %d", len(got))
+	}
+
+	a := got[0]
+	if a.Count != 1 || a.Message != "database down" || a.Level != "ERROR" || a.TraceID != "trace-1" {
+		t.Errorf("Should get the alert of the record: got %+v", a)
+	}
+
+	if a.Attributes["msg"] != "connection refused" {
+		t.Errorf("Should get the error text as an attribute: got %v", a.Attributes["msg"])
+	}
+
+	if a.Fingerprint != alert.Fingerprint(logger.LevelError, "database down") {
+		t.Errorf("Should fingerprint the level and message: got %s", a.Fingerprint)
+	}
+
+	time.Sleep(400 * time.Millisecond)
+
+	got = sink.alerts()
+	if len(got) != 2 {
+		t.Fatalf("Should send the suppressed duplicates when the window ends: got %d", len(got))
+	}
+
+	if got[1].Count != 4 || got[1].Fingerprint != a.Fingerprint {
+		t.Errorf("Should count the 4 suppressed duplicates: got %+v", got[1])
+	}
+
+	if err := alerts.Shutdown(ctx); err != nil {
+		t.Fatalf("Should be able to shut down: %s", err)
+	}
+}
+
+func Test_RateLimitAndBatch(t *testing.T) {
+	alerts := alert.New()
+	log, _ := newLogger(t, alerts)
+
+	sink := memorySink{}
+	err := alerts.Start(alert.Config{
+		Levels: map[logger.Level]alert.Level{
+			logger.LevelError: {Sinks: []alert.Sink{&sink}, Rate: 4, RatePeriod: time.Hour, BatchSize: 3, BatchWait: time.Hour},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Should be able to start the alerter: %s", err)
+	}
+
+	ctx := context.Background()
+	for _, msg := range []string{"a", "b", "c", "d", "e", "f"} {
+		log.Error(ctx, msg)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	sink.mu.Lock()
+	if len(sink.batches) != 1 || len(sink.batches[0]) != 3 {
+		t.Errorf("Should send a full batch of 3 alerts: got %v", sink.batches)
+	}
+	sink.mu.Unlock()
+
+	// The fourth alert waits in a batch that is sent on shutdown.
+	if err := alerts.Shutdown(ctx); err != nil {
+		t.Fatalf("Should be able to shut down: %s", err)
+	}
+
+	if got := sink.alerts(); len(got) != 4 {
+		t.Errorf("Should send 4 alerts within the rate: got %d", len(got))
+	}
+
+	if alerts.Dropped() != 2 {
+		t.Errorf("Should drop 2 alerts over the rate: got %d", alerts.Dropped())
+	}
+}
+
+func Test_NonBlocking(t *testing.T) {
+	alerts := alert.New()
+	log, buf := newLogger(t, alerts)
+
+	failing := memorySink{err: errors.New("unavailable")}
+	blocked := memorySink{block: make(chan struct{})}
+
+	err := alerts.Start(alert.Config{
+		Log: log,
+		Levels: map[logger.Level]alert.Level{
+			logger.LevelWarn:  {Sinks: []alert.Sink{&failing}},
+			logger.LevelError: {Sinks: []alert.Sink{&failing, &blocked}},
+		},
+		QueueSize:   10,
+		SendTimeout: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to start the alerter: %s", err)
+	}
+
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		log.Error(ctx, "overloaded")
+	}
+
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("Should not block logging on the sinks: took %s", took)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+
+	if err := alerts.Shutdown(ctx); err != nil {
+		t.Fatalf("Should be able to shut down: %s", err)
+	}
+
+	if !strings.Contains(buf.String(), "alert sink failed") {
+		t.Error("Should log the sink failures.")
+	}
+
+	for _, a := range failing.alerts() {
+		if a.Message == "alert sink failed" {
+			t.Fatal("Should not alert on the sink failures.")
+		}
+	}
+}
+
+func Test_Sinks(t *testing.T) {
+	ctx := context.Background()
+
+	batch := []alert.Alert{
+		{Fingerprint: "fp", Time: time.Now(), Level: "ERROR", Message: "database down", Count: 2, Attributes: map[string]any{"db": "postgres"}},
+	}
+
+	t.Run("webhook", func(t *testing.T) {
+		var body []byte
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ = io.ReadAll(r.Body)
+		}))
+		defer srv.Close()
+
+		if err := alert.NewWebhook(srv.URL, nil).Send(ctx, batch); err != nil {
+			t.Fatalf("Should be able to send to the webhook: %s", err)
+		}
+
+		var got struct {
+			Alerts []alert.Alert `json:"alerts"`
+		}
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatalf("Should be able to unmarshal the body: %s", err)
+		}
+
+		if len(got.Alerts) != 1 || got.Alerts[0].Message != "database down" || got.Alerts[0].Count != 2 {
+			t.Errorf("Should post the alerts: got %s", body)
+		}
+
+		srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		})
+
+		if err := alert.NewWebhook(srv.URL, nil).Send(ctx, batch); err == nil {
+			t.Error("Should fail on a status other than 2xx.")
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "alerts.log")
+
+		file, err := alert.NewFile(path)
+		if err != nil {
+			t.Fatalf("Should be able to open the file: %s", err)
+		}
+
+		if err := file.Send(ctx, append(batch, batch...)); err != nil {
+			t.Fatalf("Should be able to write to the file: %s", err)
+		}
+		file.Close()
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Should be able to read the file: %s", err)
+		}
+
+		if n := strings.Count(string(data), "\n"); n != 2 {
+			t.Errorf("Should write an alert per line: got %d lines", n)
+		}
+	})
+
+	t.Run("email", func(t *testing.T) {
+		dir := t.TempDir()
+
+		outbox, err := alert.NewSpoolOutbox(dir)
+		if err != nil {
+			t.Fatalf("Should be able to construct the outbox: %s", err)
+		}
+
+		if _, err := alert.NewEmail(outbox, "alerts@gateone.local", nil); err == nil {
+			t.Error("Should not be able to construct an email sink without recipients.")
+		}
+
+		email, err := alert.NewEmail(outbox, "alerts@gateone.local", []string{"ops@gateone.local"})
+		if err != nil {
+			t.Fatalf("Should be able to construct the email sink: %s", err)
+		}
+
+		if err := email.Send(ctx, batch); err != nil {
+			t.Fatalf("Should be able to enqueue the email: %s", err)
+		}
+
+		files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+		if err != nil || len(files) != 1 {
+			t.Fatalf("Should spool a message: got %v %v", files, err)
+		}
+
+		data, err := os.ReadFile(files[0])
+		if err != nil {
+			t.Fatalf("Should be able to read the message: %s", err)
+		}
+
+		for _, exp := range []string{"To: ops@gateone.local", "Subject: [ERROR] database down", "count: 2", "db: postgres"} {
+			if !strings.Contains(string(data), exp) {
+				t.Errorf("Should find %q in the message: got %s", exp, data)
+			}
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Email represents an email message to be sent.
+type Email struct {
+	From    string
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Outbox represents a store of emails that are sent later by something
+// else, so sending an alert never waits on a mail server.
+type Outbox interface {
+	Enqueue(ctx context.Context, email Email) error
+}
+
+// EmailSink sends alerts as an email through an outbox.
+type EmailSink struct {
+	outbox Outbox
+	from   string
+	to     []string
+}
+
+// NewEmail constructs a sink enqueuing an email listing the alerts of a
+// batch in the outbox.
+func NewEmail(outbox Outbox, from string, to []string) (*EmailSink, error) {
+	if len(to) == 0 {
+		return nil, errors.New("no recipients")
+	}
+
+	for _, addr := range append([]string{from}, to...) {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return nil, fmt.Errorf("address %q: %w", addr, err)
+		}
+	}
+
+	es := EmailSink{
+		outbox: outbox,
+		from:   from,
+		to:     to,
+	}
+
+	return &es, nil
+}
+
+// Name implements the Sink interface.
+func (es *EmailSink) Name() string {
+	return "email"
+}
+
+// Send implements the Sink interface.
+func (es *EmailSink) Send(ctx context.Context, alerts []Alert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	subject := fmt.Sprintf("[%s] %s", alerts[0].Level, alerts[0].Message)
+	if len(alerts) > 1 {
+		subject = fmt.Sprintf("[%s] %d alerts", alerts[0].Level, len(alerts))
+	}
+
+	var b strings.Builder
+	for _, alert := range alerts {
+		fmt.Fprintf(&b, "%s %s %s\n", alert.Time.UTC().Format(time.RFC3339), alert.Level, alert.Message)
+		fmt.Fprintf(&b, "  fingerprint: %s\n", alert.Fingerprint)
+		fmt.Fprintf(&b, "  count: %d\n", alert.Count)
+		if alert.TraceID != "" {
+			fmt.Fprintf(&b, "  trace_id: %s\n", alert.TraceID)
+		}
+
+		keys := make([]string, 0, len(alert.Attributes))
+		for k := range alert.Attributes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			fmt.Fprintf(&b, "  %s: %v\n", k, alert.Attributes[k])
+		}
+		b.WriteString("\n")
+	}
+
+	email := Email{
+		From:    es.from,
+		To:      es.to,
+		Subject: subject,
+		Body:    b.String(),
+	}
+
+	if err := es.outbox.Enqueue(ctx, email); err != nil {
+		return fmt.Errorf("enqueue: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// SpoolOutbox stores emails as message files in a spool directory that a
+// mail relay picks up and sends.
+type SpoolOutbox struct {
+	dir string
+}
+
+// NewSpoolOutbox constructs an outbox writing to the directory, which is
+// created when it doesn't exist.
+func NewSpoolOutbox(dir string) (*SpoolOutbox, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("mkdir: %w", err)
+	}
+
+	return &SpoolOutbox{dir: dir}, nil
+}
+
+// Enqueue implements the Outbox interface. The message is written under a
+// temporary name and renamed so a relay never reads a partial message.
+func (so *SpoolOutbox) Enqueue(ctx context.Context, email Email) error {
+	id := uuid.NewString()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", email.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(email.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@gateone>\r\n", id)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(email.Body, "\n", "\r\n"))
+
+	tmp := filepath.Join(so.dir, "."+id+".tmp")
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o640); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	if err := os.Rename(tmp, filepath.Join(so.dir, id+".eml")); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("rename: %w", err)
+	}
+
+	return nil
+}
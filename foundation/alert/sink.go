@@ -0,0 +1,123 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/go-json-experiment/json"
+)
+
+// Webhook sends alerts as JSON to a URL.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook constructs a sink posting the alerts of a batch to the URL in
+// the form of {"alerts":[...]}. The default client is used when client is
+// nil, the sends are bounded by the send timeout either way.
+func NewWebhook(url string, client *http.Client) *Webhook {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Webhook{
+		url:    url,
+		client: client,
+	}
+}
+
+// Name implements the Sink interface.
+func (wh *Webhook) Name() string {
+	return "webhook"
+}
+
+// Send implements the Sink interface. Responses other than 2xx are
+// reported as an error.
+func (wh *Webhook) Send(ctx context.Context, alerts []Alert) error {
+	body, err := json.Marshal(struct {
+		Alerts []Alert `json:"alerts"`
+	}{
+		Alerts: alerts,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("post: unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// File writes alerts as JSON lines to a file.
+type File struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFile constructs a sink appending the alerts to the file at the path,
+// the file is created when it doesn't exist.
+func NewFile(path string) (*File, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+
+	return &File{file: f}, nil
+}
+
+// Name implements the Sink interface.
+func (f *File) Name() string {
+	return "file"
+}
+
+// Send implements the Sink interface.
+func (f *File) Send(ctx context.Context, alerts []Alert) error {
+	var buf bytes.Buffer
+	for _, alert := range alerts {
+		if err := json.MarshalWrite(&buf, alert); err != nil {
+			return fmt.Errorf("marshal: %w", err)
+		}
+		buf.WriteByte('\n')
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.file.Close()
+}
@@ -0,0 +1,196 @@
+// Package jwks provides support for JSON Web Key Sets as defined by RFC 7517.
+// It can convert between PEM encoded public keys and their JWK form, and it
+// implements the auth.KeyLookup interface against a remote key set.
+package jwks
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Key represents a single public JSON Web Key.
+type Key struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	KeyID     string `json:"kid"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// Set represents a JSON Web Key Set document.
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+// Key searches the set for the specified key id.
+func (s Set) Key(kid string) (Key, bool) {
+	for _, key := range s.Keys {
+		if key.KeyID == kid {
+			return key, true
+		}
+	}
+
+	return Key{}, false
+}
+
+// FromPublicPEM converts a PEM encoded public key into a JWK for the
+// specified key id.
+func FromPublicPEM(kid string, publicPEM string) (Key, error) {
+	block, _ := pem.Decode([]byte(publicPEM))
+	if block == nil {
+		return Key{}, errors.New("invalid key: key must be PEM encoded")
+	}
+
+	parsedKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return Key{}, fmt.Errorf("parsing public key: %w", err)
+	}
+
+	switch pk := parsedKey.(type) {
+	case *rsa.PublicKey:
+		key := Key{
+			KeyType:   "RSA",
+			Use:       "sig",
+			Algorithm: "RS256",
+			KeyID:     kid,
+			N:         base64.RawURLEncoding.EncodeToString(pk.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pk.E)).Bytes()),
+		}
+
+		return key, nil
+
+	case *ecdsa.PublicKey:
+		if pk.Curve != elliptic.P256() {
+			return Key{}, fmt.Errorf("unsupported curve %s", pk.Curve.Params().Name)
+		}
+
+		// Coordinates are encoded at the full size of the curve as
+		// required by RFC 7518 section 6.2.1.2.
+		size := (pk.Curve.Params().BitSize + 7) / 8
+
+		key := Key{
+			KeyType:   "EC",
+			Use:       "sig",
+			Algorithm: "ES256",
+			KeyID:     kid,
+			Curve:     "P-256",
+			X:         base64.RawURLEncoding.EncodeToString(pk.X.FillBytes(make([]byte, size))),
+			Y:         base64.RawURLEncoding.EncodeToString(pk.Y.FillBytes(make([]byte, size))),
+		}
+
+		return key, nil
+
+	case ed25519.PublicKey:
+		key := Key{
+			KeyType:   "OKP",
+			Use:       "sig",
+			Algorithm: "EdDSA",
+			KeyID:     kid,
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(pk),
+		}
+
+		return key, nil
+	}
+
+	return Key{}, fmt.Errorf("unsupported public key type %T", parsedKey)
+}
+
+// PublicPEM converts the JWK into a PEM encoded public key.
+func (k Key) PublicPEM() (string, error) {
+	var pub any
+
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return "", fmt.Errorf("decoding modulus: %w", err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return "", fmt.Errorf("decoding exponent: %w", err)
+		}
+
+		pub = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+
+	case "EC":
+		if k.Curve != "P-256" {
+			return "", fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return "", fmt.Errorf("decoding x coordinate: %w", err)
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return "", fmt.Errorf("decoding y coordinate: %w", err)
+		}
+
+		pk := ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+
+		if !pk.Curve.IsOnCurve(pk.X, pk.Y) {
+			return "", errors.New("point is not on the curve")
+		}
+
+		pub = &pk
+
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return "", fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return "", fmt.Errorf("decoding public key: %w", err)
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return "", fmt.Errorf("invalid public key size %d", len(x))
+		}
+
+		pub = ed25519.PublicKey(x)
+
+	default:
+		return "", fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+
+	asn1Bytes, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("marshaling public key: %w", err)
+	}
+
+	publicBlock := pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: asn1Bytes,
+	}
+
+	var buf bytes.Buffer
+	if err := pem.Encode(&buf, &publicBlock); err != nil {
+		return "", fmt.Errorf("encoding to public PEM: %w", err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,214 @@
+package jwks_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/fadhilijuma/gateone-service/foundation/jwks"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-json-experiment/json"
+)
+
+func Test_RoundTrip(t *testing.T) {
+	key, err := jwks.FromPublicPEM(kid, publicKeyPEM)
+	if err != nil {
+		t.Fatalf("Should be able to convert the PEM to a JWK : %s", err)
+	}
+
+	if key.KeyID != kid || key.KeyType != "RSA" || key.Algorithm != "RS256" {
+		t.Fatalf("Should get back the expected key metadata : %+v", key)
+	}
+
+	pem, err := key.PublicPEM()
+	if err != nil {
+		t.Fatalf("Should be able to convert the JWK to a PEM : %s", err)
+	}
+
+	if pem != publicKeyPEM {
+		t.Logf("got: %s", pem)
+		t.Logf("exp: %s", publicKeyPEM)
+		t.Fatal("Should get back the same PEM")
+	}
+}
+
+func Test_RoundTripCurves(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate an ECDSA key : %s", err)
+	}
+
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate an Ed25519 key : %s", err)
+	}
+
+	tests := []struct {
+		name string
+		pub  any
+		kty  string
+		alg  string
+	}{
+		{name: "es256", pub: &ecKey.PublicKey, kty: "EC", alg: "ES256"},
+		{name: "eddsa", pub: edKey, kty: "OKP", alg: "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asn1Bytes, err := x509.MarshalPKIXPublicKey(tt.pub)
+			if err != nil {
+				t.Fatalf("Should be able to marshal the public key : %s", err)
+			}
+			publicPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: asn1Bytes}))
+
+			key, err := jwks.FromPublicPEM(kid, publicPEM)
+			if err != nil {
+				t.Fatalf("Should be able to convert the PEM to a JWK : %s", err)
+			}
+
+			if key.KeyType != tt.kty || key.Algorithm != tt.alg {
+				t.Fatalf("Should get back the expected key metadata : %+v", key)
+			}
+
+			got, err := key.PublicPEM()
+			if err != nil {
+				t.Fatalf("Should be able to convert the JWK to a PEM : %s", err)
+			}
+
+			if got != publicPEM {
+				t.Logf("got: %s", got)
+				t.Logf("exp: %s", publicPEM)
+				t.Fatal("Should get back the same PEM")
+			}
+		})
+	}
+}
+
+func Test_RemoteStore(t *testing.T) {
+	key, err := jwks.FromPublicPEM(kid, publicKeyPEM)
+	if err != nil {
+		t.Fatalf("Should be able to convert the PEM to a JWK : %s", err)
+	}
+
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		json.MarshalWrite(w, jwks.Set{Keys: []jwks.Key{key}})
+	}))
+	defer srv.Close()
+
+	rs, err := jwks.NewRemoteStore(jwks.RemoteConfig{URL: srv.URL, Client: srv.Client()})
+	if err != nil {
+		t.Fatalf("Should be able to construct a remote store : %s", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		pem, err := rs.PublicKey(kid)
+		if err != nil {
+			t.Fatalf("Should be able to lookup the public key : %s", err)
+		}
+
+		if pem != publicKeyPEM {
+			t.Fatal("Should get back the published public key")
+		}
+	}
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("Should fetch the key set once, got %d fetches", n)
+	}
+
+	if _, err := rs.PublicKey("unknown"); err == nil {
+		t.Error("Should NOT be able to lookup an unknown kid")
+	}
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("Should not refetch inside the minimum interval, got %d fetches", n)
+	}
+
+	if _, err := rs.PrivateKey(kid); err == nil {
+		t.Error("Should NOT be able to lookup a private key")
+	}
+}
+
+func Test_RemoteStoreSlowRefresh(t *testing.T) {
+	key, err := jwks.FromPublicPEM(kid, publicKeyPEM)
+	if err != nil {
+		t.Fatalf("Should be able to convert the PEM to a JWK : %s", err)
+	}
+
+	// Every fetch after the first waits until it's released.
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		json.MarshalWrite(w, jwks.Set{Keys: []jwks.Key{key}})
+	}))
+	defer srv.Close()
+
+	// The key set is stale as soon as it's fetched.
+	rs, err := jwks.NewRemoteStore(jwks.RemoteConfig{URL: srv.URL, Client: srv.Client(), TTL: time.Nanosecond, MinInterval: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("Should be able to construct a remote store : %s", err)
+	}
+
+	if _, err := rs.PublicKey(kid); err != nil {
+		t.Fatalf("Should be able to lookup the public key : %s", err)
+	}
+
+	unknown := make(chan error, 1)
+	go func() {
+		_, err := rs.PublicKey("unknown")
+		unknown <- err
+	}()
+
+	for fetches.Load() < 2 {
+		runtime.Gosched()
+	}
+
+	// The refresh is in flight, the cached key is still served.
+	looked := make(chan error, 1)
+	go func() {
+		_, err := rs.PublicKey(kid)
+		looked <- err
+	}()
+
+	select {
+	case err := <-looked:
+		if err != nil {
+			t.Errorf("Should be able to lookup the cached key during a refresh : %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Should not wait on the refresh to lookup a cached key")
+	}
+
+	close(release)
+
+	if err := <-unknown; err == nil {
+		t.Error("Should NOT be able to lookup an unknown kid")
+	}
+}
+
+const (
+	kid = "s4sKIjD9kIRjxs2tulPqGLdxSfgPErRN1Mu3Hd9k9NQ"
+
+	publicKeyPEM = `-----BEGIN PUBLIC KEY-----
+MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEAvMAHb0IoLvoYuW2kA+LT
+mnk+hfnBq1eYIh4CT/rMPCxgtzjqU0guQOMnLg69ydyA5uu37v6rbS1+stuBTEiM
+Ql/bxAhgLkGrUhgpZ10Bt6GzSEgwQNloZoGaxe4p20wMPpT4kcMKNHkQds3uONNc
+LxPUmfjbbH64g+seg28pbgQPwKFKtF7bIsOBgz0g5Ptn5mrkdzqMPUSy9k9VCu+R
+42LH9c75JsRzz4FeN+VzwMAL6yQnZvOi7/zOgNyxeVia8XVKykrnhgcpiOn5oaLR
+BzQGN00Z7TuBRIfDJWU21qQN4Cq7keZmMP4gqCVWjYneK4bzrG/+H2w9BJ2TsmMG
+vwIDAQAB
+-----END PUBLIC KEY-----
+`
+)
@@ -0,0 +1,203 @@
+package jwks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"golang.org/x/sync/singleflight"
+)
+
+// Set of default values for the remote store.
+const (
+	defaultTTL         = 10 * time.Minute
+	defaultMinInterval = 30 * time.Second
+)
+
+// refreshKey is the key concurrent refreshes of the key set share.
+const refreshKey = "refresh"
+
+// RemoteConfig represents the settings for constructing a RemoteStore.
+type RemoteConfig struct {
+	URL         string
+	Client      *http.Client
+	TTL         time.Duration
+	MinInterval time.Duration
+}
+
+// RemoteStore implements the auth.KeyLookup interface by fetching public keys
+// from a remote JWKS endpoint. The key set is cached for the configured time
+// to live. An unknown key id forces a refresh so newly published keys are
+// picked up, but never more often than the minimum interval. A RemoteStore
+// only holds public keys and can't be used to sign tokens.
+type RemoteStore struct {
+	url         string
+	client      *http.Client
+	ttl         time.Duration
+	minInterval time.Duration
+	group       singleflight.Group
+
+	mu          sync.Mutex
+	keys        map[string]string
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+// NewRemoteStore constructs a RemoteStore for the specified configuration.
+func NewRemoteStore(cfg RemoteConfig) (*RemoteStore, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("jwks url must be provided")
+	}
+
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultTTL
+	}
+
+	if cfg.MinInterval <= 0 {
+		cfg.MinInterval = defaultMinInterval
+	}
+
+	rs := RemoteStore{
+		url:         cfg.URL,
+		client:      cfg.Client,
+		ttl:         cfg.TTL,
+		minInterval: cfg.MinInterval,
+		keys:        make(map[string]string),
+	}
+
+	return &rs, nil
+}
+
+// PrivateKey always fails since a remote key set only publishes public keys.
+func (rs *RemoteStore) PrivateKey(kid string) (string, error) {
+	return "", errors.New("private keys are not available from a remote key set")
+}
+
+// PublicKey searches the cached key set for a given kid and returns the
+// public key in PEM form. A stale key is still returned while the key set is
+// fetched in the background, only a lookup of an unknown kid waits for the
+// fetch. The lock is never held while fetching so lookups of cached keys
+// don't wait on the remote.
+func (rs *RemoteStore) PublicKey(kid string) (string, error) {
+	rs.mu.Lock()
+	pem, found := rs.keys[kid]
+	stale := time.Since(rs.fetchedAt) >= rs.ttl
+	rs.mu.Unlock()
+
+	switch {
+	case found && !stale:
+		return pem, nil
+
+	case found:
+		rs.group.DoChan(refreshKey, rs.refresh)
+		return pem, nil
+	}
+
+	if _, err, _ := rs.group.Do(refreshKey, rs.refresh); err != nil {
+		return "", fmt.Errorf("refresh: %w", err)
+	}
+
+	rs.mu.Lock()
+	pem, found = rs.keys[kid]
+	rs.mu.Unlock()
+
+	if !found {
+		return "", errors.New("kid lookup failed")
+	}
+
+	return pem, nil
+}
+
+// PublicKeys returns the set of cached public keys by kid.
+func (rs *RemoteStore) PublicKeys() map[string]string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	keys := make(map[string]string, len(rs.keys))
+	for kid, pem := range rs.keys {
+		keys[kid] = pem
+	}
+
+	return keys
+}
+
+// refresh fetches the key set and replaces the cache unless the last
+// attempt was inside the minimum interval. Concurrent refreshes share a
+// single fetch.
+func (rs *RemoteStore) refresh() (any, error) {
+	rs.mu.Lock()
+	if time.Since(rs.attemptedAt) < rs.minInterval {
+		rs.mu.Unlock()
+		return nil, nil
+	}
+	rs.attemptedAt = time.Now()
+	rs.mu.Unlock()
+
+	keys, err := rs.fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	rs.mu.Lock()
+	rs.keys = keys
+	rs.fetchedAt = time.Now()
+	rs.mu.Unlock()
+
+	return nil, nil
+}
+
+// fetch retrieves the key set from the remote.
+func (rs *RemoteStore) fetch() (map[string]string, error) {
+	// Lookups of unknown kids wait on the fetch so never wait on the
+	// remote forever.
+	timeout := rs.client.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rs.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := rs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, rs.url)
+	}
+
+	// Limit the document to 1 megabyte which is far more than any
+	// reasonable key set needs.
+	var set Set
+	if err := json.UnmarshalRead(io.LimitReader(resp.Body, 1024*1024), &set, json.RejectUnknownMembers(false)); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	keys := make(map[string]string, len(set.Keys))
+	for _, key := range set.Keys {
+		pem, err := key.PublicPEM()
+		if err != nil {
+			continue
+		}
+		keys[key.KeyID] = pem
+	}
+
+	return keys, nil
+}
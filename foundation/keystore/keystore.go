@@ -4,6 +4,9 @@ package keystore
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -12,33 +15,60 @@ import (
 	"io"
 	"io/fs"
 	"path"
+	"sort"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-json-experiment/json"
 )
 
+// Metadata describes the lifetime of a key. A key is used to sign new tokens
+// once NotBefore has passed and until a newer key becomes active. A key can
+// verify tokens until RetireAt has passed. Zero values mean the key has been
+// active since forever and never retires.
+type Metadata struct {
+	NotBefore time.Time `json:"not_before"`
+	RetireAt  time.Time `json:"retire_at,omitzero"`
+}
+
 // key represents key information.
 type key struct {
 	privatePEM string
 	publicPEM  string
+	meta       Metadata
 }
 
 // KeyStore represents an in memory store implementation of the
-// KeyLookup interface for use with the auth package.
+// KeyLookup interface for use with the auth package. The set of keys can be
+// reloaded while the store is in use.
 type KeyStore struct {
+	mu    sync.RWMutex
 	store map[string]key
+	now   func() time.Time
 }
 
 // New constructs an empty KeyStore ready for use.
 func New() *KeyStore {
 	return &KeyStore{
 		store: make(map[string]key),
+		now:   time.Now,
 	}
 }
 
-// LoadRSAKeys loads a set of RSA PEM files rooted inside of a directory. The
-// name of each PEM file will be used as the key id.
-// Example: ks.LoadRSAKeys(os.DirFS("/zarf/keys/"))
+// LoadKeys loads a set of PEM encoded private key files rooted inside of a
+// directory. RSA, ECDSA P-256 and Ed25519 keys are supported, which sign
+// tokens with RS256, ES256 and EdDSA respectively. The name of each PEM file
+// will be used as the key id. An optional JSON file
+// with the same name holds the key's Metadata. The loaded keys replace the
+// current set only if every file could be read, so a failed reload leaves
+// the store unchanged.
+// Example: ks.LoadKeys(os.DirFS("/zarf/keys/"))
 // Example: /zarf/keys/54bb2165-71e1-41a6-af3e-7da4a0e1e2c1.pem
-func (ks *KeyStore) LoadRSAKeys(fsys fs.FS) error {
+// Example: /zarf/keys/54bb2165-71e1-41a6-af3e-7da4a0e1e2c1.json
+func (ks *KeyStore) LoadKeys(fsys fs.FS) error {
+	store := make(map[string]key)
+
 	fn := func(fileName string, dirEntry fs.DirEntry, err error) error {
 		if err != nil {
 			return fmt.Errorf("walkdir failure: %w", err)
@@ -52,32 +82,28 @@ func (ks *KeyStore) LoadRSAKeys(fsys fs.FS) error {
 			return nil
 		}
 
-		file, err := fsys.Open(fileName)
+		privatePEM, err := readFile(fsys, fileName)
 		if err != nil {
-			return fmt.Errorf("opening key file: %w", err)
+			return fmt.Errorf("reading auth private key: %w", err)
 		}
-		defer file.Close()
 
-		// limit PEM file size to 1 megabyte. This should be reasonable for
-		// almost any PEM file and prevents shenanigans like linking the file
-		// to /dev/random or something like that.
-		pem, err := io.ReadAll(io.LimitReader(file, 1024*1024))
+		publicPEM, err := toPublicPEM(string(privatePEM))
 		if err != nil {
-			return fmt.Errorf("reading auth private key: %w", err)
+			return fmt.Errorf("converting private PEM to public: %w", err)
 		}
 
-		privatePEM := string(pem)
-		publicPEM, err := toPublicPEM(privatePEM)
+		meta, err := readMetadata(fsys, strings.TrimSuffix(fileName, ".pem")+".json")
 		if err != nil {
-			return fmt.Errorf("converting private PEM to public: %w", err)
+			return fmt.Errorf("reading key metadata: %w", err)
 		}
 
 		key := key{
-			privatePEM: privatePEM,
+			privatePEM: string(privatePEM),
 			publicPEM:  publicPEM,
+			meta:       meta,
 		}
 
-		ks.store[strings.TrimSuffix(dirEntry.Name(), ".pem")] = key
+		store[strings.TrimSuffix(dirEntry.Name(), ".pem")] = key
 
 		return nil
 	}
@@ -86,50 +112,190 @@ func (ks *KeyStore) LoadRSAKeys(fsys fs.FS) error {
 		return fmt.Errorf("walking directory: %w", err)
 	}
 
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.store = store
+
 	return nil
 }
 
-// PrivateKey searches the key store for a given kid and returns the private key.
+// ActiveKID returns the kid of the key new tokens should be signed with. This
+// is the key with the most recent NotBefore that has passed and that has not
+// been retired.
+func (ks *KeyStore) ActiveKID() (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	now := ks.now()
+
+	var activeKID string
+	var active Metadata
+
+	for _, kid := range ks.kids() {
+		meta := ks.store[kid].meta
+
+		if meta.NotBefore.After(now) || isRetired(meta, now) {
+			continue
+		}
+
+		if activeKID == "" || meta.NotBefore.After(active.NotBefore) {
+			activeKID = kid
+			active = meta
+		}
+	}
+
+	if activeKID == "" {
+		return "", errors.New("no active key")
+	}
+
+	return activeKID, nil
+}
+
+// PrivateKey searches the key store for a given kid and returns the private
+// key. Keys that are not active yet or have been retired can't sign.
 func (ks *KeyStore) PrivateKey(kid string) (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
 	key, found := ks.store[kid]
 	if !found {
 		return "", errors.New("kid lookup failed")
 	}
 
+	now := ks.now()
+
+	if key.meta.NotBefore.After(now) {
+		return "", fmt.Errorf("kid %s is not active until %s", kid, key.meta.NotBefore.Format(time.RFC3339))
+	}
+
+	if isRetired(key.meta, now) {
+		return "", fmt.Errorf("kid %s was retired at %s", kid, key.meta.RetireAt.Format(time.RFC3339))
+	}
+
 	return key.privatePEM, nil
 }
 
-// PublicKey searches the key store for a given kid and returns the public key.
+// PublicKey searches the key store for a given kid and returns the public
+// key. Retired keys can no longer verify tokens.
 func (ks *KeyStore) PublicKey(kid string) (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
 	key, found := ks.store[kid]
 	if !found {
 		return "", errors.New("kid lookup failed")
 	}
 
+	if isRetired(key.meta, ks.now()) {
+		return "", fmt.Errorf("kid %s was retired at %s", kid, key.meta.RetireAt.Format(time.RFC3339))
+	}
+
 	return key.publicPEM, nil
 }
 
+// PublicKeys returns the public key for every kid in the key store that has
+// not been retired. Keys that are not active yet are included so other
+// services learn about them before they are used.
+func (ks *KeyStore) PublicKeys() map[string]string {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	now := ks.now()
+
+	keys := make(map[string]string, len(ks.store))
+	for kid, key := range ks.store {
+		if isRetired(key.meta, now) {
+			continue
+		}
+		keys[kid] = key.publicPEM
+	}
+
+	return keys
+}
+
+// kids returns the sorted set of kids in the store. The caller must hold
+// the lock.
+func (ks *KeyStore) kids() []string {
+	kids := make([]string, 0, len(ks.store))
+	for kid := range ks.store {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	return kids
+}
+
+func isRetired(meta Metadata, now time.Time) bool {
+	return !meta.RetireAt.IsZero() && !now.Before(meta.RetireAt)
+}
+
+func readFile(fsys fs.FS, fileName string) ([]byte, error) {
+	file, err := fsys.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// limit key file size to 1 megabyte. This should be reasonable for
+	// almost any PEM file and prevents shenanigans like linking the file
+	// to /dev/random or something like that.
+	return io.ReadAll(io.LimitReader(file, 1024*1024))
+}
+
+func readMetadata(fsys fs.FS, fileName string) (Metadata, error) {
+	data, err := readFile(fsys, fileName)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Metadata{}, nil
+		}
+		return Metadata{}, err
+	}
+
+	var meta Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return Metadata{}, fmt.Errorf("decoding %s: %w", fileName, err)
+	}
+
+	return meta, nil
+}
+
 func toPublicPEM(privatePEM string) (string, error) {
 	block, _ := pem.Decode([]byte(privatePEM))
 	if block == nil {
-		return "", errors.New("invalid key: Key must be a PEM encoded PKCS1 or PKCS8 key")
+		return "", errors.New("invalid key: Key must be a PEM encoded PKCS1, PKCS8 or SEC1 key")
 	}
 
 	var parsedKey any
-	parsedKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
 	if err != nil {
-		parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
-		if err != nil {
-			return "", err
+		if parsedKey, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			if parsedKey, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+				return "", errors.New("key is not a valid PKCS1, PKCS8 or SEC1 private key")
+			}
 		}
 	}
 
-	pk, ok := parsedKey.(*rsa.PrivateKey)
-	if !ok {
-		return "", errors.New("key is not a valid RSA private key")
+	var publicKey any
+
+	switch pk := parsedKey.(type) {
+	case *rsa.PrivateKey:
+		publicKey = &pk.PublicKey
+
+	case *ecdsa.PrivateKey:
+		if pk.Curve != elliptic.P256() {
+			return "", fmt.Errorf("unsupported curve %s: only P-256 is supported", pk.Curve.Params().Name)
+		}
+		publicKey = &pk.PublicKey
+
+	case ed25519.PrivateKey:
+		publicKey = pk.Public()
+
+	default:
+		return "", fmt.Errorf("unsupported private key type %T", parsedKey)
 	}
 
-	asn1Bytes, err := x509.MarshalPKIXPublicKey(&pk.PublicKey)
+	asn1Bytes, err := x509.MarshalPKIXPublicKey(publicKey)
 	if err != nil {
 		return "", fmt.Errorf("marshaling public key: %w", err)
 	}
@@ -0,0 +1,121 @@
+package keystore_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/foundation/keystore"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func Test_Rotation(t *testing.T) {
+	now := time.Now().UTC()
+
+	fsys := fstest.MapFS{
+		"old.pem":      {Data: genKey(t)},
+		"old.json":     {Data: meta(now.Add(-2*time.Hour), now.Add(time.Hour))},
+		"current.pem":  {Data: genKey(t)},
+		"current.json": {Data: meta(now.Add(-time.Hour), time.Time{})},
+		"next.pem":     {Data: genKey(t)},
+		"next.json":    {Data: meta(now.Add(time.Hour), time.Time{})},
+		"retired.pem":  {Data: genKey(t)},
+		"retired.json": {Data: meta(now.Add(-3*time.Hour), now.Add(-time.Minute))},
+		"es256.pem":    {Data: genECKey(t)},
+		"eddsa.pem":    {Data: genEdKey(t)},
+	}
+
+	ks := keystore.New()
+	if err := ks.LoadKeys(fsys); err != nil {
+		t.Fatalf("Should be able to load keys: %s", err)
+	}
+
+	kid, err := ks.ActiveKID()
+	if err != nil {
+		t.Fatalf("Should be able to find the active key: %s", err)
+	}
+
+	if kid != "current" {
+		t.Errorf("Should sign with the newest active key: got %s", kid)
+	}
+
+	for _, kid := range []string{"old", "current", "next", "es256", "eddsa"} {
+		if _, err := ks.PublicKey(kid); err != nil {
+			t.Errorf("Should be able to verify with %s: %s", kid, err)
+		}
+	}
+
+	if _, err := ks.PublicKey("retired"); err == nil {
+		t.Error("Should not be able to verify with a retired key")
+	}
+
+	if _, err := ks.PrivateKey("next"); err == nil {
+		t.Error("Should not be able to sign with a key before it is active")
+	}
+
+	if n := len(ks.PublicKeys()); n != 5 {
+		t.Errorf("Should publish 5 keys: got %d", n)
+	}
+
+	// A failed reload must leave the current keys in place.
+	fsys["broken.pem"] = &fstest.MapFile{Data: []byte("not a key")}
+
+	if err := ks.LoadKeys(fsys); err == nil {
+		t.Fatal("Should not be able to load a broken key")
+	}
+
+	if _, err := ks.PublicKey("current"); err != nil {
+		t.Errorf("Should keep the previous keys after a failed reload: %s", err)
+	}
+}
+
+func genKey(t *testing.T) []byte {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Should be able to generate a key: %s", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(pk)})
+}
+
+func genECKey(t *testing.T) []byte {
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate a key: %s", err)
+	}
+
+	der, err := x509.MarshalECPrivateKey(pk)
+	if err != nil {
+		t.Fatalf("Should be able to marshal the key: %s", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func genEdKey(t *testing.T) []byte {
+	_, pk, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate a key: %s", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(pk)
+	if err != nil {
+		t.Fatalf("Should be able to marshal the key: %s", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func meta(notBefore time.Time, retireAt time.Time) []byte {
+	if retireAt.IsZero() {
+		return []byte(fmt.Sprintf(`{"not_before":%q}`, notBefore.Format(time.RFC3339)))
+	}
+
+	return []byte(fmt.Sprintf(`{"not_before":%q,"retire_at":%q}`, notBefore.Format(time.RFC3339), retireAt.Format(time.RFC3339)))
+}
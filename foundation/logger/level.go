@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// String returns the name of the level, such as INFO.
+func (l Level) String() string {
+	return slog.Level(l).String()
+}
+
+// ParseLevel parses the name of a level, such as DEBUG or info.
+func ParseLevel(name string) (Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return 0, fmt.Errorf("unknown level %q", name)
+	}
+
+	return Level(level), nil
+}
+
+type ctxKey int
+
+const debugKey ctxKey = 1
+
+// WithDebug returns a context that has debug logging enabled regardless of
+// the minimum level, so the records of a single request can be traced in
+// detail without changing the level for everything.
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey, true)
+}
+
+// debugEnabled reports whether debug logging is enabled for the context.
+func debugEnabled(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+
+	v, _ := ctx.Value(debugKey).(bool)
+	return v
+}
+
+// Sampling describes how repetitive records are sampled. Within every Tick
+// the first Initial records with the same level and message are logged and
+// after that only every Thereafter record. Records at LevelWarn and above
+// and records of contexts with debug logging enabled are never sampled.
+// Sampling is disabled when Tick is zero.
+type Sampling struct {
+	Tick       time.Duration
+	Initial    int
+	Thereafter int
+}
+
+// counterSize is the number of counters records are hashed into. Records
+// with different messages can share a counter, which only makes sampling
+// start a little earlier for them.
+const counterSize = 4096
+
+type counter struct {
+	resetAt atomic.Int64
+	n       atomic.Uint64
+}
+
+// sampler counts the records of every level and message in the current tick.
+type sampler struct {
+	cfg      Sampling
+	counters [counterSize]counter
+}
+
+func newSampler(cfg Sampling) *sampler {
+	return &sampler{cfg: cfg}
+}
+
+// allow reports whether the record should be logged.
+func (s *sampler) allow(now time.Time, level slog.Level, msg string) bool {
+	h := fnv.New32a()
+	h.Write([]byte{byte(level)})
+	h.Write([]byte(msg))
+
+	c := &s.counters[h.Sum32()%counterSize]
+
+	tn := now.UnixNano()
+	resetAt := c.resetAt.Load()
+
+	var n uint64
+	switch {
+	case tn > resetAt && c.resetAt.CompareAndSwap(resetAt, tn+s.cfg.Tick.Nanoseconds()):
+		c.n.Store(1)
+		n = 1
+	default:
+		n = c.n.Add(1)
+	}
+
+	if n <= uint64(s.cfg.Initial) {
+		return true
+	}
+
+	return s.cfg.Thereafter > 0 && (n-uint64(s.cfg.Initial))%uint64(s.cfg.Thereafter) == 0
+}
+
+// control holds the minimum level and sampling of a logger. The settings
+// can be changed while logging since the handlers share the control.
+type control struct {
+	level   slog.LevelVar
+	sampler atomic.Pointer[sampler]
+}
+
+func newControl(minLevel Level) *control {
+	var c control
+	c.level.Set(slog.Level(minLevel))
+
+	return &c
+}
+
+// controlHandler provides a wrapper around the slog handler which applies
+// the minimum level, debug logging of single requests and sampling.
+type controlHandler struct {
+	handler slog.Handler
+	control *control
+}
+
+func newControlHandler(handler slog.Handler, control *control) *controlHandler {
+	return &controlHandler{
+		handler: handler,
+		control: control,
+	}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+// Records below the minimum level are handled when the context has debug
+// logging enabled.
+func (h *controlHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.control.level.Level() || debugEnabled(ctx)
+}
+
+// WithAttrs returns a new handler whose attributes consists of h's
+// attributes followed by attrs.
+func (h *controlHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &controlHandler{handler: h.handler.WithAttrs(attrs), control: h.control}
+}
+
+// WithGroup returns a new handler with the given group appended to the
+// receiver's existing groups.
+func (h *controlHandler) WithGroup(name string) slog.Handler {
+	return &controlHandler{handler: h.handler.WithGroup(name), control: h.control}
+}
+
+// Handle drops the records sampled out and passes the others on.
+func (h *controlHandler) Handle(ctx context.Context, r slog.Record) error {
+	if s := h.control.sampler.Load(); s != nil && r.Level < slog.LevelWarn && !debugEnabled(ctx) {
+		if !s.allow(r.Time, r.Level, r.Message) {
+			return nil
+		}
+	}
+
+	return h.handler.Handle(ctx, r)
+}
@@ -0,0 +1,106 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Level(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	ctx := context.Background()
+
+	log.Debug(ctx, "hidden")
+	if buf.Len() != 0 {
+		t.Fatalf("Should not log below the minimum level: got %s", buf.String())
+	}
+
+	log.Debug(logger.WithDebug(ctx), "request")
+	if !strings.Contains(buf.String(), `"msg":"request"`) {
+		t.Fatalf("Should log debug records of a debug context: got %s", buf.String())
+	}
+	buf.Reset()
+
+	log.SetLevel(logger.LevelDebug)
+	if log.Level() != logger.LevelDebug {
+		t.Fatalf("Should get level %s: got %s", logger.LevelDebug, log.Level())
+	}
+
+	log.Debug(ctx, "shown")
+	if !strings.Contains(buf.String(), `"msg":"shown"`) {
+		t.Fatalf("Should log debug records after changing the level: got %s", buf.String())
+	}
+}
+
+func Test_ParseLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		exp  logger.Level
+		fail bool
+	}{
+		{name: "DEBUG", exp: logger.LevelDebug},
+		{name: "info", exp: logger.LevelInfo},
+		{name: " WARN ", exp: logger.LevelWarn},
+		{name: "ERROR", exp: logger.LevelError},
+		{name: "LOUD", fail: true},
+	}
+
+	for _, tt := range tests {
+		level, err := logger.ParseLevel(tt.name)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("Should not be able to parse %q: got %s", tt.name, level)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Should be able to parse %q: %s", tt.name, err)
+			continue
+		}
+
+		if level != tt.exp {
+			t.Errorf("Should get %s for %q: got %s", tt.exp, tt.name, level)
+		}
+	}
+}
+
+func Test_Sampling(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+	log.SetSampling(logger.Sampling{Tick: time.Hour, Initial: 3, Thereafter: 5})
+
+	ctx := context.Background()
+
+	for i := 0; i < 13; i++ {
+		log.Info(ctx, "query")
+		log.Warn(ctx, "slow")
+	}
+
+	// The first 3 records are logged and then every 5th, the 8th and 13th.
+	if n := strings.Count(buf.String(), `"msg":"query"`); n != 5 {
+		t.Errorf("Should log 5 sampled records: got %d", n)
+	}
+
+	if n := strings.Count(buf.String(), `"msg":"slow"`); n != 13 {
+		t.Errorf("Should not sample warnings: got %d", n)
+	}
+
+	buf.Reset()
+	log.Info(logger.WithDebug(ctx), "query")
+	if buf.Len() == 0 {
+		t.Error("Should not sample records of a debug context.")
+	}
+
+	log.SetSampling(logger.Sampling{})
+	buf.Reset()
+	log.Info(ctx, "query")
+	if buf.Len() == 0 {
+		t.Error("Should log every record when sampling is disabled.")
+	}
+}
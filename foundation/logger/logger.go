@@ -21,6 +21,8 @@ type TraceIDFn func(ctx context.Context) string
 type Logger struct {
 	handler   slog.Handler
 	traceIDFn TraceIDFn
+	redactor  *redactor
+	control   *control
 }
 
 // New constructs a new log for application use.
@@ -39,6 +41,50 @@ func NewWithHandler(h slog.Handler) *Logger {
 	return &Logger{handler: h}
 }
 
+// SetRedactKeys sets the keys of the attributes whose values are replaced
+// with RedactedValue, in addition to the DefaultRedactKeys. The keys also
+// apply to the fields and map entries of logged values.
+func (log *Logger) SetRedactKeys(keys ...string) {
+	if log.redactor == nil {
+		return
+	}
+
+	log.redactor.set(append(append([]string{}, DefaultRedactKeys...), keys...))
+}
+
+// SetLevel sets the minimum level of the records that are logged.
+func (log *Logger) SetLevel(level Level) {
+	if log.control == nil {
+		return
+	}
+
+	log.control.level.Set(slog.Level(level))
+}
+
+// Level returns the minimum level of the records that are logged.
+func (log *Logger) Level() Level {
+	if log.control == nil {
+		return LevelInfo
+	}
+
+	return Level(log.control.level.Level())
+}
+
+// SetSampling sets how repetitive records are sampled. A zero Tick disables
+// sampling.
+func (log *Logger) SetSampling(s Sampling) {
+	if log.control == nil {
+		return
+	}
+
+	if s.Tick <= 0 {
+		log.control.sampler.Store(nil)
+		return
+	}
+
+	log.control.sampler.Store(newSampler(s))
+}
+
 // NewStdLogger returns a standard library Logger that wraps the slog Logger.
 func NewStdLogger(logger *Logger, level Level) *log.Logger {
 	return slog.NewLogLogger(logger.handler, slog.Level(level))
@@ -119,8 +165,10 @@ func new(w io.Writer, minLevel Level, serviceName string, traceIDFn TraceIDFn, e
 		return a
 	}
 
-	// Construct the slog JSON handler for use.
-	handler := slog.Handler(slog.NewJSONHandler(w, &slog.HandlerOptions{AddSource: true, Level: slog.Level(minLevel), ReplaceAttr: f}))
+	// Construct the slog JSON handler for use. The minimum level is applied
+	// by the control handler so it can be changed and overridden for debug
+	// logging of single requests.
+	handler := slog.Handler(slog.NewJSONHandler(w, &slog.HandlerOptions{AddSource: true, Level: slog.LevelDebug, ReplaceAttr: f}))
 
 	// If events are to be processed, wrap the JSON handler around the custom
 	// log handler.
@@ -128,6 +176,14 @@ func new(w io.Writer, minLevel Level, serviceName string, traceIDFn TraceIDFn, e
 		handler = newLogHandler(handler, events)
 	}
 
+	// Redact the values that must not be logged before anything else sees
+	// the record, including the event functions.
+	redactor := newRedactor(DefaultRedactKeys)
+	handler = newRedactHandler(handler, redactor)
+
+	control := newControl(minLevel)
+	handler = newControlHandler(handler, control)
+
 	// Attributes to add to every log.
 	attrs := []slog.Attr{
 		{Key: "service", Value: slog.StringValue(serviceName)},
@@ -139,5 +195,7 @@ func new(w io.Writer, minLevel Level, serviceName string, traceIDFn TraceIDFn, e
 	return &Logger{
 		handler:   handler,
 		traceIDFn: traceIDFn,
+		redactor:  redactor,
+		control:   control,
 	}
 }
@@ -0,0 +1,305 @@
+package logger
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// RedactedValue replaces the values that must not be logged.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactKeys are the attribute keys redacted by every logger. Keys are
+// matched without case, underscores and dashes so passwordConfirm matches
+// password_confirm.
+var DefaultRedactKeys = []string{
+	"password",
+	"passwordconfirm",
+	"passwordhash",
+	"token",
+	"secret",
+	"authorization",
+	"email",
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	stringerType      = reflect.TypeFor[fmt.Stringer]()
+	errorType         = reflect.TypeFor[error]()
+)
+
+// Redacts reports whether the struct field is tagged with log:"redact" and
+// its value must not be logged.
+func Redacts(fld reflect.StructField) bool {
+	return fld.Tag.Get("log") == "redact"
+}
+
+// Redact returns the value with the struct fields tagged with log:"redact"
+// replaced, so the value can be formatted into a message or error. Structs
+// holding such fields are returned as maps keyed by the json name of the
+// fields. Values without such fields are returned as they are.
+func Redact(v any) any {
+	rv, changed := redactValue(reflect.ValueOf(v), nil)
+	if !changed {
+		return v
+	}
+
+	return rv
+}
+
+// redactor holds the keys of the attributes to redact. The keys can be
+// changed while logging since the handlers share the redactor.
+type redactor struct {
+	keys atomic.Pointer[map[string]struct{}]
+}
+
+func newRedactor(keys []string) *redactor {
+	var r redactor
+	r.set(keys)
+
+	return &r
+}
+
+// set replaces the keys to redact.
+func (r *redactor) set(keys []string) {
+	m := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		m[normalizeKey(key)] = struct{}{}
+	}
+
+	r.keys.Store(&m)
+}
+
+// attr returns the attribute with the values that must not be logged
+// replaced.
+func (r *redactor) attr(a slog.Attr) slog.Attr {
+	return redactAttr(a, *r.keys.Load())
+}
+
+func redactAttr(a slog.Attr, keys map[string]struct{}) slog.Attr {
+	if _, exists := keys[normalizeKey(a.Key)]; exists {
+		return slog.String(a.Key, RedactedValue)
+	}
+
+	a.Value = a.Value.Resolve()
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		group := a.Value.Group()
+
+		attrs := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			attrs[i] = redactAttr(ga, keys)
+		}
+
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+
+	case slog.KindAny:
+		if v, changed := redactValue(reflect.ValueOf(a.Value.Any()), keys); changed {
+			return slog.Any(a.Key, v)
+		}
+	}
+
+	return a
+}
+
+// redactValue walks the value replacing the struct fields tagged with
+// log:"redact" and the struct fields and map entries named by the keys. It
+// reports whether anything was replaced, the original value is kept as it
+// is when nothing was.
+func redactValue(v reflect.Value, keys map[string]struct{}) (any, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+
+	if opaque(v.Type()) {
+		return nil, false
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, false
+		}
+		return redactValue(v.Elem(), keys)
+
+	case reflect.Struct:
+		return redactStruct(v, keys)
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+
+		m := make(map[string]any, v.Len())
+		var changed bool
+
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+
+			if _, exists := keys[normalizeKey(key)]; exists {
+				m[key] = RedactedValue
+				changed = true
+				continue
+			}
+
+			rv, ok := redactValue(iter.Value(), keys)
+			if !ok {
+				m[key] = iter.Value().Interface()
+				continue
+			}
+
+			m[key] = rv
+			changed = true
+		}
+
+		return m, changed
+
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil, false
+		}
+
+		s := make([]any, v.Len())
+		var changed bool
+
+		for i := range s {
+			rv, ok := redactValue(v.Index(i), keys)
+			if !ok {
+				s[i] = v.Index(i).Interface()
+				continue
+			}
+
+			s[i] = rv
+			changed = true
+		}
+
+		return s, changed
+	}
+
+	return nil, false
+}
+
+func redactStruct(v reflect.Value, keys map[string]struct{}) (any, bool) {
+	t := v.Type()
+
+	m := make(map[string]any, t.NumField())
+	var changed bool
+
+	for i := 0; i < t.NumField(); i++ {
+		fld := t.Field(i)
+		if !fld.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(fld.Tag.Get("json"), ",")
+		if name == "-" {
+			name = ""
+		}
+		if name == "" {
+			name = fld.Name
+		}
+
+		if _, exists := keys[normalizeKey(name)]; exists || Redacts(fld) {
+			m[name] = RedactedValue
+			changed = true
+			continue
+		}
+
+		rv, ok := redactValue(v.Field(i), keys)
+		if !ok {
+			m[name] = v.Field(i).Interface()
+			continue
+		}
+
+		m[name] = rv
+		changed = true
+	}
+
+	return m, changed
+}
+
+// opaque reports whether values of the type format themselves and are not
+// walked into.
+func opaque(t reflect.Type) bool {
+	if t == timeType {
+		return true
+	}
+
+	if t.Kind() == reflect.Interface {
+		return false
+	}
+
+	for _, it := range []reflect.Type{textMarshalerType, stringerType, errorType} {
+		if t.Implements(it) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeKey returns the key in the form keys are matched in.
+func normalizeKey(key string) string {
+	key = strings.ToLower(key)
+	key = strings.ReplaceAll(key, "_", "")
+	key = strings.ReplaceAll(key, "-", "")
+
+	return key
+}
+
+// redactHandler provides a wrapper around the slog handler which replaces
+// the values that must not be logged before the record is handled.
+type redactHandler struct {
+	handler  slog.Handler
+	redactor *redactor
+}
+
+func newRedactHandler(handler slog.Handler, redactor *redactor) *redactHandler {
+	return &redactHandler{
+		handler:  handler,
+		redactor: redactor,
+	}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// WithAttrs returns a new handler whose attributes consists of h's
+// attributes followed by the redacted attrs.
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redactor.attr(a)
+	}
+
+	return &redactHandler{handler: h.handler.WithAttrs(redacted), redactor: h.redactor}
+}
+
+// WithGroup returns a new handler with the given group appended to the
+// receiver's existing groups.
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{handler: h.handler.WithGroup(name), redactor: h.redactor}
+}
+
+// Handle replaces the values of the record that must not be logged and
+// passes the record on.
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactor.attr(a))
+		return true
+	})
+
+	return h.handler.Handle(ctx, redacted)
+}
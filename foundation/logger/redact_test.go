@@ -0,0 +1,87 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/fadhilijuma/gateone-service/foundation/logger"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type credentials struct {
+	Name     string `json:"name" log:"redact"`
+	Password string `json:"password"`
+	Region   string `json:"region"`
+}
+
+type account struct {
+	ID    string       `json:"id"`
+	Creds *credentials `json:"creds"`
+}
+
+func Test_Redact(t *testing.T) {
+	var buf bytes.Buffer
+
+	var events []logger.Record
+	log := logger.NewWithEvents(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" }, logger.Events{
+		Info: func(ctx context.Context, r logger.Record) {
+			events = append(events, r)
+		},
+	})
+
+	acct := account{
+		ID:    "acct-1",
+		Creds: &credentials{Name: "Jane Doe", Password: "hunter2", Region: "north"},
+	}
+
+	log.Info(context.Background(), "test",
+		"account", acct,
+		"token", "eyJhbGci",
+		slog.Group("request", "email", "jane@example.com", "path", "/v1/users"),
+		"headers", map[string]string{"Authorization": "Bearer eyJhbGci", "Accept": "*/*"},
+		"patient_ssn", "123-45-6789",
+	)
+
+	out := buf.String()
+
+	for _, secret := range []string{"Jane Doe", "hunter2", "eyJhbGci", "jane@example.com"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("Should not log %q: got %s", secret, out)
+		}
+	}
+
+	for _, kept := range []string{"acct-1", "north", "/v1/users", "*/*", "123-45-6789", logger.RedactedValue} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("Should log %q: got %s", kept, out)
+		}
+	}
+
+	if len(events) != 1 || events[0].Attributes["token"] != logger.RedactedValue {
+		t.Errorf("Should pass the redacted record to the events: got %+v", events)
+	}
+
+	t.Run("keys", func(t *testing.T) {
+		buf.Reset()
+		log.SetRedactKeys("patient_ssn")
+
+		log.Info(context.Background(), "test", "patientSSN", "123-45-6789", "password", "hunter2")
+
+		if out := buf.String(); strings.Contains(out, "123-45-6789") || strings.Contains(out, "hunter2") {
+			t.Errorf("Should redact the configured and the default keys: got %s", out)
+		}
+	})
+
+	t.Run("format", func(t *testing.T) {
+		s := fmt.Sprintf("%+v", logger.Redact(acct))
+
+		if strings.Contains(s, "Jane Doe") || !strings.Contains(s, "north") || !strings.Contains(s, "hunter2") {
+			t.Errorf("Should only redact the tagged fields: got %s", s)
+		}
+
+		if s := fmt.Sprintf("%+v", logger.Redact("plain")); s != "plain" {
+			t.Errorf("Should keep values without tagged fields: got %s", s)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+// Package tracing provides support for configuring OpenTelemetry tracing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// Set of exporters spans can be sent to.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Config represents the settings for tracing. Endpoint is the URL of an
+// OTLP/HTTP collector, for example http://localhost:4318/v1/traces, and is
+// only used by the otlp exporter. Probability is the ratio of the traces
+// started by this service that are sampled, traces started by a caller
+// follow the decision of the caller. Writer is where the stdout exporter
+// writes the spans and defaults to os.Stdout.
+type Config struct {
+	ServiceName string
+	Exporter    string
+	Endpoint    string
+	Probability float64
+	Writer      io.Writer
+}
+
+// NewProvider constructs a tracer provider exporting the sampled spans to
+// the configured exporter, and installs it with the W3C trace context
+// propagator as the global provider. Spans are still created, with trace
+// ids for the logs, when no exporter is configured. The provider must be
+// shut down to flush the spans that are not exported yet.
+func NewProvider(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
+	if cfg.Probability < 0 || cfg.Probability > 1 {
+		return nil, fmt.Errorf("probability %v is not between 0 and 1", cfg.Probability)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("resource: %w", err)
+	}
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Probability))),
+	}
+
+	switch cfg.Exporter {
+	case ExporterNone, "":
+
+	case ExporterStdout:
+		w := cfg.Writer
+		if w == nil {
+			w = os.Stdout
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return nil, fmt.Errorf("stdout exporter: %w", err)
+		}
+
+		// Spans are written as they end so nothing is held back locally.
+		options = append(options, sdktrace.WithSyncer(exporter))
+
+	case ExporterOTLP:
+		u, err := url.Parse(cfg.Endpoint)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("endpoint %q is not a valid url", cfg.Endpoint)
+		}
+
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		if err != nil {
+			return nil, fmt.Errorf("otlp exporter: %w", err)
+		}
+
+		options = append(options, sdktrace.WithBatcher(exporter))
+
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", cfg.Exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider, nil
+}
@@ -0,0 +1,104 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/fadhilijuma/gateone-service/foundation/tracing"
+	"github.com/fadhilijuma/gateone-service/foundation/web"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+type span struct {
+	Name        string
+	SpanContext struct {
+		TraceID string
+	}
+	Parent struct {
+		SpanID string
+	}
+}
+
+func Test_NewProvider(t *testing.T) {
+	var buf bytes.Buffer
+
+	provider, err := tracing.NewProvider(context.Background(), tracing.Config{
+		ServiceName: "tracing-test",
+		Exporter:    tracing.ExporterStdout,
+		Probability: 1,
+		Writer:      &buf,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct the provider: %s", err)
+	}
+
+	app := web.NewApp(make(chan os.Signal, 1), provider.Tracer("tracing-test"))
+
+	var traceID string
+	app.Handle(http.MethodGet, "v1", "/things", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		traceID = web.GetTraceID(ctx)
+
+		_, span := web.AddSpan(ctx, "handler")
+		span.End()
+
+		return web.Respond(ctx, w, "ok", http.StatusOK)
+	})
+
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/things", nil))
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Should be able to shut down the provider: %s", err)
+	}
+
+	if !strings.Contains(buf.String(), "tracing-test") {
+		t.Error("Should export the service name with the spans.")
+	}
+
+	spans := make(map[string]span)
+	dec := jsontext.NewDecoder(&buf)
+	for {
+		var s span
+		if err := json.UnmarshalDecode(dec, &s, json.MatchCaseInsensitiveNames(true)); err != nil {
+			break
+		}
+		spans[s.Name] = s
+	}
+
+	handler, exists := spans["handler"]
+	if !exists {
+		t.Fatalf("Should export the span of the handler: got %v", spans)
+	}
+
+	if handler.SpanContext.TraceID != traceID {
+		t.Errorf("Should use the trace id as the request trace id: got %s, exp %s", traceID, handler.SpanContext.TraceID)
+	}
+
+	if request := spans["request"]; request.SpanContext.TraceID != handler.SpanContext.TraceID {
+		t.Errorf("Should add the span of the handler to the trace of the request: got %v", spans)
+	}
+}
+
+func Test_NewProviderConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  tracing.Config
+	}{
+		{name: "probability", cfg: tracing.Config{Exporter: tracing.ExporterNone, Probability: 2}},
+		{name: "exporter", cfg: tracing.Config{Exporter: "zipkin", Probability: 1}},
+		{name: "endpoint", cfg: tracing.Config{Exporter: tracing.ExporterOTLP, Endpoint: "localhost", Probability: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tracing.NewProvider(context.Background(), tt.cfg); err == nil {
+				t.Error("Should not be able to construct the provider.")
+			}
+		})
+	}
+}
@@ -13,12 +13,14 @@ type ctxKey int
 
 const key ctxKey = 1
 
-// Values represent state for each request.
+// Values represent state for each request. Route is the pattern of the route
+// handling the request, such as /v1/patients/{patient_id}.
 type Values struct {
 	TraceID    string
 	Tracer     trace.Tracer
 	Now        time.Time
 	StatusCode int
+	Route      string
 }
 
 // GetValues returns the values from the context.
@@ -5,18 +5,40 @@ package web
 // direct to any given app Handler.
 type MidHandler func(Handler) Handler
 
+// Wrap implements the Middleware interface. A nil MidHandler leaves the
+// handler as it is.
+func (mw MidHandler) Wrap(handler Handler) Handler {
+	if mw == nil {
+		return handler
+	}
+
+	return mw(handler)
+}
+
+// Middleware is the behavior of the middleware that can be bound to a route.
+type Middleware interface {
+	Wrap(handler Handler) Handler
+}
+
+// DocMidHandler is a MidHandler that also documents the routes it is bound
+// to, so the documentation of a route follows from the middleware it runs.
+// Describe is applied after the documentation set with Route.Describe.
+type DocMidHandler struct {
+	MidHandler
+	Describe func(doc *RouteDoc)
+}
+
 // wrapMiddleware creates a new handler by wrapping middleware around a final
 // handler. The middlewares' Handlers will be executed by requests in the order
 // they are provided.
-func wrapMiddleware(mw []MidHandler, handler Handler) Handler {
+func wrapMiddleware[M Middleware](mw []M, handler Handler) Handler {
 
 	// Loop backwards through the middleware invoking each one. Replace the
 	// handler with the new wrapped handler. Looping backwards ensures that the
 	// first middleware of the slice is the first to be executed by requests.
 	for i := len(mw) - 1; i >= 0; i-- {
-		mwFunc := mw[i]
-		if mwFunc != nil {
-			handler = mwFunc(handler)
+		if mwFunc := mw[i]; any(mwFunc) != nil {
+			handler = mwFunc.Wrap(handler)
 		}
 	}
 
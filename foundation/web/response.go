@@ -34,3 +34,22 @@ func Respond(ctx context.Context, w http.ResponseWriter, data any, statusCode in
 
 	return nil
 }
+
+// RespondRaw sends data that is already encoded to the client.
+func RespondRaw(ctx context.Context, w http.ResponseWriter, contentType string, data []byte, statusCode int) error {
+	ctx, span := AddSpan(ctx, "foundation.web.response", attribute.Int("status", statusCode))
+	defer span.End()
+
+	setStatusCode(ctx, statusCode)
+
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.WriteHeader(statusCode)
+
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	return nil
+}
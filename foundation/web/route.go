@@ -0,0 +1,65 @@
+package web
+
+// Set of security schemes a route can require.
+const (
+	SecurityNone   = ""
+	SecurityBearer = "bearer"
+	SecurityBasic  = "basic"
+)
+
+// Route represents a route bound to the App and the documentation describing
+// it. Routes are recorded so documents like an OpenAPI specification can be
+// generated from what is actually bound.
+type Route struct {
+	Method string
+	Path   string
+	Doc    RouteDoc
+
+	describers []func(doc *RouteDoc)
+}
+
+// RouteDoc describes a route. Request and Response hold a value of the model
+// type the route decodes and responds with, and are nil when there is no
+// body. Status is the status code of a successful response. Rule is the
+// authorization rule the route is protected by and is set by the middleware
+// enforcing it. Query lists the query string parameters the route reads.
+type RouteDoc struct {
+	Summary  string
+	Request  any
+	Response any
+	Status   int
+	Security string
+	Rule     string
+	Query    []string
+}
+
+// Describe sets the documentation of the route. What the middleware bound to
+// the route documents is kept.
+func (rt *Route) Describe(doc RouteDoc) {
+	rt.Doc = doc
+
+	for _, describe := range rt.describers {
+		describe(&rt.Doc)
+	}
+}
+
+// Routes returns the routes bound to the App in the order they were bound.
+func (a *App) Routes() []Route {
+	routes := make([]Route, len(a.routes))
+	for i, rt := range a.routes {
+		routes[i] = *rt
+	}
+
+	return routes
+}
+
+// addRoute records a route bound to the App.
+func (a *App) addRoute(method string, path string) *Route {
+	rt := Route{
+		Method: method,
+		Path:   path,
+	}
+	a.routes = append(a.routes, &rt)
+
+	return &rt
+}
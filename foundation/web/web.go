@@ -12,6 +12,7 @@ import (
 
 	"github.com/google/uuid"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // A Handler is a type that handles a http request within our own little mini
@@ -25,11 +26,15 @@ type App struct {
 	mux      *http.ServeMux
 	otmux    http.Handler
 	shutdown chan os.Signal
+	tracer   trace.Tracer
 	mw       []MidHandler
+	routes   []*Route
 }
 
 // NewApp creates an App value that handle a set of routes for the application.
-func NewApp(shutdown chan os.Signal, mw ...MidHandler) *App {
+// The tracer is used to add spans to the trace of each request and can be nil
+// when tracing is not needed.
+func NewApp(shutdown chan os.Signal, tracer trace.Tracer, mw ...MidHandler) *App {
 
 	// Create an OpenTelemetry HTTP Handler which wraps our router. This will start
 	// the initial span and annotate it with information about the request/trusted.
@@ -44,6 +49,7 @@ func NewApp(shutdown chan os.Signal, mw ...MidHandler) *App {
 		mux:      mux,
 		otmux:    otelhttp.NewHandler(mux, "request"),
 		shutdown: shutdown,
+		tracer:   tracer,
 		mw:       mw,
 	}
 }
@@ -83,13 +89,20 @@ func (a *App) EnableCORS(mw MidHandler) {
 
 // HandleNoMiddleware sets a handler function for a given HTTP method and path pair
 // to the application server mux. Does not include the application middleware or
-// OTEL tracing.
-func (a *App) HandleNoMiddleware(method string, group string, path string, handler Handler) {
+// OTEL tracing. The returned route can be used to describe it.
+func (a *App) HandleNoMiddleware(method string, group string, path string, handler Handler) *Route {
+	finalPath := path
+	if group != "" {
+		finalPath = "/" + group + path
+	}
+	rt := a.addRoute(method, finalPath)
+
 	h := func(w http.ResponseWriter, r *http.Request) {
 		v := Values{
 			TraceID: uuid.NewString(),
 			Tracer:  nil,
 			Now:     time.Now().UTC(),
+			Route:   rt.Path,
 		}
 		ctx := setValues(r.Context(), &v)
 
@@ -101,24 +114,48 @@ func (a *App) HandleNoMiddleware(method string, group string, path string, handl
 		}
 	}
 
-	finalPath := path
-	if group != "" {
-		finalPath = "/" + group + path
-	}
 	finalPath = fmt.Sprintf("%s %s", method, finalPath)
 
 	a.mux.HandleFunc(finalPath, h)
+
+	return rt
 }
 
 // Handle sets a handler function for a given HTTP method and path pair
-// to the application server mux.
-func (a *App) Handle(method string, group string, path string, handler Handler, mw ...MidHandler) {
+// to the application server mux. The returned route can be used to describe
+// it and is documented further by the middleware that documents routes.
+func (a *App) Handle(method string, group string, path string, handler Handler, mw ...Middleware) *Route {
 	handler = wrapMiddleware(mw, handler)
 	handler = wrapMiddleware(a.mw, handler)
 
+	finalPath := path
+	if group != "" {
+		finalPath = "/" + group + path
+	}
+	rt := a.addRoute(method, finalPath)
+
+	for _, m := range mw {
+		if dm, ok := m.(DocMidHandler); ok && dm.Describe != nil {
+			rt.describers = append(rt.describers, dm.Describe)
+			dm.Describe(&rt.Doc)
+		}
+	}
+
 	h := func(w http.ResponseWriter, r *http.Request) {
 
-		ctx := context.Background()
+		// The request context isn't used so a client going away doesn't
+		// cancel work in progress. The span started for the request is
+		// carried over so the spans added by handlers are part of its trace.
+		span := trace.SpanFromContext(r.Context())
+		ctx := trace.ContextWithSpan(context.Background(), span)
+
+		v := Values{
+			TraceID: traceID(span),
+			Tracer:  a.tracer,
+			Now:     time.Now().UTC(),
+			Route:   rt.Path,
+		}
+		ctx = setValues(ctx, &v)
 
 		if err := handler(ctx, w, r); err != nil {
 			if validateError(err) {
@@ -128,13 +165,22 @@ func (a *App) Handle(method string, group string, path string, handler Handler,
 		}
 	}
 
-	finalPath := path
-	if group != "" {
-		finalPath = "/" + group + path
-	}
 	finalPath = fmt.Sprintf("%s %s", method, finalPath)
 
 	a.mux.HandleFunc(finalPath, h)
+
+	return rt
+}
+
+// traceID returns the id of the trace the span belongs to, so the logs of a
+// request can be found with its trace. A random id is used when there is no
+// trace.
+func traceID(span trace.Span) string {
+	if sc := span.SpanContext(); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+
+	return uuid.NewString()
 }
 
 // validateError validates the error for special conditions that do not